@@ -10,6 +10,10 @@ package main
 import (
 	"context"
 	"flag"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/intel/oim/pkg/log"
@@ -24,12 +28,22 @@ var (
 	spdk              = flag.String("spdk", "/var/tmp/vhost.sock", "SPDK VHost RPC socket path")
 	vhost             = flag.String("vhost-scsi-controller", "vhost.0", "SPDK VirtIO SCSI controller name")
 	vhostDev          = flag.String("vm-vhost-device", "", "the PCI address of the SCSI controller in a VM ([domain:]bus:device.function), partial address allowed (:.3)")
+	moreSCSI          = flag.String("vhost-scsi-controllers", "", "comma-separated list of additional <SPDK vhost-scsi controller name>=<PCI address in the VM> pairs which get used once all SCSI targets of -vhost-scsi-controller are in use")
+	vhostBLK          = flag.String("vhost-blk-controllers", "", "comma-separated list of <SPDK vhost-blk controller name>=<PCI address in the VM> pairs; when set, volumes are mapped via vhost-blk instead of vhost-scsi")
+	nvmeof            = flag.String("nvmeof-tcp-address", "", "<IP address>:<port> on which volumes are exported via NVMe/TCP instead of vhost, must be reachable from the host")
+	nvmeofAnyHost     = flag.Bool("nvmeof-allow-any-host", false, "allow all hosts to connect to the NVMe/TCP subsystems instead of just the host which mapped the volume; insecure, any host which can reach -nvmeof-tcp-address can access all volumes")
+	iscsi             = flag.String("iscsi-portal", "", "<IP address>:<port> on which volumes are exported via iSCSI instead of vhost, must be reachable from the host")
+	iscsiAnyInitiator = flag.Bool("iscsi-allow-any-initiator", false, "allow all initiators to log into the iSCSI targets instead of just the one which mapped the volume; insecure, any initiator which can reach -iscsi-portal can access all volumes without CHAP")
+	journal           = flag.String("journal", "", "file in which mapped volumes are recorded and from which they get restored after a restart, disabled when empty")
 	controllerID      = flag.String("controllerid", "", "unique id for this controller instance")
 	controllerAddress = flag.String("controller-address", "ipv4:///oim-controller:8999", "external gRPC name for use with grpc.Dial that corresponds to the endpoint")
 	registry          = flag.String("registry", "", "gRPC name that connects to the OIM registry, empty disables registration")
 	ca                = flag.String("ca", "", "the required CA's .crt file which is used for verifying connections to the registry")
 	key               = flag.String("key", "", "the base name of the required .key and .crt files that authenticate and authorize the registry client")
+	crl               = flag.String("crl", "", "a certificate revocation list signed by the CA, reloaded automatically when modified; a revoked registry certificate is rejected")
 	registryDelay     = flag.Duration("registry-delay", time.Minute, "determines how long the controller waits before registering at the OIM registry")
+	registryTTL       = flag.Duration("registry-ttl", 0, "determines how long the OIM registry keeps the controller address after the last registration, 0 for three times the registry delay")
+	metricsAddr       = flag.String("metrics-address", "", "host:port on which Prometheus metrics are served via HTTP under /metrics, disabled when empty")
 	_                 = log.InitSimpleFlags()
 )
 
@@ -51,7 +65,16 @@ func main() {
 	}
 	defer closer.Close()
 
-	transportCreds, err := oimcommon.LoadTLS(*ca, *key, "component.registry")
+	if *metricsAddr != "" {
+		metrics, err := oimcommon.StartMetricsServer(context.Background(), *metricsAddr)
+		if err != nil {
+			logger.Fatalw("serve metrics", "error", err)
+		}
+		defer metrics.Close()
+	}
+
+	ctx := context.Background()
+	transportCreds, err := oimcommon.WatchTLS(ctx, *ca, *key, oimcommon.RegistryIdentity.String(), oimcommon.WithCRL(*crl))
 	if err != nil {
 		logger.Fatalw("load TLS certs", "error", err)
 	}
@@ -64,8 +87,42 @@ func main() {
 		oimcontroller.WithControllerAddress(*controllerAddress),
 		oimcontroller.WithRegistry(*registry),
 		oimcontroller.WithRegistryDelay(*registryDelay),
+		oimcontroller.WithRegistryTTL(*registryTTL),
 		oimcontroller.WithCreds(transportCreds),
 	}
+	if *journal != "" {
+		options = append(options, oimcontroller.WithJournal(*journal))
+	}
+	if *nvmeof != "" {
+		options = append(options, oimcontroller.WithNVMeoF(*nvmeof))
+	}
+	if *nvmeofAnyHost {
+		options = append(options, oimcontroller.WithNVMeoFAllowAnyHost())
+	}
+	if *iscsi != "" {
+		options = append(options, oimcontroller.WithISCSI(*iscsi))
+	}
+	if *iscsiAnyInitiator {
+		options = append(options, oimcontroller.WithISCSIAllowAnyInitiator())
+	}
+	if *moreSCSI != "" {
+		for _, pair := range strings.Split(*moreSCSI, ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 {
+				logger.Fatalf("Invalid -vhost-scsi-controllers entry, expected <name>=<PCI address>: %s\n", pair)
+			}
+			options = append(options, oimcontroller.WithVHostSCSI(parts[0], parts[1]))
+		}
+	}
+	if *vhostBLK != "" {
+		for _, pair := range strings.Split(*vhostBLK, ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 {
+				logger.Fatalf("Invalid -vhost-blk-controllers entry, expected <name>=<PCI address>: %s\n", pair)
+			}
+			options = append(options, oimcontroller.WithVHostBLK(parts[0], parts[1]))
+		}
+	}
 	controller, err := oimcontroller.New(options...)
 	if err != nil {
 		logger.Fatalf("Failed to initialize server: %s\n", err)
@@ -75,7 +132,18 @@ func main() {
 	}
 	defer controller.Close()
 	server, service := controller.Server(*endpoint)
-	if err := server.Run(context.Background(), service); err != nil {
+
+	// Shut down cleanly when asked to, so that the controller
+	// gets removed from the registry by controller.Close.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	if err := server.Start(ctx, service); err != nil {
 		logger.Fatalf("Failed to run server: %s\n", err)
 	}
+	go func() {
+		sig := <-signals
+		logger.Infow("shutting down", "signal", sig)
+		server.Stop(ctx)
+	}()
+	server.Wait(ctx)
 }
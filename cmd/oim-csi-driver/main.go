@@ -29,6 +29,7 @@ var (
 	controllerID       = flag.String("controller-id", "", "The ID under which the OIM controller can be found in the registry.")
 	emulate            = flag.String("emulate", "", "name of CSI driver to emulate for node operations")
 	csiversion         = flag.String("csiversion", "1.0", "CSI version that is to be implemented by the driver (1.0 or 0.3)")
+	metricsAddr        = flag.String("metrics-address", "", "host:port on which Prometheus metrics are served via HTTP under /metrics, disabled when empty")
 	_                  = log.InitSimpleFlags()
 )
 
@@ -49,6 +50,14 @@ func main() {
 	}
 	defer closer.Close()
 
+	if *metricsAddr != "" {
+		metrics, err := oimcommon.StartMetricsServer(context.Background(), *metricsAddr)
+		if err != nil {
+			logger.Fatalw("serve metrics", "error", err)
+		}
+		defer metrics.Close()
+	}
+
 	options := []oimcsidriver.Option{
 		oimcsidriver.WithDriverName(*driverName),
 		oimcsidriver.WithDriverVersion(version),
@@ -10,6 +10,10 @@ package main
 import (
 	"context"
 	"flag"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
 
 	"github.com/intel/oim/pkg/log"
 	"github.com/intel/oim/pkg/oim-common"
@@ -22,6 +26,18 @@ var (
 	endpoint     = flag.String("endpoint", "unix:///tmp/registry.sock", "OIM registry endpoint")
 	ca           = flag.String("ca", "", "the required CA's .crt file which is used for verifying connections")
 	key          = flag.String("key", "", "the base name of the required .key and .crt files that authenticate and authorize the registry")
+	crl          = flag.String("crl", "", "a certificate revocation list signed by the CA, reloaded automatically when modified; revoked client and controller certificates are rejected")
+	policy       = flag.String("policy", "", "YAML file with the authorization policy, reloaded automatically when modified; the built-in default policy is used when empty")
+	db           = flag.String("db", "memory", "the registry database backend: memory (not persistent, only for testing), file or etcd")
+	dbFile       = flag.String("db-file", "/var/lib/oim/registry.db", "the file which stores all registry entries, used with -db=file")
+	etcdEndpoint = flag.String("etcd-endpoints", "http://localhost:2379", "comma-separated list of etcd client URLs, used with -db=etcd")
+	etcdPrefix   = flag.String("etcd-prefix", "/oim/registry/", "all registry entries are stored in etcd under this key prefix")
+	etcdCA       = flag.String("etcd-ca", "", "the CA's .crt file for verifying the etcd server, enables TLS for etcd connections together with -etcd-key")
+	etcdKey      = flag.String("etcd-key", "", "the base name of the .key and .crt files used to authenticate against etcd")
+	proxyConns   = flag.Int("proxy-max-connections", 100, "maximum number of connections to controllers that are kept open for proxying, 0 disables connection reuse")
+	proxyIdle    = flag.Duration("proxy-idle-timeout", 5*time.Minute, "unused connections to controllers are closed after this time")
+	auditLog     = flag.String("audit-log", "", "file to which a record of each registry modification and each proxied controller call is appended, disabled when empty")
+	metricsAddr  = flag.String("metrics-address", "", "host:port on which Prometheus metrics are served via HTTP under /metrics, disabled when empty")
 	_            = log.InitSimpleFlags()
 )
 
@@ -50,17 +66,75 @@ func main() {
 	}
 	defer closer.Close()
 
-	tlsConfig, err := oimcommon.LoadTLSConfig(*ca, *key, "")
+	if *metricsAddr != "" {
+		metrics, err := oimcommon.StartMetricsServer(context.Background(), *metricsAddr)
+		if err != nil {
+			logger.Fatalw("serve metrics", "error", err)
+		}
+		defer metrics.Close()
+	}
+
+	ctx := context.Background()
+	tlsConfig, err := oimcommon.WatchTLSConfig(ctx, *ca, *key, "", oimcommon.WithCRL(*crl))
 	if err != nil {
 		logger.Fatalw("load TLS certs", "error", err)
 	}
 
-	registry, err := oimregistry.New(oimregistry.TLS(tlsConfig))
+	options := []oimregistry.Option{
+		oimregistry.TLS(tlsConfig),
+		oimregistry.ProxyConnections(*proxyConns, *proxyIdle),
+	}
+	if *auditLog != "" {
+		auditFile, err := oimregistry.NewAuditFile(*auditLog)
+		if err != nil {
+			logger.Fatalw("open audit log", "error", err)
+		}
+		defer auditFile.Close()
+		options = append(options, oimregistry.Audit(auditFile))
+	}
+	switch *db {
+	case "memory":
+		// The default.
+	case "file":
+		fileDB, err := oimregistry.NewFileRegistryDB(*dbFile)
+		if err != nil {
+			logger.Fatalw("open registry database", "error", err)
+		}
+		defer fileDB.Close()
+		options = append(options, oimregistry.DB(fileDB))
+	case "etcd":
+		config := clientv3.Config{
+			Endpoints:   strings.Split(*etcdEndpoint, ","),
+			DialTimeout: 10 * time.Second,
+		}
+		if *etcdCA != "" || *etcdKey != "" {
+			etcdTLS, err := oimcommon.WatchTLSConfig(ctx, *etcdCA, *etcdKey, "")
+			if err != nil {
+				logger.Fatalw("load etcd TLS certs", "error", err)
+			}
+			config.TLS = etcdTLS
+		}
+		client, err := clientv3.New(config)
+		if err != nil {
+			logger.Fatalw("connect to etcd", "endpoints", config.Endpoints, "error", err)
+		}
+		defer client.Close()
+		options = append(options, oimregistry.DB(oimregistry.NewEtcdRegistryDB(client, *etcdPrefix)))
+	default:
+		logger.Fatalf("Unknown registry database backend %q.", *db)
+	}
+
+	registry, err := oimregistry.New(options...)
 	if err != nil {
 		logger.Fatalf("Failed to initialize server: %s\n", err)
 	}
+	if *policy != "" {
+		if err := oimregistry.WatchPolicyFile(ctx, *policy, registry.SetPolicy); err != nil {
+			logger.Fatalw("load policy", "error", err)
+		}
+	}
 	server, service := registry.Server(*endpoint)
-	if err := server.Run(context.Background(), service); err != nil {
+	if err := server.Run(ctx, service); err != nil {
 		logger.Fatalf("Failed to run server: %s\n", err)
 	}
 }
@@ -0,0 +1,144 @@
+/*
+Copyright 2018 Intel Coporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+// backupVersion identifies the format of backup files. Must be
+// increased when making incompatible changes.
+const backupVersion = 1
+
+// backupFile is the content of a backup file in JSON format.
+type backupFile struct {
+	Version  int           `json:"version"`
+	Revision int64         `json:"revision"`
+	Values   []backupValue `json:"values"`
+}
+
+type backupValue struct {
+	Path     string `json:"path"`
+	Value    string `json:"value"`
+	Revision int64  `json:"revision"`
+	// Temporary values are not restored.
+	Temporary bool `json:"temporary,omitempty"`
+}
+
+// importChunkSize is the maximum number of values per ImportRequest.
+const importChunkSize = 1000
+
+// backup exports all registry values and writes them to the file,
+// or stdout when the file name is "-".
+func backup(ctx context.Context, registry oim.RegistryClient, filename string) error {
+	stream, err := registry.Export(ctx, &oim.ExportRequest{})
+	if err != nil {
+		return errors.Wrap(err, "export")
+	}
+	content := backupFile{
+		Version: backupVersion,
+		Values:  []backupValue{},
+	}
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "export")
+		}
+		content.Revision = reply.Revision
+		for _, value := range reply.Values {
+			content.Values = append(content.Values, backupValue{
+				Path:      value.Path,
+				Value:     value.Value,
+				Revision:  value.Revision,
+				Temporary: value.Temporary,
+			})
+		}
+	}
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode backup")
+	}
+	data = append(data, '\n')
+	if filename == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	// Never leave a partially written backup behind under the
+	// final name.
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrap(err, "write backup")
+	}
+	return errors.Wrap(os.Rename(tmp, filename), "write backup")
+}
+
+// restore reads a backup file, or stdin when the file name is "-",
+// and imports all values. The reply lists the temporary values which
+// were skipped.
+func restore(ctx context.Context, registry oim.RegistryClient, filename string, replace bool) (*oim.ImportReply, error) {
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read backup")
+	}
+	var content backupFile
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, errors.Wrap(err, "decode backup")
+	}
+	if content.Version != backupVersion {
+		return nil, errors.Errorf("unsupported backup version %d, expected %d", content.Version, backupVersion)
+	}
+
+	stream, err := registry.Import(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "import")
+	}
+	values := content.Values
+	// Always send at least one request, for the replace flag.
+	for first := true; first || len(values) > 0; first = false {
+		request := &oim.ImportRequest{
+			Replace: replace,
+		}
+		n := len(values)
+		if n > importChunkSize {
+			n = importChunkSize
+		}
+		for _, value := range values[:n] {
+			request.Values = append(request.Values, &oim.Value{
+				Path:      value.Path,
+				Value:     value.Value,
+				Temporary: value.Temporary,
+			})
+		}
+		values = values[n:]
+		if err := stream.Send(request); err != nil {
+			// The real error is returned by CloseAndRecv.
+			break
+		}
+	}
+	reply, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, errors.Wrap(err, "import")
+	}
+	return reply, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 Intel Coporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/intel/oim/pkg/log"
+	"github.com/intel/oim/pkg/oim-common"
+)
+
+// Flags of the ca and cert commands. They do not need a connection
+// to the registry.
+var (
+	caBase       string
+	caCommonName string
+	certRole     string
+	certID       string
+	certOut      string
+	caLifetime   time.Duration
+	certLifetime time.Duration
+	keyType      string
+)
+
+var caCmd = &cobra.Command{
+	Use:   "ca",
+	Short: "manage the certificate authority (CA)",
+}
+
+var caInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "create a new CA as <base>.crt and <base>.key (see --ca-base)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := oimcommon.CreateCA(caBase, caCommonName, oimcommon.CertOptions{
+			KeyType:  oimcommon.KeyType(keyType),
+			Lifetime: caLifetime,
+		}); err != nil {
+			return errors.Wrap(err, "create CA")
+		}
+		crtFile, keyFile := oimcommon.KeyPairFiles(caBase)
+		log.L().Infow("created CA", "crt", crtFile, "key", keyFile)
+		return nil
+	},
+}
+
+var certCmd = &cobra.Command{
+	Use:   "cert",
+	Short: "manage certificates",
+}
+
+var certIssueCmd = &cobra.Command{
+	Use:   "issue",
+	Short: "create a new key and certificate for the identity given with --role and --id",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		identity := oimcommon.Identity{Role: certRole, ID: certID}
+		base := certOut
+		if base == "" {
+			base = identity.String()
+		}
+		if err := oimcommon.IssueCert(caBase, identity, base, oimcommon.CertOptions{
+			KeyType:  oimcommon.KeyType(keyType),
+			Lifetime: certLifetime,
+		}); err != nil {
+			return errors.Wrap(err, "issue certificate")
+		}
+		crtFile, keyFile := oimcommon.KeyPairFiles(base)
+		log.L().Infow("issued certificate", "identity", identity, "crt", crtFile, "key", keyFile)
+		return nil
+	},
+}
+
+func init() {
+	caInitCmd.Flags().StringVar(&caBase, "ca-base", "ca", "the base name of the new .crt and .key files")
+	caInitCmd.Flags().StringVar(&caCommonName, "common-name", "OIM CA", "the common name of the CA")
+	caInitCmd.Flags().DurationVar(&caLifetime, "lifetime", 10*365*24*time.Hour, "validity period of the CA certificate")
+
+	certIssueCmd.Flags().StringVar(&caBase, "ca-base", "ca", "the base name of the CA's .crt and .key files")
+	certIssueCmd.Flags().StringVar(&certRole, "role", "", "the role of the new certificate: user, host, controller or component")
+	certIssueCmd.Flags().StringVar(&certID, "id", "", "user name, controller ID or component name, depending on the role")
+	certIssueCmd.Flags().StringVar(&certOut, "out", "", "the base name of the new .crt and .key files, <role>.<id> when empty")
+	certIssueCmd.Flags().DurationVar(&certLifetime, "lifetime", 365*24*time.Hour, "validity period of the certificate, limited by the CA's lifetime")
+
+	for _, cmd := range []*cobra.Command{caInitCmd, certIssueCmd} {
+		cmd.Flags().StringVar(&keyType, "key-type", string(oimcommon.KeyRSA2048), fmt.Sprintf("type of the new key, one of %v", oimcommon.KeyTypes))
+	}
+
+	caCmd.AddCommand(caInitCmd)
+	certCmd.AddCommand(certIssueCmd)
+	rootCmd.AddCommand(caCmd, certCmd)
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2018 Intel Coporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// configFileEnv can be used to override the default config file.
+const configFileEnv = "OIMCTL_CONFIG"
+
+// config is the content of the config file.
+type config struct {
+	CurrentContext string                   `json:"current-context" yaml:"current-context"`
+	Contexts       map[string]contextConfig `json:"contexts" yaml:"contexts"`
+}
+
+// contextConfig contains the connection settings for one registry
+// and one identity.
+type contextConfig struct {
+	Registry string `json:"registry,omitempty" yaml:"registry,omitempty"`
+	CA       string `json:"ca,omitempty" yaml:"ca,omitempty"`
+	Key      string `json:"key,omitempty" yaml:"key,omitempty"`
+}
+
+func defaultConfigFile() string {
+	if filename := os.Getenv(configFileEnv); filename != "" {
+		return filename
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".oimctl.yaml"
+	}
+	return filepath.Join(home, ".oimctl.yaml")
+}
+
+// loadConfig reads the config file. A missing file is the same as
+// an empty one.
+func loadConfig() (*config, error) {
+	c := &config{}
+	data, err := ioutil.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read config")
+	}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, errors.Wrapf(err, "parse config %q", configFile)
+	}
+	return c, nil
+}
+
+func (c *config) save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "encode config")
+	}
+	// The config file only contains file names, but those
+	// point to secret keys.
+	if err := ioutil.WriteFile(configFile, data, 0600); err != nil {
+		return errors.Wrap(err, "write config")
+	}
+	return nil
+}
+
+// connectionSettings combines the settings from the selected
+// context with the ones from the command line, which take
+// precedence.
+func connectionSettings() (contextConfig, error) {
+	c, err := loadConfig()
+	if err != nil {
+		return contextConfig{}, err
+	}
+	name := contextName
+	if name == "" {
+		name = c.CurrentContext
+	}
+	settings := contextConfig{}
+	if name != "" {
+		var ok bool
+		settings, ok = c.Contexts[name]
+		if !ok {
+			return contextConfig{}, errors.Errorf("context %q not found in %q", name, configFile)
+		}
+	}
+	flags := rootCmd.PersistentFlags()
+	if flags.Changed("registry") {
+		settings.Registry = endpoint
+	}
+	if flags.Changed("ca") {
+		settings.CA = ca
+	}
+	if flags.Changed("key") {
+		settings.Key = key
+	}
+	return settings, nil
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "manage contexts in the config file",
+	Long: `A context stores the --registry, --ca and --key settings under a name.
+The current context is used when those settings are not given on the
+command line.`,
+}
+
+var setContextCmd = &cobra.Command{
+	Use:   "set-context <name>",
+	Short: "create or update a context with the --registry, --ca and --key values from the command line",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if c.Contexts == nil {
+			c.Contexts = map[string]contextConfig{}
+		}
+		settings := c.Contexts[args[0]]
+		flags := rootCmd.PersistentFlags()
+		if flags.Changed("registry") {
+			settings.Registry = endpoint
+		}
+		if flags.Changed("ca") {
+			settings.CA = absPath(ca)
+		}
+		if flags.Changed("key") {
+			settings.Key = absPath(key)
+		}
+		c.Contexts[args[0]] = settings
+		if c.CurrentContext == "" {
+			c.CurrentContext = args[0]
+		}
+		return c.save()
+	},
+}
+
+var useContextCmd = &cobra.Command{
+	Use:   "use-context <name>",
+	Short: "make the context the current one",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if _, ok := c.Contexts[args[0]]; !ok {
+			return errors.Errorf("context %q not found in %q", args[0], configFile)
+		}
+		c.CurrentContext = args[0]
+		return c.save()
+	},
+}
+
+var deleteContextCmd = &cobra.Command{
+	Use:   "delete-context <name>",
+	Short: "remove the context",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if _, ok := c.Contexts[args[0]]; !ok {
+			return errors.Errorf("context %q not found in %q", args[0], configFile)
+		}
+		delete(c.Contexts, args[0])
+		if c.CurrentContext == args[0] {
+			c.CurrentContext = ""
+		}
+		return c.save()
+	},
+}
+
+var getContextsCmd = &cobra.Command{
+	Use:   "get-contexts",
+	Short: "list all contexts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(c.Contexts))
+		for name := range c.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var rows [][]string
+		for _, name := range names {
+			current := ""
+			if name == c.CurrentContext {
+				current = "*"
+			}
+			settings := c.Contexts[name]
+			rows = append(rows, []string{current, name, settings.Registry, settings.CA, settings.Key})
+		}
+		return printResult(c,
+			func(w io.Writer) {
+				for _, row := range rows {
+					fmt.Fprintf(w, "%1s %s\n", row[0], row[1])
+				}
+			},
+			[]string{"CURRENT", "NAME", "REGISTRY", "CA", "KEY"}, rows)
+	},
+}
+
+// absPath turns relative file names into absolute ones, so that the
+// config works regardless of the current directory.
+func absPath(filename string) string {
+	if abs, err := filepath.Abs(filename); err == nil {
+		return abs
+	}
+	return filename
+}
+
+func init() {
+	configCmd.AddCommand(setContextCmd, useContextCmd, deleteContextCmd, getContextsCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2018 Intel Coporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/intel/oim/pkg/log"
+
+	"github.com/intel/oim/pkg/oim-common"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+// controllerInfo is how a controller is represented in JSON and YAML
+// output.
+type controllerInfo struct {
+	ID      string `json:"id" yaml:"id"`
+	Address string `json:"address" yaml:"address"`
+	PCI     string `json:"pci,omitempty" yaml:"pci,omitempty"`
+}
+
+// mappedVolume is how a MapVolumeReply is represented in JSON and
+// YAML output. Unknown parts of the PCI address are left out.
+type mappedVolume struct {
+	VolumeID  string `json:"volumeID" yaml:"volumeID"`
+	PCI       string `json:"pci,omitempty" yaml:"pci,omitempty"`
+	Target    *int   `json:"target,omitempty" yaml:"target,omitempty"`
+	LUN       *int   `json:"lun,omitempty" yaml:"lun,omitempty"`
+	Blk       bool   `json:"blk,omitempty" yaml:"blk,omitempty"`
+	NQN       string `json:"nqn,omitempty" yaml:"nqn,omitempty"`
+	Transport string `json:"transport,omitempty" yaml:"transport,omitempty"`
+	Address   string `json:"address,omitempty" yaml:"address,omitempty"`
+	Port      string `json:"port,omitempty" yaml:"port,omitempty"`
+	Namespace uint32 `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	IQN       string `json:"iqn,omitempty" yaml:"iqn,omitempty"`
+	Portal    string `json:"portal,omitempty" yaml:"portal,omitempty"`
+}
+
+// Flags of the commands which are sent to a controller.
+var (
+	controllerID   string
+	mallocSize     int64
+	cephUser       string
+	cephSecretFile string
+	cephMonitors   string
+	cephPool       string
+	cephImage      string
+	chapUser       string
+	chapSecretFile string
+	hostNQN        string
+	initiator      string
+)
+
+var controllerCmd = &cobra.Command{
+	Use:   "controller",
+	Short: "inspect and use OIM controllers",
+}
+
+var controllerListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list all controllers that are known to the registry",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withRegistry(func(ctx context.Context, registry oim.RegistryClient) error {
+			reply, err := registry.GetValues(ctx, &oim.GetValuesRequest{})
+			if err != nil {
+				return errors.Wrap(err, "getting registry values")
+			}
+			controllers := map[string]*controllerInfo{}
+			for _, value := range reply.Values {
+				elements := strings.Split(value.Path, "/")
+				if len(elements) != 2 {
+					continue
+				}
+				info := controllers[elements[0]]
+				if info == nil {
+					info = &controllerInfo{ID: elements[0]}
+				}
+				switch elements[1] {
+				case oimcommon.RegistryAddress:
+					info.Address = value.Value
+				case oimcommon.RegistryPCI:
+					info.PCI = value.Value
+				default:
+					continue
+				}
+				controllers[elements[0]] = info
+			}
+			list := make([]controllerInfo, 0, len(controllers))
+			for _, info := range controllers {
+				list = append(list, *info)
+			}
+			sort.Slice(list, func(i, j int) bool {
+				return list[i].ID < list[j].ID
+			})
+			var rows [][]string
+			for _, info := range list {
+				rows = append(rows, []string{info.ID, info.Address, info.PCI})
+			}
+			return printResult(list,
+				func(w io.Writer) {
+					for _, info := range list {
+						fmt.Fprintln(w, info.ID)
+					}
+				},
+				[]string{"ID", "ADDRESS", "PCI"}, rows)
+		})
+	},
+}
+
+// checkHostKey warns when the client key does not have the host
+// identity for the controller. The default policy only allows
+// proxying for that identity, so anything else most likely is a
+// mistake. A custom policy might allow it, therefore this is not an
+// error.
+func checkHostKey() {
+	settings, err := connectionSettings()
+	if err != nil || settings.Key == "" {
+		// Reported by dialRegistry.
+		return
+	}
+	keyPair, err := tls.LoadX509KeyPair(oimcommon.KeyPairFiles(settings.Key))
+	if err != nil {
+		// Same here.
+		return
+	}
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return
+	}
+	expected := oimcommon.Identity{Role: oimcommon.RoleHost, ID: controllerID}
+	if identity, err := oimcommon.CertIdentity(cert); err != nil || identity != expected {
+		log.L().Warnw("controller commands normally need the host credentials of the controller (--key)",
+			"expected", expected, "key", settings.Key)
+	}
+}
+
+// withController connects to the registry and invokes the callback
+// with a client for the controller selected with --controller-id.
+// Calls are forwarded by the registry's proxy.
+func withController(cb func(ctx context.Context, controller oim.ControllerClient) error) error {
+	if controllerID == "" {
+		return errors.New("--controller-id must be set")
+	}
+	checkHostKey()
+	ctx := context.Background()
+	conn, err := dialRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx = metadata.AppendToOutgoingContext(ctx, "controllerid", controllerID)
+	return cb(ctx, oim.NewControllerClient(conn))
+}
+
+var controllerMapCmd = &cobra.Command{
+	Use:   "map <volume ID>",
+	Short: "make a volume available to the host of the controller",
+	Long: `Makes a volume available to the host of the controller. By default,
+the volume is an existing Malloc BDev with the same name as the volume ID.
+A Ceph RBD image is used instead when --ceph-pool and --ceph-image are set.
+
+The text output is the PCI address in extended BDF format and, for SCSI
+disks, <target>:<lun>. A virtio-blk disk is identified by the PCI
+address alone. For NVMe-oF, the output is <transport> <address>:<port>
+<NQN> <namespace ID> instead, for iSCSI it is iscsi <portal> <IQN> <LUN>.
+Unknown parts of the PCI address are left out.
+
+--chap-user and --chap-secret-file set the CHAP credentials which
+initiators must use when the controller exports the volume via iSCSI.
+
+--host-nqn sets the NQN of the host which may connect when the
+controller exports the volume via NVMe-oF. The default is the host NQN
+that the OIM CSI driver uses for the controller. --iscsi-initiator
+does the same for the initiator name which may log into an iSCSI
+target.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := &oim.MapVolumeRequest{
+			VolumeId: args[0],
+			Params: &oim.MapVolumeRequest_Malloc{
+				Malloc: &oim.MallocParams{},
+			},
+		}
+		if cephPool != "" || cephImage != "" {
+			ceph := &oim.CephParams{
+				UserId:   cephUser,
+				Monitors: cephMonitors,
+				Pool:     cephPool,
+				Image:    cephImage,
+			}
+			if cephSecretFile != "" {
+				secret, err := ioutil.ReadFile(cephSecretFile)
+				if err != nil {
+					return errors.Wrap(err, "read Ceph secret")
+				}
+				ceph.Secret = strings.TrimSpace(string(secret))
+			}
+			request.Params = &oim.MapVolumeRequest_Ceph{Ceph: ceph}
+		}
+		request.HostNqn = hostNQN
+		if request.HostNqn == "" {
+			nqn, err := oimcommon.HostNQN(controllerID)
+			if err != nil {
+				return errors.Wrap(err, "host NQN")
+			}
+			request.HostNqn = nqn
+		}
+		request.IscsiInitiator = initiator
+		if request.IscsiInitiator == "" {
+			iqn, err := oimcommon.InitiatorIQN(controllerID)
+			if err != nil {
+				return errors.Wrap(err, "initiator IQN")
+			}
+			request.IscsiInitiator = iqn
+		}
+		if chapUser != "" {
+			chap := &oim.ISCSICHAP{User: chapUser}
+			if chapSecretFile != "" {
+				secret, err := ioutil.ReadFile(chapSecretFile)
+				if err != nil {
+					return errors.Wrap(err, "read CHAP secret")
+				}
+				chap.Secret = strings.TrimSpace(string(secret))
+			}
+			request.IscsiChap = chap
+		}
+		return withController(func(ctx context.Context, controller oim.ControllerClient) error {
+			reply, err := controller.MapVolume(ctx, request)
+			if err != nil {
+				return errors.Wrapf(err, "MapVolume for %s", args[0])
+			}
+			result := mappedVolume{
+				VolumeID: args[0],
+			}
+			if reply.GetPciAddress() != nil {
+				result.PCI = oimcommon.PrettyPCIAddress(reply.GetPciAddress())
+			}
+			row := []string{result.VolumeID, result.PCI, "", ""}
+			if disk := reply.GetScsiDisk(); disk != nil {
+				target, lun := int(disk.Target), int(disk.Lun)
+				result.Target = &target
+				result.LUN = &lun
+				row[2] = fmt.Sprint(target)
+				row[3] = fmt.Sprint(lun)
+			}
+			result.Blk = reply.GetBlkDisk() != nil
+			if disk := reply.GetNvmeofDisk(); disk != nil {
+				result.NQN = disk.Nqn
+				result.Transport = disk.Transport
+				result.Address = disk.Address
+				result.Port = disk.Port
+				result.Namespace = disk.NamespaceId
+			}
+			if disk := reply.GetIscsiDisk(); disk != nil {
+				lun := int(disk.Lun)
+				result.IQN = disk.Iqn
+				result.Portal = disk.Portal
+				result.LUN = &lun
+				row[3] = fmt.Sprint(lun)
+			}
+			return printResult(result,
+				func(w io.Writer) {
+					if result.NQN != "" {
+						fmt.Fprintf(w, "%s %s:%s %s %d\n", result.Transport, result.Address, result.Port, result.NQN, result.Namespace)
+					} else if result.IQN != "" {
+						fmt.Fprintf(w, "iscsi %s %s %d\n", result.Portal, result.IQN, *result.LUN)
+					} else if result.Target != nil {
+						fmt.Fprintf(w, "%s %d:%d\n", result.PCI, *result.Target, *result.LUN)
+					} else {
+						fmt.Fprintln(w, result.PCI)
+					}
+				},
+				[]string{"VOLUME", "PCI", "TARGET", "LUN"}, [][]string{row})
+		})
+	},
+}
+
+var controllerUnmapCmd = &cobra.Command{
+	Use:   "unmap <volume ID>",
+	Short: "remove access to a volume that was mapped before",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withController(func(ctx context.Context, controller oim.ControllerClient) error {
+			if _, err := controller.UnmapVolume(ctx, &oim.UnmapVolumeRequest{
+				VolumeId: args[0],
+			}); err != nil {
+				return errors.Wrapf(err, "UnmapVolume for %s", args[0])
+			}
+			log.L().Debugw("unmapped volume", "controller", controllerID, "volume", args[0])
+			return nil
+		})
+	},
+}
+
+var controllerProvisionMallocCmd = &cobra.Command{
+	Use:   "provision-malloc <BDev name>",
+	Short: "create (--size > 0) or delete (--size 0) a Malloc BDev for testing",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if mallocSize < 0 || mallocSize%512 != 0 {
+			return errors.Errorf("size must be zero or a positive multiple of 512: %d", mallocSize)
+		}
+		return withController(func(ctx context.Context, controller oim.ControllerClient) error {
+			if _, err := controller.ProvisionMallocBDev(ctx, &oim.ProvisionMallocBDevRequest{
+				BdevName: args[0],
+				Size_:    mallocSize,
+			}); err != nil {
+				return errors.Wrapf(err, "ProvisionMallocBDev for %s", args[0])
+			}
+			log.L().Debugw("provisioned Malloc BDev", "controller", controllerID, "bdev", args[0], "size", mallocSize)
+			return nil
+		})
+	},
+}
+
+var controllerCheckMallocCmd = &cobra.Command{
+	Use:   "check-malloc <BDev name>",
+	Short: "check that the BDev exists, fails with NotFound if not",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withController(func(ctx context.Context, controller oim.ControllerClient) error {
+			if _, err := controller.CheckMallocBDev(ctx, &oim.CheckMallocBDevRequest{
+				BdevName: args[0],
+			}); err != nil {
+				return errors.Wrapf(err, "CheckMallocBDev for %s", args[0])
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{controllerMapCmd, controllerUnmapCmd, controllerProvisionMallocCmd, controllerCheckMallocCmd} {
+		cmd.Flags().StringVar(&controllerID, "controller-id", "", "the ID of the controller, the key must normally be the host.<controller ID> key")
+	}
+	controllerMapCmd.Flags().StringVar(&cephUser, "ceph-user", "", "the Ceph user ID, \"admin\" when empty")
+	controllerMapCmd.Flags().StringVar(&cephSecretFile, "ceph-secret-file", "", "a file with the Ceph key for the user")
+	controllerMapCmd.Flags().StringVar(&cephMonitors, "ceph-monitors", "", "comma-separated list of Ceph monitor addr:port values")
+	controllerMapCmd.Flags().StringVar(&cephPool, "ceph-pool", "", "the Ceph pool of the RBD image")
+	controllerMapCmd.Flags().StringVar(&cephImage, "ceph-image", "", "the name of the RBD image")
+	controllerMapCmd.Flags().StringVar(&chapUser, "chap-user", "", "the CHAP user name for iSCSI")
+	controllerMapCmd.Flags().StringVar(&chapSecretFile, "chap-secret-file", "", "a file with the CHAP secret for the user")
+	controllerMapCmd.Flags().StringVar(&hostNQN, "host-nqn", "", "the NQN of the NVMe-oF host, derived from --controller-id when empty")
+	controllerMapCmd.Flags().StringVar(&initiator, "iscsi-initiator", "", "the IQN of the iSCSI initiator, derived from --controller-id when empty")
+	controllerProvisionMallocCmd.Flags().Int64Var(&mallocSize, "size", 1024*1024, "size in bytes, must be a multiple of 512, 0 deletes the BDev")
+
+	controllerCmd.AddCommand(controllerListCmd, controllerMapCmd, controllerUnmapCmd, controllerProvisionMallocCmd, controllerCheckMallocCmd)
+	rootCmd.AddCommand(controllerCmd)
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2018 Intel Coporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/intel/oim/pkg/oim-common"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+// Results of a single diagnose step.
+const (
+	diagnoseOK   = "OK"
+	diagnoseWarn = "WARN"
+	diagnoseFail = "FAIL"
+)
+
+// diagnoseBDev is used for the no-op call to the controller. It is
+// not expected to exist.
+const diagnoseBDev = "oimctl-diagnose-no-such-bdev"
+
+var (
+	expiryWarning   time.Duration
+	diagnoseTimeout time.Duration
+)
+
+// diagnoseStep is how the result of a step is represented in JSON
+// and YAML output.
+type diagnoseStep struct {
+	Step    string `json:"step" yaml:"step"`
+	Result  string `json:"result" yaml:"result"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// diagnosis collects the results of all steps.
+type diagnosis struct {
+	steps  []diagnoseStep
+	failed bool
+}
+
+func (d *diagnosis) add(step, result, format string, args ...interface{}) {
+	d.steps = append(d.steps, diagnoseStep{Step: step, Result: result, Message: fmt.Sprintf(format, args...)})
+	if result == diagnoseFail {
+		d.failed = true
+	}
+}
+
+// checkExpiry adds a step for the validity period of the certificate.
+func (d *diagnosis) checkExpiry(step string, cert *x509.Certificate, now time.Time) {
+	switch {
+	case now.Before(cert.NotBefore):
+		d.add(step, diagnoseFail, "%q is not valid before %s, check the clock", cert.Subject.CommonName, cert.NotBefore)
+	case now.After(cert.NotAfter):
+		d.add(step, diagnoseFail, "%q expired at %s, issue a new certificate", cert.Subject.CommonName, cert.NotAfter)
+	case now.Add(expiryWarning).After(cert.NotAfter):
+		d.add(step, diagnoseWarn, "%q expires soon at %s, issue a new certificate", cert.Subject.CommonName, cert.NotAfter)
+	default:
+		d.add(step, diagnoseOK, "%q valid until %s", cert.Subject.CommonName, cert.NotAfter)
+	}
+}
+
+// checkCerts loads the CA and the client key and checks them without
+// contacting the registry. It returns false if connecting makes no
+// sense.
+func (d *diagnosis) checkCerts(caFile, key string) bool {
+	now := time.Now()
+	pemData, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		d.add("CA", diagnoseFail, "%s, check --ca", err)
+		return false
+	}
+	roots := x509.NewCertPool()
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			d.add("CA", diagnoseFail, "%s: %s", caFile, err)
+			return false
+		}
+		roots.AddCert(cert)
+		d.checkExpiry("CA", cert, now)
+	}
+	if len(roots.Subjects()) == 0 {
+		d.add("CA", diagnoseFail, "%s: no certificates found, check --ca", caFile)
+		return false
+	}
+
+	crtFile, keyFile := oimcommon.KeyPairFiles(key)
+	keyPair, err := tls.LoadX509KeyPair(crtFile, keyFile)
+	if err != nil {
+		d.add("key", diagnoseFail, "%s, check --key", err)
+		return false
+	}
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		d.add("key", diagnoseFail, "%s: %s", crtFile, err)
+		return false
+	}
+	d.checkExpiry("key", cert, now)
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: now,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		d.add("key", diagnoseFail, "%s not accepted by the CA in %s: %s", crtFile, caFile, err)
+	}
+	identity, err := oimcommon.CertIdentity(cert)
+	expected := oimcommon.Identity{Role: oimcommon.RoleHost, ID: controllerID}
+	switch {
+	case err != nil:
+		d.add("identity", diagnoseFail, "%s: %s", crtFile, err)
+	case identity != expected:
+		d.add("identity", diagnoseWarn, "%s has identity %q, the default policy only allows %q to use the controller", crtFile, identity, expected)
+	default:
+		d.add("identity", diagnoseOK, "%s", identity)
+	}
+	return !d.failed
+}
+
+// checkRegistry reads the controller entries. It returns false if
+// contacting the controller makes no sense.
+func (d *diagnosis) checkRegistry(ctx context.Context, registry oim.RegistryClient) bool {
+	path := oimcommon.JoinRegistryPath([]string{controllerID, oimcommon.RegistryAddress})
+	reply, err := registry.GetValues(ctx, &oim.GetValuesRequest{Path: path})
+	if err != nil {
+		d.add("registry", diagnoseFail, "%s, check --registry, the registry log and that the registry uses a %q certificate from the same CA",
+			status.Convert(err).Message(), oimcommon.RegistryIdentity)
+		return false
+	}
+	d.add("registry", diagnoseOK, "connected")
+	if len(reply.Values) != 1 || reply.Values[0].Value == "" {
+		d.add("address", diagnoseFail, "%s not set, check that oim-controller -controllerid=%s is running and registering itself", path, controllerID)
+		return false
+	}
+	d.add("address", diagnoseOK, "%s=%s", path, reply.Values[0].Value)
+
+	path = oimcommon.JoinRegistryPath([]string{controllerID, oimcommon.RegistryPCI})
+	reply, err = registry.GetValues(ctx, &oim.GetValuesRequest{Path: path})
+	switch {
+	case err != nil:
+		d.add("pci", diagnoseFail, "%s", status.Convert(err).Message())
+	case len(reply.Values) == 0:
+		d.add("pci", diagnoseWarn, "%s not set, the controller must then report a complete PCI address", path)
+	default:
+		if _, err := oimcommon.ParseBDFString(reply.Values[0].Value); err != nil {
+			d.add("pci", diagnoseFail, "%s=%s: %s, fix it with oimctl registry set", path, reply.Values[0].Value, err)
+		} else {
+			d.add("pci", diagnoseOK, "%s=%s", path, reply.Values[0].Value)
+		}
+	}
+	return true
+}
+
+// checkController sends a CheckMallocBDev request for a BDev which
+// does not exist. NotFound proves that the registry proxy, the
+// controller and SPDK behind it are working.
+func (d *diagnosis) checkController(ctx context.Context, controller oim.ControllerClient) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "controllerid", controllerID)
+	_, err := controller.CheckMallocBDev(ctx, &oim.CheckMallocBDevRequest{BdevName: diagnoseBDev})
+	s := status.Convert(err)
+	switch s.Code() {
+	case codes.OK, codes.NotFound:
+		d.add("controller", diagnoseOK, "reachable through the registry")
+		d.add("spdk", diagnoseOK, "responding")
+	case codes.PermissionDenied:
+		d.add("controller", diagnoseFail, "%s, use the host.%s key or change the registry policy", s.Message(), controllerID)
+	case codes.Unavailable:
+		// The registry uses the same code when it cannot reach the
+		// controller, so look at the message.
+		if strings.HasPrefix(s.Message(), "SPDK:") {
+			d.add("controller", diagnoseOK, "reachable through the registry")
+			d.add("spdk", diagnoseFail, "%s, check the SPDK daemon and oim-controller -spdk", s.Message())
+		} else {
+			d.add("controller", diagnoseFail, "%s, check that the registry can reach the registered address and that the controller uses a %q certificate",
+				s.Message(), oimcommon.ControllerIdentity(controllerID))
+		}
+	case codes.DeadlineExceeded:
+		d.add("controller", diagnoseFail, "%s, the controller or the SPDK daemon behind it did not answer within --timeout", s.Message())
+	default:
+		d.add("controller", diagnoseFail, "%s", s.Message())
+	}
+}
+
+var diagnoseCmd = &cobra.Command{
+	Use:   "diagnose",
+	Short: "check the path from a host through the registry to its controller",
+	Long: `Checks the path from a host through the registry to its controller
+the same way as the OIM CSI driver uses it: certificates, registry
+connection, registered address and PCI address of the controller,
+and finally a no-op call through the registry proxy to the controller
+and SPDK. Each step is reported as OK, WARN or FAIL. The command fails
+if any step failed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if controllerID == "" {
+			return errors.New("--controller-id must be set")
+		}
+		d := &diagnosis{}
+		diagnose(d)
+
+		var rows [][]string
+		for _, step := range d.steps {
+			rows = append(rows, []string{step.Step, step.Result, step.Message})
+		}
+		if err := printResult(d.steps,
+			func(w io.Writer) {
+				for _, step := range d.steps {
+					fmt.Fprintf(w, "%-4s %s: %s\n", step.Result, step.Step, step.Message)
+				}
+			},
+			[]string{"STEP", "RESULT", "MESSAGE"}, rows); err != nil {
+			return err
+		}
+		if d.failed {
+			return errors.New("diagnosis failed")
+		}
+		return nil
+	},
+}
+
+func diagnose(d *diagnosis) {
+	settings, err := connectionSettings()
+	if err != nil {
+		d.add("config", diagnoseFail, "%s", err)
+		return
+	}
+	if settings.Registry == "" || settings.CA == "" || settings.Key == "" {
+		d.add("config", diagnoseFail, "--registry, --ca and --key must be set or come from the current context")
+		return
+	}
+	d.add("config", diagnoseOK, "registry %s, CA %s, key %s", settings.Registry, settings.CA, settings.Key)
+	if !d.checkCerts(settings.CA, settings.Key) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), diagnoseTimeout)
+	defer cancel()
+	conn, err := dialRegistry(ctx)
+	if err != nil {
+		d.add("registry", diagnoseFail, "%s", err)
+		return
+	}
+	defer conn.Close()
+	if !d.checkRegistry(ctx, oim.NewRegistryClient(conn)) {
+		return
+	}
+	d.checkController(ctx, oim.NewControllerClient(conn))
+}
+
+func init() {
+	diagnoseCmd.Flags().StringVar(&controllerID, "controller-id", "", "the ID of the controller")
+	diagnoseCmd.Flags().DurationVar(&expiryWarning, "expiry-warning", 30*24*time.Hour, "warn about certificates which expire within this time")
+	diagnoseCmd.Flags().DurationVar(&diagnoseTimeout, "timeout", 30*time.Second, "the timeout for all network operations")
+	rootCmd.AddCommand(diagnoseCmd)
+}
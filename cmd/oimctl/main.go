@@ -9,111 +9,100 @@ package main
 import (
 	"context"
 	"flag"
-	"fmt"
 	"os"
-	"sort"
-	"strings"
 
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 
 	"github.com/intel/oim/pkg/log"
 	"github.com/intel/oim/pkg/oim-common"
-	"github.com/intel/oim/pkg/spec/oim/v0"
 )
 
 var (
-	version      = "unknown" // set at build time
-	printVersion = flag.Bool("version", false, "output version information and exit")
-
-	endpoint = flag.String("registry", "", "the gRPC endpoint of the OIM registry (for example, dns:///localhost:8999)")
-	ca       = flag.String("ca", "", "the required CA's .crt file which is used for verifying connections to the registry")
-	key      = flag.String("key", "", "the base name of the required .key and .crt files that authenticate and authorize the registry client")
-	_        = log.InitSimpleFlags()
-
-	// Quick-and-dirty bool flags for triggering operations. What we want instead is
-	// probably something like a Cobra-based command line tool. We also need to consider
-	// keys which contain the = sign: right now, the command line parsing does not support those.
-	get   = flag.Bool("get", false, "retrieve values from the registry as <key>=<value> pairs to stdout")
-	set   = flag.Bool("set", false, "sets or updates a registry value, deletes it when value is empty")
-	path  = flag.String("path", "", "the complete path of a value (set, delete, get of single value) or a path prefix (get multiple values)")
-	value = flag.String("value", "", "the value to set or update")
+	version = "unknown" // set at build time
+
+	// Connection settings, shared by all commands which talk to
+	// the registry. Values which are not set on the command
+	// line are taken from the current context in the config
+	// file.
+	endpoint    string
+	ca          string
+	key         string
+	configFile  string
+	contextName string
+
+	// output is the format of the command output.
+	output string
+
+	_ = log.InitSimpleFlags()
 )
 
-func main() {
-	ctx := context.Background()
-
-	flag.Parse()
+var rootCmd = &cobra.Command{
+	Use:   "oimctl",
+	Short: "oimctl controls the OIM registry and OIM controllers",
+	Long: `oimctl controls the OIM registry and, through the registry, OIM controllers.
+It also manages the certificates needed for that.`,
+	Version: version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		config := log.NewSimpleConfig()
+		config.Output = os.Stderr
+		log.Set(log.NewSimpleLogger(config))
+
+		switch output {
+		case outputText, outputJSON, outputYAML, outputTable:
+		default:
+			return errors.Errorf("unsupported output format %q", output)
+		}
 
-	config := log.NewSimpleConfig()
-	config.Output = os.Stderr
-	logger := log.NewSimpleLogger(config)
-	log.Set(logger)
+		// Command line parsing is done at this point, so
+		// further errors are not caused by wrong usage.
+		cmd.SilenceUsage = true
+		return nil
+	},
+}
 
-	if *printVersion {
-		logger.Infof("oimctl %s", version)
-		return
-	}
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&endpoint, "registry", "", "the gRPC endpoint of the OIM registry (for example, dns:///localhost:8999)")
+	flags.StringVar(&ca, "ca", "", "the CA's .crt file which is used for verifying connections to the registry")
+	flags.StringVar(&key, "key", "", "the base name of the .key and .crt files that authenticate and authorize the registry client")
+	flags.StringVar(&configFile, "config", defaultConfigFile(), "the config file with contexts (registry, ca and key settings)")
+	flags.StringVar(&contextName, "context", "", "the context in the config file that is used instead of the current one")
+	flags.StringVarP(&output, "output", "o", outputText, "output format: "+outputText+", "+outputJSON+", "+outputYAML+" or "+outputTable)
+	flags.AddGoFlagSet(flag.CommandLine)
+}
 
-	if *endpoint == "" {
-		logger.Fatal("-registry must be set")
+// dialRegistry connects to the registry with the settings from the
+// command line and the config file.
+func dialRegistry(ctx context.Context) (*grpc.ClientConn, error) {
+	settings, err := connectionSettings()
+	if err != nil {
+		return nil, err
 	}
-	if *ca == "" {
-		logger.Fatalf("A CA file is required.")
+	if settings.Registry == "" {
+		return nil, errors.New("--registry must be set")
 	}
-	if *key == "" {
-		logger.Fatalf("A key file is required.")
+	if settings.CA == "" {
+		return nil, errors.New("a CA file is required (--ca)")
 	}
-
-	transportCreds, err := oimcommon.LoadTLS(*ca, *key, "component.registry")
-	if err != nil {
-		logger.Fatalw("load TLS certs", "error", err)
+	if settings.Key == "" {
+		return nil, errors.New("a key file is required (--key)")
 	}
-	opts := oimcommon.ChooseDialOpts(*endpoint, grpc.WithTransportCredentials(transportCreds))
-	conn, err := grpc.DialContext(ctx, *endpoint, opts...)
+	transportCreds, err := oimcommon.LoadTLS(settings.CA, settings.Key, oimcommon.RegistryIdentity.String())
 	if err != nil {
-		logger.Fatalw("connecting to OIM registry", "error", err)
+		return nil, errors.Wrap(err, "load TLS certs")
 	}
-	defer conn.Close()
-
-	registry := oim.NewRegistryClient(conn)
-
-	// sanitize path
-	elements, err := oimcommon.SplitRegistryPath(*path)
+	opts := oimcommon.ChooseDialOpts(settings.Registry, grpc.WithTransportCredentials(transportCreds))
+	conn, err := grpc.DialContext(ctx, settings.Registry, opts...)
 	if err != nil {
-		logger.Fatalw("path", *path, "error", err)
+		return nil, errors.Wrap(err, "connecting to OIM registry")
 	}
-	key := oimcommon.JoinRegistryPath(elements)
+	return conn, nil
+}
 
-	if *set {
-		if key == "" {
-			logger.Fatal("key required")
-		}
-		_, err := registry.SetValue(ctx, &oim.SetValueRequest{
-			Value: &oim.Value{
-				Path:  key,
-				Value: *value,
-			},
-		})
-		if err != nil {
-			logger.Fatalw("setting a registry value", "error", err, "path", key, "value", *value)
-		}
-	} else if *get {
-		if *value != "" {
-			logger.Fatalw("value not allowed for --get", "value", *value)
-		}
-		reply, err := registry.GetValues(ctx, &oim.GetValuesRequest{
-			Path: key,
-		})
-		if err != nil {
-			logger.Fatalw("getting registry values", "error", err)
-		}
-		sort.SliceStable(reply.Values, func(i, j int) bool {
-			return strings.Compare(reply.Values[i].Path, reply.Values[j].Path) < 0
-		})
-		for _, entry := range reply.Values {
-			fmt.Printf("%s=%s\n", entry.Path, entry.Value)
-		}
-	} else {
-		logger.Fatal("either --get or --set must be chosen")
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,71 @@
+/*
+Copyright 2018 Intel Coporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported values for --output.
+const (
+	// outputText is the traditional, line-oriented output.
+	outputText = "text"
+	// outputJSON prints one JSON document per result.
+	outputJSON = "json"
+	// outputYAML prints one YAML document per result.
+	outputYAML = "yaml"
+	// outputTable prints columns with a header.
+	outputTable = "table"
+)
+
+// printResult writes the result to stdout in the selected output
+// format. JSON and YAML are generated from the data, text output by
+// the callback and table output from the header and rows.
+func printResult(data interface{}, text func(w io.Writer), header []string, rows [][]string) error {
+	w := os.Stdout
+	switch output {
+	case outputText:
+		text(w)
+	case outputJSON:
+		encoded, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "encode JSON")
+		}
+		if _, err := w.Write(append(encoded, '\n')); err != nil {
+			return errors.Wrap(err, "write output")
+		}
+	case outputYAML:
+		encoded, err := yaml.Marshal(data)
+		if err != nil {
+			return errors.Wrap(err, "encode YAML")
+		}
+		if _, err := w.Write(append([]byte("---\n"), encoded...)); err != nil {
+			return errors.Wrap(err, "write output")
+		}
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		if header != nil {
+			io.WriteString(tw, strings.Join(header, "\t")+"\n") // nolint: gosec
+		}
+		for _, row := range rows {
+			io.WriteString(tw, strings.Join(row, "\t")+"\n") // nolint: gosec
+		}
+		if err := tw.Flush(); err != nil {
+			return errors.Wrap(err, "write output")
+		}
+	default:
+		return errors.Errorf("unsupported output format %q", output)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2018 Intel Coporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/intel/oim/pkg/log"
+	"github.com/intel/oim/pkg/oim-common"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+var (
+	expectedRevision int64
+	backupFileName   string
+	replace          bool
+)
+
+// registryValue is how values are represented in JSON and YAML
+// output.
+type registryValue struct {
+	Path     string `json:"path" yaml:"path"`
+	Value    string `json:"value" yaml:"value"`
+	Revision int64  `json:"revision" yaml:"revision"`
+}
+
+// registryEvent is how watch events are represented in JSON and YAML
+// output.
+type registryEvent struct {
+	Type     string          `json:"type" yaml:"type"`
+	Revision int64           `json:"revision" yaml:"revision"`
+	Values   []registryValue `json:"values" yaml:"values"`
+}
+
+func toRegistryValues(values []*oim.Value) []registryValue {
+	result := make([]registryValue, 0, len(values))
+	for _, value := range values {
+		result = append(result, registryValue{Path: value.Path, Value: value.Value, Revision: value.Revision})
+	}
+	return result
+}
+
+// registryPath sanitizes the path given on the command line.
+func registryPath(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	elements, err := oimcommon.SplitRegistryPath(args[0])
+	if err != nil {
+		return "", err
+	}
+	return oimcommon.JoinRegistryPath(elements), nil
+}
+
+// withRegistry connects to the registry and invokes the callback
+// with a client for it.
+func withRegistry(cb func(ctx context.Context, registry oim.RegistryClient) error) error {
+	ctx := context.Background()
+	conn, err := dialRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return cb(ctx, oim.NewRegistryClient(conn))
+}
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "read and modify registry values",
+}
+
+var registryGetCmd = &cobra.Command{
+	Use:   "get [<path>]",
+	Short: "retrieve the value with that path or all values below it",
+	Long: `Retrieves the value with that path or all values below it, or all values
+when no path is given. The text output consists of <path>=<value> lines.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := registryPath(args)
+		if err != nil {
+			return err
+		}
+		return withRegistry(func(ctx context.Context, registry oim.RegistryClient) error {
+			reply, err := registry.GetValues(ctx, &oim.GetValuesRequest{
+				Path: path,
+			})
+			if err != nil {
+				return errors.Wrap(err, "getting registry values")
+			}
+			sort.SliceStable(reply.Values, func(i, j int) bool {
+				return strings.Compare(reply.Values[i].Path, reply.Values[j].Path) < 0
+			})
+			var rows [][]string
+			for _, entry := range reply.Values {
+				rows = append(rows, []string{entry.Path, entry.Value, strconv.FormatInt(entry.Revision, 10)})
+			}
+			return printResult(toRegistryValues(reply.Values),
+				func(w io.Writer) {
+					for _, entry := range reply.Values {
+						fmt.Fprintf(w, "%s=%s\n", entry.Path, entry.Value)
+					}
+				},
+				[]string{"PATH", "VALUE", "REVISION"}, rows)
+		})
+	},
+}
+
+// setValue is used for both set and delete.
+func setValue(path, value string) error {
+	if path == "" {
+		return errors.New("path required")
+	}
+	return withRegistry(func(ctx context.Context, registry oim.RegistryClient) error {
+		request := &oim.SetValueRequest{
+			Value: &oim.Value{
+				Path:  path,
+				Value: value,
+			},
+		}
+		if expectedRevision >= 0 {
+			request.ExpectedRevision = &types.Int64Value{Value: expectedRevision}
+		}
+		reply, err := registry.SetValue(ctx, request)
+		if err != nil {
+			return errors.Wrapf(err, "setting registry value %q", path)
+		}
+		log.L().Debugw("modified registry", "path", path, "value", value, "revision", reply.Revision)
+		return nil
+	})
+}
+
+var registrySetCmd = &cobra.Command{
+	Use:   "set <path> <value>",
+	Short: "set or update a registry value",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := registryPath(args)
+		if err != nil {
+			return err
+		}
+		if args[1] == "" {
+			return errors.New("empty value, use delete instead")
+		}
+		return setValue(path, args[1])
+	},
+}
+
+var registryDeleteCmd = &cobra.Command{
+	Use:   "delete <path>",
+	Short: "remove a registry value",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := registryPath(args)
+		if err != nil {
+			return err
+		}
+		return setValue(path, "")
+	},
+}
+
+var registryWatchCmd = &cobra.Command{
+	Use:   "watch [<path>]",
+	Short: "print current values and then all changes until killed",
+	Long: `Prints current values and then all changes until killed.
+The text output consists of <type> <revision> <path>[=<value>] lines.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := registryPath(args)
+		if err != nil {
+			return err
+		}
+		return withRegistry(func(ctx context.Context, registry oim.RegistryClient) error {
+			stream, err := registry.WatchValues(ctx, &oim.WatchValuesRequest{
+				Path: path,
+			})
+			if err != nil {
+				return errors.Wrap(err, "watching registry values")
+			}
+			header := []string{"TYPE", "REVISION", "PATH", "VALUE"}
+			for {
+				reply, err := stream.Recv()
+				if err != nil {
+					return errors.Wrap(err, "watching registry values")
+				}
+				revision := strconv.FormatInt(reply.Revision, 10)
+				var rows [][]string
+				for _, entry := range reply.Values {
+					rows = append(rows, []string{reply.Type.String(), revision, entry.Path, entry.Value})
+				}
+				if err := printResult(registryEvent{Type: reply.Type.String(), Revision: reply.Revision, Values: toRegistryValues(reply.Values)},
+					func(w io.Writer) {
+						if reply.Type == oim.WatchValuesReply_SNAPSHOT && len(reply.Values) == 0 {
+							fmt.Fprintf(w, "%s %d\n", reply.Type, reply.Revision)
+						}
+						for _, entry := range reply.Values {
+							if reply.Type == oim.WatchValuesReply_DELETE {
+								fmt.Fprintf(w, "%s %d %s\n", reply.Type, reply.Revision, entry.Path)
+							} else {
+								fmt.Fprintf(w, "%s %d %s=%s\n", reply.Type, reply.Revision, entry.Path, entry.Value)
+							}
+						}
+					},
+					header, rows); err != nil {
+					return err
+				}
+				// Only print the table header once.
+				header = nil
+			}
+		})
+	},
+}
+
+var registryBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "write all registry values to a file (admin only)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withRegistry(func(ctx context.Context, registry oim.RegistryClient) error {
+			return errors.Wrap(backup(ctx, registry, backupFileName), "backing up registry values")
+		})
+	},
+}
+
+var registryRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "import all values from a backup file (admin only)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withRegistry(func(ctx context.Context, registry oim.RegistryClient) error {
+			reply, err := restore(ctx, registry, backupFileName, replace)
+			if err != nil {
+				return errors.Wrap(err, "restoring registry values")
+			}
+			for _, path := range reply.Skipped {
+				log.L().Infof("skipped temporary value %s", path)
+			}
+			log.L().Infof("restored registry values, new revision %d", reply.Revision)
+			return nil
+		})
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{registrySetCmd, registryDeleteCmd} {
+		cmd.Flags().Int64Var(&expectedRevision, "expected-revision", -1, "only modify the value if it was last modified at this revision (as reported by watch), 0 if it must not exist yet, -1 to modify unconditionally")
+	}
+	for _, cmd := range []*cobra.Command{registryBackupCmd, registryRestoreCmd} {
+		cmd.Flags().StringVar(&backupFileName, "file", "-", "the backup file, - for stdout resp. stdin")
+	}
+	registryRestoreCmd.Flags().BoolVar(&replace, "replace", false, "remove all values which are not in the backup")
+	registryCmd.AddCommand(registryGetCmd, registrySetCmd, registryDeleteCmd, registryWatchCmd, registryBackupCmd, registryRestoreCmd)
+	rootCmd.AddCommand(registryCmd)
+}
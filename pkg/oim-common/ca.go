@@ -0,0 +1,195 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// KeyType selects the algorithm and size of new keys.
+type KeyType string
+
+// Supported key types.
+const (
+	KeyRSA2048   KeyType = "rsa-2048"
+	KeyRSA4096   KeyType = "rsa-4096"
+	KeyECDSAP256 KeyType = "ecdsa-p256"
+	KeyECDSAP384 KeyType = "ecdsa-p384"
+	KeyEd25519   KeyType = "ed25519"
+)
+
+// KeyTypes lists all supported key types.
+var KeyTypes = []KeyType{KeyRSA2048, KeyRSA4096, KeyECDSAP256, KeyECDSAP384, KeyEd25519}
+
+// CertOptions determines how new keys and certificates are created.
+type CertOptions struct {
+	// KeyType is the type of the new key, KeyRSA2048 if empty.
+	KeyType KeyType
+	// Lifetime is the validity period of the new
+	// certificate, starting now.
+	Lifetime time.Duration
+}
+
+// CreateCA creates a new, self-signed CA with the given common name
+// and writes it as <base>.crt and <base>.key, i.e. the same layout
+// that LoadTLSConfig expects for key pairs. The .crt file is also
+// the CA file for LoadTLSConfig. Existing files are not overwritten.
+func CreateCA(base, commonName string, options CertOptions) error {
+	key, err := generateKey(options.KeyType)
+	if err != nil {
+		return err
+	}
+	template, err := newTemplate(options.Lifetime)
+	if err != nil {
+		return err
+	}
+	template.Subject = pkix.Name{CommonName: commonName}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return errors.Wrap(err, "create CA certificate")
+	}
+	return writeKeyPair(base, der, key)
+}
+
+// IssueCert creates a new key and a certificate for the identity,
+// signed by the CA in <caBase>.crt and <caBase>.key, and writes them
+// as <base>.crt and <base>.key. The identity is stored as common
+// name, DNS SAN and SPIFFE ID, so all versions of OIM accept it.
+// Existing files are not overwritten.
+func IssueCert(caBase string, identity Identity, base string, options CertOptions) error {
+	switch identity.Role {
+	case RoleUser, RoleHost, RoleController, RoleComponent:
+	default:
+		return errors.Errorf("unknown role %q", identity.Role)
+	}
+	// The ID ends up in registry paths and gets matched against
+	// policy patterns, see DefaultPolicyYAML in oimregistry.
+	if err := CheckPathElement(identity.ID); err != nil {
+		return errors.Wrap(err, "invalid ID")
+	}
+
+	caCrtFile, caKeyFile := KeyPairFiles(caBase)
+	ca, err := tls.LoadX509KeyPair(caCrtFile, caKeyFile)
+	if err != nil {
+		return errors.Wrapf(err, "load CA %q", caBase)
+	}
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return errors.Wrapf(err, "parse CA certificate %q", caCrtFile)
+	}
+
+	key, err := generateKey(options.KeyType)
+	if err != nil {
+		return err
+	}
+	template, err := newTemplate(options.Lifetime)
+	if err != nil {
+		return err
+	}
+	if template.NotAfter.After(caCert.NotAfter) {
+		template.NotAfter = caCert.NotAfter
+	}
+	template.Subject = pkix.Name{CommonName: identity.String()}
+	template.DNSNames = []string{identity.String()}
+	template.URIs = []*url.URL{identity.URI()}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), ca.PrivateKey)
+	if err != nil {
+		return errors.Wrapf(err, "create certificate for %s", identity)
+	}
+	return writeKeyPair(base, der, key)
+}
+
+func generateKey(keyType KeyType) (crypto.Signer, error) {
+	switch keyType {
+	case "", KeyRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, errors.Errorf("unsupported key type %q, must be one of %v", keyType, KeyTypes)
+	}
+}
+
+// newTemplate returns a certificate template with a random serial
+// number and the given lifetime.
+func newTemplate(lifetime time.Duration) (*x509.Certificate, error) {
+	if lifetime <= 0 {
+		return nil, errors.Errorf("invalid lifetime %s", lifetime)
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errors.Wrap(err, "serial number")
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		// Allow some clock skew between hosts.
+		NotBefore: now.Add(-5 * time.Minute),
+		NotAfter:  now.Add(lifetime),
+	}, nil
+}
+
+// writeKeyPair stores the certificate and key in PEM format. The key
+// is only readable by the current user.
+func writeKeyPair(base string, der []byte, key crypto.Signer) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return errors.Wrap(err, "marshal key")
+	}
+	crtFile, keyFile := KeyPairFiles(base)
+	if err := writeNewFile(keyFile, &pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}, 0600); err != nil {
+		return err
+	}
+	if err := writeNewFile(crtFile, &pem.Block{Type: "CERTIFICATE", Bytes: der}, 0644); err != nil {
+		os.Remove(keyFile) // nolint: gosec
+		return err
+	}
+	return nil
+}
+
+func writeNewFile(filename string, block *pem.Block, perm os.FileMode) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return errors.Wrap(err, "create file")
+	}
+	if err := pem.Encode(file, block); err != nil {
+		file.Close() // nolint: gosec
+		return errors.Wrapf(err, "write %q", filename)
+	}
+	return errors.Wrapf(file.Close(), "close %q", filename)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCA(t *testing.T) {
+	for _, keyType := range KeyTypes {
+		t.Run(string(keyType), func(t *testing.T) {
+			tmp, err := ioutil.TempDir("", "ca")
+			require.NoError(t, err)
+			defer os.RemoveAll(tmp)
+
+			options := CertOptions{KeyType: keyType, Lifetime: time.Hour}
+			ca := filepath.Join(tmp, "ca")
+			require.NoError(t, CreateCA(ca, "test CA", options))
+			assert.Error(t, CreateCA(ca, "test CA", options), "overwrite CA")
+
+			registry := filepath.Join(tmp, "registry")
+			host := filepath.Join(tmp, "host")
+			require.NoError(t, IssueCert(ca, RegistryIdentity, registry, options))
+			require.NoError(t, IssueCert(ca, Identity{Role: RoleHost, ID: "host-0"}, host, options))
+			assert.Error(t, IssueCert(ca, RegistryIdentity, registry, options), "overwrite cert")
+
+			info, err := os.Stat(host + ".key")
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "key permissions")
+			cert := readCert(t, host+".crt")
+			identity, err := CertIdentity(cert)
+			require.NoError(t, err)
+			assert.Equal(t, "host.host-0", identity.String())
+			assert.Equal(t, "host.host-0", cert.Subject.CommonName)
+			assert.Equal(t, []string{"host.host-0"}, cert.DNSNames)
+			assert.Equal(t, "spiffe://oim/host/host-0", cert.URIs[0].String())
+
+			serverConfig, err := LoadTLSConfig(ca+".crt", registry, "host.host-0")
+			require.NoError(t, err)
+			clientConfig, err := LoadTLSConfig(ca+".crt", host, RegistryIdentity.String())
+			require.NoError(t, err)
+			serverCN, clientCN, err := handshake(t, clientConfig, serverConfig)
+			require.NoError(t, err)
+			assert.Equal(t, "component.registry", serverCN)
+			assert.Equal(t, "host.host-0", clientCN)
+		})
+	}
+}
+
+func TestIssueCertInvalid(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ca")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	options := CertOptions{Lifetime: 24 * time.Hour}
+	ca := filepath.Join(tmp, "ca")
+	require.NoError(t, CreateCA(ca, "test CA", CertOptions{Lifetime: time.Hour}))
+
+	out := filepath.Join(tmp, "out")
+	assert.Error(t, IssueCert(ca, Identity{Role: "foo", ID: "bar"}, out, options), "unknown role")
+	assert.Error(t, IssueCert(ca, Identity{Role: RoleUser}, out, options), "no ID")
+	assert.Error(t, IssueCert(ca, Identity{Role: RoleHost, ID: "*"}, out, options), "wildcard ID")
+	assert.Error(t, IssueCert(ca, Identity{Role: RoleController, ID: "host-0/pci"}, out, options), "path ID")
+	assert.Error(t, IssueCert(filepath.Join(tmp, "no-such-ca"), Identity{Role: RoleUser, ID: "admin"}, out, options), "no CA")
+	assert.Error(t, IssueCert(ca, Identity{Role: RoleUser, ID: "admin"}, out, CertOptions{KeyType: "foo", Lifetime: time.Hour}), "key type")
+	assert.Error(t, IssueCert(ca, Identity{Role: RoleUser, ID: "admin"}, out, CertOptions{}), "lifetime")
+
+	// The certificate does not outlive the CA.
+	require.NoError(t, IssueCert(ca, Identity{Role: RoleUser, ID: "admin"}, out, options))
+	assert.Equal(t, readCert(t, ca+".crt").NotAfter, readCert(t, out+".crt").NotAfter)
+
+	// Certificates can also be issued by a CA created with
+	// certstrap.
+	require.NoError(t, IssueCert(os.ExpandEnv("${TEST_WORK}/ca/ca"), Identity{Role: RoleUser, ID: "admin"}, filepath.Join(tmp, "certstrap"), options))
+}
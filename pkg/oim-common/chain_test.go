@@ -0,0 +1,56 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestChainUnaryServer(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			calls = append(calls, name+" pre")
+			resp, err := handler(ctx, req)
+			calls = append(calls, name+" post")
+			return resp, err
+		}
+	}
+	chained := ChainUnaryServer(interceptor("a"), interceptor("b"))
+	resp, err := chained(context.Background(), "req", &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls = append(calls, "handler "+req.(string))
+			return "resp", nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, "resp", resp)
+	assert.Equal(t, []string{"a pre", "b pre", "handler req", "b post", "a post"}, calls)
+}
+
+func TestChainUnaryClient(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) grpc.UnaryClientInterceptor {
+		return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			calls = append(calls, name+" pre")
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			calls = append(calls, name+" post")
+			return err
+		}
+	}
+	chained := ChainUnaryClient(interceptor("a"), interceptor("b"))
+	err := chained(context.Background(), "/foo", "req", nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			calls = append(calls, "invoker "+method)
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a pre", "b pre", "invoker /foo", "b post", "a post"}, calls)
+}
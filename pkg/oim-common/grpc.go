@@ -7,17 +7,18 @@ SPDX-License-Identifier: Apache-2.0
 package oimcommon
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
 	"io/ioutil"
 	"net"
 	"strings"
 	"time"
 
-	// "github.com/grpc-ecosystem/go-grpc-middleware"
-	// "github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
-	// "github.com/opentracing/opentracing-go"
+	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
+	"github.com/opentracing/opentracing-go"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
@@ -54,13 +55,19 @@ func ChooseDialOpts(endpoint string, opts ...grpc.DialOption) []grpc.DialOption
 
 	// Tracing of outgoing calls, including remote and local logging.
 	formatter := StripSecretsFormatter{}
-	// interceptor := grpc_middleware.ChainUnaryClient(
-	// 	otgrpc.OpenTracingClientInterceptor(
-	// 		opentracing.GlobalTracer(),
-	// 		otgrpc.SpanDecorator(TraceGRPCPayload(formatter))),
-	// 	LogGRPCClient(formatter))
-	interceptor := LogGRPCClient(formatter)
-	opts = append(opts, grpc.WithUnaryInterceptor(interceptor))
+	interceptor := ChainUnaryClient(
+		otgrpc.OpenTracingClientInterceptor(
+			opentracing.GlobalTracer(),
+			otgrpc.SpanDecorator(TraceGRPCPayload(formatter))),
+		MetricsGRPCClient(),
+		LogGRPCClient(formatter))
+	streamInterceptor := ChainStreamClient(
+		otgrpc.OpenTracingStreamClientInterceptor(opentracing.GlobalTracer()),
+		MetricsGRPCClientStream())
+	opts = append(opts,
+		grpc.WithUnaryInterceptor(interceptor),
+		grpc.WithStreamInterceptor(streamInterceptor),
+	)
 
 	result = append(result, opts...)
 	return result
@@ -68,21 +75,43 @@ func ChooseDialOpts(endpoint string, opts ...grpc.DialOption) []grpc.DialOption
 
 // LoadTLSConfig sets up the necessary TLS configuration for a
 // client or server. The peer name must be set when expecting the
-// peer to offer a certificate with that common name, otherwise it can
-// be left empty.
+// peer to offer a certificate with that identity (see CertIdentity),
+// otherwise it can be left empty.
 //
 // caFile must be the full file name. keyFile can either be the .crt
 // file (foo.crt, implies foo.key) or the base name (foo for foo.crt
 // and foo.key).
-func LoadTLSConfig(caFile, key, peerName string) (*tls.Config, error) {
-	var base string
-	if strings.HasSuffix(key, ".key") || strings.HasSuffix(key, ".crt") {
-		base = key[0 : len(key)-4]
-	} else {
-		base = key
+func LoadTLSConfig(caFile, key, peerName string, opts ...TLSOption) (*tls.Config, error) {
+	options := tlsOptions{}
+	for _, op := range opts {
+		op(&options)
+	}
+	return loadTLSConfig(caFile, key, peerName, options)
+}
+
+// TLSOption is an optional parameter for LoadTLSConfig and
+// WatchTLSConfig.
+type TLSOption func(*tlsOptions)
+
+type tlsOptions struct {
+	crlFile string
+}
+
+// WithCRL enables checking of all peer certificates against the
+// certificate revocation list in the given file (PEM or DER). The
+// list must be signed by one of the CAs. It is used regardless of
+// its next update time because an outdated list is still better than
+// none. An empty file name disables the check.
+func WithCRL(crlFile string) TLSOption {
+	return func(options *tlsOptions) {
+		options.crlFile = crlFile
 	}
-	crtFile := base + ".crt"
-	keyFile := base + ".key"
+}
+
+// loadTLSConfig reads all files and creates the configuration
+// returned by LoadTLSConfig.
+func loadTLSConfig(caFile, key, peerName string, options tlsOptions) (*tls.Config, error) {
+	crtFile, keyFile := KeyPairFiles(key)
 	certificate, err := tls.LoadX509KeyPair(crtFile, keyFile)
 	if err != nil {
 		return nil, errors.Wrapf(err, "load X509 key pair for key=%q", key)
@@ -99,21 +128,113 @@ func LoadTLSConfig(caFile, key, peerName string) (*tls.Config, error) {
 		return nil, errors.Errorf("failed to append certs from %q", caFile)
 	}
 
-	tlsConfig := &tls.Config{
-		ServerName: peerName, // Common name check when connecting to server.
-		VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
-			// Common name check when accepting a connection from a client.
+	var crl *x509.RevocationList
+	if options.crlFile != "" {
+		crl, err = loadCRL(options.crlFile, bs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newTLSConfig(certificate, certPool, crl, peerName), nil
+}
+
+// KeyPairFiles returns the .crt and .key file names for the key
+// parameter of LoadTLSConfig. The key may be given as base name or
+// as name of one of the two files.
+func KeyPairFiles(key string) (crtFile, keyFile string) {
+	var base string
+	if strings.HasSuffix(key, ".key") || strings.HasSuffix(key, ".crt") {
+		base = key[0 : len(key)-4]
+	} else {
+		base = key
+	}
+	return base + ".crt", base + ".key"
+}
+
+// loadCRL reads a certificate revocation list and checks that it was
+// signed by one of the CA certificates in the PEM data.
+func loadCRL(crlFile string, caPEM []byte) (*x509.RevocationList, error) {
+	data, err := ioutil.ReadFile(crlFile) // nolint: gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "read CRL")
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "X509 CRL" {
+			return nil, errors.Errorf("%q: unexpected PEM block %q", crlFile, block.Type)
+		}
+		data = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse CRL %q", crlFile)
+	}
+	for block, rest := pem.Decode(caPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if crl.CheckSignatureFrom(ca) == nil {
+			return crl, nil
+		}
+	}
+	return nil, errors.Errorf("CRL %q not signed by any of the CAs", crlFile)
+}
+
+// newTLSConfig creates the configuration returned by LoadTLSConfig.
+func newTLSConfig(certificate tls.Certificate, certPool *x509.CertPool, crl *x509.RevocationList, peerName string) *tls.Config {
+	var crlIssuer []byte
+	revoked := map[string]bool{}
+	if crl != nil {
+		crlIssuer = crl.RawIssuer
+		for _, entry := range crl.RevokedCertificateEntries {
+			revoked[entry.SerialNumber.String()] = true
+		}
+	}
+
+	return &tls.Config{
+		ServerName: peerName, // Identity check when connecting to server.
+
+		// The server certificate gets verified in
+		// VerifyConnection because crypto/tls only supports
+		// DNS names and not SPIFFE IDs.
+		InsecureSkipVerify: true, // nolint: gosec
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			verifiedChains := cs.VerifiedChains
+			if len(verifiedChains) == 0 {
+				// Connection to a server, not verified yet.
+				chains, err := verifyServer(certPool, cs)
+				if err != nil {
+					return err
+				}
+				verifiedChains = chains
+			}
+
+			// Revocation check for client and server.
+			for _, chain := range verifiedChains {
+				for _, cert := range chain {
+					if revoked[cert.SerialNumber.String()] &&
+						bytes.Equal(cert.RawIssuer, crlIssuer) {
+						return errors.Errorf("certificate %q with serial number %s was revoked",
+							cert.Subject.CommonName, cert.SerialNumber)
+					}
+				}
+			}
+
+			// Identity check, in particular when accepting a
+			// connection from a client.
 			if peerName == "" {
 				// All names allowed.
 				return nil
 			}
-			if len(verifiedChains) == 0 ||
-				len(verifiedChains[0]) == 0 {
-				return errors.New("no valid certificate")
+			identity, err := CertIdentity(verifiedChains[0][0])
+			if err != nil {
+				return err
 			}
-			commonName := verifiedChains[0][0].Subject.CommonName
-			if commonName != peerName {
-				return errors.Errorf("expected CN %q, got %q", peerName, commonName)
+			if identity.String() != peerName {
+				return errors.Errorf("expected identity %q, got %q", peerName, identity)
 			}
 			return nil
 		},
@@ -123,13 +244,42 @@ func LoadTLSConfig(caFile, key, peerName string) (*tls.Config, error) {
 		ClientCAs:    certPool,
 		ClientAuth:   tls.RequireAndVerifyClientCert,
 	}
-	return tlsConfig, nil
+}
+
+// verifyServer does the same checks for a connection to a server as
+// crypto/tls, except that the server name may also match the
+// identity in a SPIFFE ID.
+func verifyServer(roots *x509.CertPool, cs tls.ConnectionState) ([][]*x509.Certificate, error) {
+	if len(cs.PeerCertificates) == 0 {
+		return nil, errors.New("no server certificate")
+	}
+	if cs.ServerName == "" {
+		return nil, errors.New("no server name")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	leaf := cs.PeerCertificates[0]
+	chains, err := leaf.Verify(opts)
+	if _, ok := err.(x509.HostnameError); ok {
+		// Try again without the name check.
+		opts.DNSName = ""
+		if identity, idErr := CertIdentity(leaf); idErr == nil && identity.String() == cs.ServerName {
+			return leaf.Verify(opts)
+		}
+	}
+	return chains, err
 }
 
 // LoadTLS is identical to LoadTLSConfig except that it returns
 // the TransportCredentials for a gRPC client or server.
-func LoadTLS(caFile, key, peerName string) (credentials.TransportCredentials, error) {
-	tlsConfig, err := LoadTLSConfig(caFile, key, peerName)
+func LoadTLS(caFile, key, peerName string, opts ...TLSOption) (credentials.TransportCredentials, error) {
+	tlsConfig, err := LoadTLSConfig(caFile, key, peerName, opts...)
 	if err != nil {
 		return nil, err
 	}
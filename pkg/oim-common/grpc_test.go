@@ -0,0 +1,145 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readCert(t *testing.T, file string) *x509.Certificate {
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	block, _ := pem.Decode(data)
+	require.NotNil(t, block, file)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return cert
+}
+
+// writeCRL creates a CRL for the test CA which revokes the given
+// certificates.
+func writeCRL(t *testing.T, file string, names ...string) {
+	caCert := readCert(t, os.ExpandEnv("${TEST_WORK}/ca/ca.crt"))
+	data, err := ioutil.ReadFile(os.ExpandEnv("${TEST_WORK}/ca/ca.key"))
+	require.NoError(t, err)
+	block, _ := pem.Decode(data)
+	require.NotNil(t, block, "ca.key")
+	caKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	require.NoError(t, err)
+
+	template := &x509.RevocationList{
+		Number:     big.NewInt(time.Now().UnixNano()),
+		ThisUpdate: time.Now(),
+		NextUpdate: time.Now().Add(time.Hour),
+	}
+	for _, name := range names {
+		cert := readCert(t, os.ExpandEnv("${TEST_WORK}/ca/"+name+".crt"))
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries,
+			x509.RevocationListEntry{
+				SerialNumber:   cert.SerialNumber,
+				RevocationTime: time.Now(),
+			})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, template, caCert, caKey)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(file+".tmp", pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0600))
+	require.NoError(t, os.Rename(file+".tmp", file))
+}
+
+func TestCRL(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "crl")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	ca := os.ExpandEnv("${TEST_WORK}/ca/ca.crt")
+	crl := filepath.Join(tmp, "ca.crl")
+	writeCRL(t, crl, "controller.host-1", "component.registry")
+	serverConfig, err := LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/controller.host-0.key"), "", WithCRL(crl))
+	require.NoError(t, err)
+
+	for name, expectError := range map[string]bool{
+		"controller.host-0": false,
+		"controller.host-1": true,
+		"controller.host-2": false,
+	} {
+		clientConfig, err := LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/"+name+".key"), "controller.host-0")
+		require.NoError(t, err)
+		_, _, err = handshake(t, clientConfig, serverConfig)
+		if expectError {
+			if assert.Error(t, err, name) {
+				assert.Contains(t, err.Error(), "revoked", name)
+			}
+		} else {
+			assert.NoError(t, err, name)
+		}
+	}
+
+	// The client also checks the server.
+	serverConfig, err = LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+	require.NoError(t, err)
+	clientConfig, err := LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/controller.host-0.key"), "component.registry", WithCRL(crl))
+	require.NoError(t, err)
+	_, _, err = handshake(t, clientConfig, serverConfig)
+	assert.Error(t, err, "revoked server")
+
+	// The empty CRL created by certstrap is also valid.
+	_, err = LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/controller.host-0.key"), "", WithCRL(os.ExpandEnv("${TEST_WORK}/ca/ca.crl")))
+	assert.NoError(t, err, "certstrap CRL")
+
+	// A CRL from some other CA is not.
+	_, err = LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/controller.host-0.key"), "", WithCRL(os.ExpandEnv("${TEST_WORK}/evil-ca/evil-ca.crl")))
+	assert.Error(t, err, "evil CRL")
+}
+
+func TestWatchCRL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmp, err := ioutil.TempDir("", "crl")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	ca := os.ExpandEnv("${TEST_WORK}/ca/ca.crt")
+	crl := filepath.Join(tmp, "ca.crl")
+	writeCRL(t, crl)
+	serverConfig, err := WatchTLSConfig(ctx, ca, os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "", WithCRL(crl))
+	require.NoError(t, err)
+	clientConfig, err := LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/host.host-0.key"), "component.registry")
+	require.NoError(t, err)
+	_, _, err = handshake(t, clientConfig, serverConfig)
+	require.NoError(t, err, "not revoked yet")
+
+	writeCRL(t, crl, "host.host-0")
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		_, _, err = handshake(t, clientConfig, serverConfig)
+		if err != nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Error(t, err, "revoked")
+}
+
+func TestKeyPairFiles(t *testing.T) {
+	for _, key := range []string{"ca/user.admin", "ca/user.admin.key", "ca/user.admin.crt"} {
+		crtFile, keyFile := KeyPairFiles(key)
+		assert.Equal(t, "ca/user.admin.crt", crtFile, key)
+		assert.Equal(t, "ca/user.admin.key", keyFile, key)
+	}
+}
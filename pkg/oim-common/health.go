@@ -0,0 +1,190 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/intel/oim/pkg/log"
+)
+
+// HealthCheck returns nil if the component that it checks is
+// working.
+type HealthCheck func(ctx context.Context) error
+
+// HealthServer implements the standard gRPC health checking protocol
+// (grpc.health.v1). The status of a service is determined anew for
+// each request by running all checks that were added for it. The
+// empty service name refers to the server as a whole, which is only
+// serving if all checks of all services pass.
+type HealthServer struct {
+	// Timeout limits how long checks may run. A check that does
+	// not finish in time counts as failed.
+	Timeout time.Duration
+	// WatchInterval determines how often checks are repeated
+	// while a client is watching.
+	WatchInterval time.Duration
+	// Forward, if set, gets called for each request. When it
+	// returns a client, the request is passed on to that client
+	// with the returned context instead of running the checks,
+	// and release gets called once the request is done.
+	Forward func(ctx context.Context) (client healthpb.HealthClient, outCtx context.Context, release func(), err error)
+
+	mutex  sync.Mutex
+	checks map[string][]HealthCheck
+}
+
+var _ healthpb.HealthServer = &HealthServer{}
+
+// NewHealthServer creates a health server without any services.
+func NewHealthServer() *HealthServer {
+	return &HealthServer{
+		Timeout:       10 * time.Second,
+		WatchInterval: 5 * time.Second,
+		checks:        map[string][]HealthCheck{},
+	}
+}
+
+// AddCheck adds a check for the service. A nil check merely makes
+// the service known, which then is always serving.
+func (h *HealthServer) AddCheck(service string, check HealthCheck) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	checks := h.checks[service]
+	if check != nil {
+		checks = append(checks, check)
+	}
+	h.checks[service] = checks
+}
+
+// Register adds the health service to a gRPC server.
+func (h *HealthServer) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, h)
+}
+
+// Check implements the Check RPC.
+func (h *HealthServer) Check(ctx context.Context, in *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if h.Forward != nil {
+		client, outCtx, release, err := h.Forward(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if client != nil {
+			defer release()
+			return client.Check(outCtx, in)
+		}
+	}
+	servingStatus := h.status(ctx, in.GetService())
+	if servingStatus == healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", in.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch implements the Watch RPC. It sends the current status and
+// then each change, as determined by running the checks periodically.
+func (h *HealthServer) Watch(in *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	if h.Forward != nil {
+		client, outCtx, release, err := h.Forward(ctx)
+		if err != nil {
+			return err
+		}
+		if client != nil {
+			defer release()
+			return forwardWatch(outCtx, client, in, stream)
+		}
+	}
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		servingStatus := h.status(ctx, in.GetService())
+		if servingStatus != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return err
+			}
+			last = servingStatus
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(h.WatchInterval):
+		}
+	}
+}
+
+// forwardWatch passes all responses from the client on to the
+// stream until either side is done.
+func forwardWatch(ctx context.Context, client healthpb.HealthClient, in *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	watch, err := client.Watch(ctx, in)
+	if err != nil {
+		return err
+	}
+	for {
+		response, err := watch.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+}
+
+// status runs the checks for the service.
+func (h *HealthServer) status(ctx context.Context, service string) healthpb.HealthCheckResponse_ServingStatus {
+	h.mutex.Lock()
+	var checks []HealthCheck
+	if service == "" {
+		for _, c := range h.checks {
+			checks = append(checks, c...)
+		}
+	} else {
+		var ok bool
+		checks, ok = h.checks[service]
+		if !ok {
+			h.mutex.Unlock()
+			return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+	}
+	h.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+	for _, check := range checks {
+		if err := h.run(ctx, check); err != nil {
+			log.FromContext(ctx).Warnw("health check failed", "service", service, "error", err)
+			return healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// run runs a single check in the background, because not all checks
+// honor the context.
+func (h *HealthServer) run(ctx context.Context, check HealthCheck) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- check(ctx)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	h := NewHealthServer()
+	h.Timeout = 100 * time.Millisecond
+	failing := errors.New("broken")
+	h.AddCheck("ok", func(ctx context.Context) error { return nil })
+	h.AddCheck("always", nil)
+	h.AddCheck("broken", func(ctx context.Context) error { return nil })
+	h.AddCheck("broken", func(ctx context.Context) error { return failing })
+	h.AddCheck("hanging", func(ctx context.Context) error { select {} })
+
+	for service, expected := range map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"ok":      healthpb.HealthCheckResponse_SERVING,
+		"always":  healthpb.HealthCheckResponse_SERVING,
+		"broken":  healthpb.HealthCheckResponse_NOT_SERVING,
+		"hanging": healthpb.HealthCheckResponse_NOT_SERVING,
+		"":        healthpb.HealthCheckResponse_NOT_SERVING,
+	} {
+		reply, err := h.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if assert.NoError(t, err, service) {
+			assert.Equal(t, expected, reply.Status, service)
+		}
+	}
+
+	_, err := h.Check(ctx, &healthpb.HealthCheckRequest{Service: "foobar"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestHealthServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmp, err := ioutil.TempDir("", "health")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	var mutex sync.Mutex
+	var healthErr error
+	s := NonBlockingGRPCServer{
+		Endpoint: "unix://" + filepath.Join(tmp, "server.sock"),
+		Health:   NewHealthServer(),
+	}
+	s.Health.WatchInterval = 10 * time.Millisecond
+	s.Health.AddCheck("test", func(ctx context.Context) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return healthErr
+	})
+	require.NoError(t, s.Start(ctx))
+	defer func() {
+		s.ForceStop(ctx)
+		s.Wait(ctx)
+	}()
+
+	conn, err := grpc.Dial(s.Endpoint, ChooseDialOpts(s.Endpoint, grpc.WithInsecure())...)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	reply, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, reply.Status)
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "test"})
+	require.NoError(t, err)
+	reply, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, reply.Status)
+	mutex.Lock()
+	healthErr = errors.New("broken")
+	mutex.Unlock()
+	reply, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, reply.Status)
+}
+
+func TestHealthForward(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmp, err := ioutil.TempDir("", "health")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	var servers []*NonBlockingGRPCServer
+	defer func() {
+		for _, s := range servers {
+			s.ForceStop(ctx)
+			s.Wait(ctx)
+		}
+	}()
+	dial := func(name string, health *HealthServer) *grpc.ClientConn {
+		s := &NonBlockingGRPCServer{
+			Endpoint: "unix://" + filepath.Join(tmp, name+".sock"),
+			Health:   health,
+		}
+		require.NoError(t, s.Start(ctx))
+		servers = append(servers, s)
+		conn, err := grpc.Dial(s.Endpoint, ChooseDialOpts(s.Endpoint, grpc.WithInsecure())...)
+		require.NoError(t, err)
+		return conn
+	}
+
+	back := NewHealthServer()
+	back.AddCheck("back", nil)
+	backConn := dial("back", back)
+	defer backConn.Close()
+
+	var mutex sync.Mutex
+	released := 0
+	front := NewHealthServer()
+	front.AddCheck("front", nil)
+	front.Forward = func(ctx context.Context) (healthpb.HealthClient, context.Context, func(), error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		switch {
+		case len(md["invalid"]) > 0:
+			return nil, nil, nil, status.Error(codes.FailedPrecondition, "invalid")
+		case len(md["forward"]) > 0:
+			return healthpb.NewHealthClient(backConn), ctx, func() {
+				mutex.Lock()
+				defer mutex.Unlock()
+				released++
+			}, nil
+		}
+		return nil, nil, nil, nil
+	}
+	frontConn := dial("front", front)
+	defer frontConn.Close()
+	client := healthpb.NewHealthClient(frontConn)
+
+	// Answered locally.
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "front"})
+	assert.NoError(t, err)
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "back"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Forwarded.
+	forwardCtx := metadata.AppendToOutgoingContext(ctx, "forward", "1")
+	_, err = client.Check(forwardCtx, &healthpb.HealthCheckRequest{Service: "back"})
+	assert.NoError(t, err)
+	_, err = client.Check(forwardCtx, &healthpb.HealthCheckRequest{Service: "front"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	mutex.Lock()
+	assert.Equal(t, 2, released)
+	mutex.Unlock()
+	stream, err := client.Watch(forwardCtx, &healthpb.HealthCheckRequest{Service: "back"})
+	require.NoError(t, err)
+	reply, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, reply.Status)
+
+	// Rejected.
+	_, err = client.Check(metadata.AppendToOutgoingContext(ctx, "invalid", "1"), &healthpb.HealthCheckRequest{Service: "front"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
@@ -0,0 +1,145 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"context"
+	"crypto/x509"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// Roles with a special meaning in OIM.
+const (
+	// RoleUser is used by administrators and other people.
+	RoleUser = "user"
+	// RoleHost is used by the OIM CSI driver on a host.
+	RoleHost = "host"
+	// RoleController is used by the OIM controller.
+	RoleController = "controller"
+	// RoleComponent is used by other OIM components (registry).
+	RoleComponent = "component"
+)
+
+// SPIFFETrustDomain is the trust domain in SPIFFE IDs of OIM
+// certificates: spiffe://oim/<role>/<id>.
+const SPIFFETrustDomain = "oim"
+
+// Identity identifies a client or server. It is derived from its
+// certificate.
+type Identity struct {
+	// Role is user, host, controller or component.
+	Role string
+	// ID is a user name, a controller ID (both for hosts and
+	// controllers) or a component name.
+	ID string
+}
+
+// RegistryIdentity is the identity of the OIM registry.
+var RegistryIdentity = Identity{Role: RoleComponent, ID: "registry"}
+
+// ControllerIdentity returns the identity of the OIM controller with
+// the given ID.
+func ControllerIdentity(controllerID string) Identity {
+	return Identity{Role: RoleController, ID: controllerID}
+}
+
+// String returns the <role>.<id> name that is used in common names,
+// DNS names and authorization policies.
+func (i Identity) String() string {
+	return i.Role + "." + i.ID
+}
+
+// URI returns the SPIFFE ID for the identity.
+func (i Identity) URI() *url.URL {
+	return &url.URL{
+		Scheme: "spiffe",
+		Host:   SPIFFETrustDomain,
+		Path:   "/" + i.Role + "/" + i.ID,
+	}
+}
+
+// ParseIdentity splits a <role>.<id> name. The role is everything
+// up to the first dot, the ID everything after it.
+func ParseIdentity(name string) (Identity, error) {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Identity{}, errors.Errorf("%q: not of the form <role>.<id>", name)
+	}
+	return Identity{Role: parts[0], ID: parts[1]}, nil
+}
+
+// ParseIdentityURI parses a spiffe://oim/<role>/<id> SPIFFE ID.
+func ParseIdentityURI(uri *url.URL) (Identity, error) {
+	if uri.Scheme != "spiffe" || uri.Host != SPIFFETrustDomain {
+		return Identity{}, errors.Errorf("%q: not a SPIFFE ID in the %q trust domain", uri, SPIFFETrustDomain)
+	}
+	parts := strings.Split(strings.TrimPrefix(uri.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Identity{}, errors.Errorf("%q: not of the form spiffe://%s/<role>/<id>", uri, SPIFFETrustDomain)
+	}
+	return Identity{Role: parts[0], ID: parts[1]}, nil
+}
+
+// CertIdentity determines the identity of the certificate owner.
+// SPIFFE IDs in URI SANs are checked first, then DNS SANs with a
+// known role (user.admin, host.host-0, ...), and finally the common
+// name. The common name is only used as fallback because Go and many
+// CAs treat it as deprecated.
+//
+// A certificate with a SPIFFE ID in the OIM trust domain must be
+// valid, the other names are not considered in that case. In DNS
+// SANs, the ID must be a single DNS label, because otherwise an
+// ordinary host name like host.example.com would be treated as
+// identity.
+func CertIdentity(cert *x509.Certificate) (Identity, error) {
+	for _, uri := range cert.URIs {
+		if uri.Scheme != "spiffe" || uri.Host != SPIFFETrustDomain {
+			continue
+		}
+		identity, err := ParseIdentityURI(uri)
+		if err != nil {
+			return Identity{}, errors.Wrap(err, "invalid identity in certificate")
+		}
+		return identity, nil
+	}
+	for _, name := range cert.DNSNames {
+		if identity, err := ParseIdentity(name); err == nil && !strings.Contains(identity.ID, ".") {
+			switch identity.Role {
+			case RoleUser, RoleHost, RoleController, RoleComponent:
+				return identity, nil
+			}
+		}
+	}
+	identity, err := ParseIdentity(cert.Subject.CommonName)
+	if err != nil {
+		return Identity{}, errors.Wrap(err, "no identity in certificate")
+	}
+	return identity, nil
+}
+
+// PeerIdentity determines the identity of the peer of a gRPC call
+// via the verified TLS certificate.
+func PeerIdentity(ctx context.Context) (Identity, error) {
+	peer, ok := peer.FromContext(ctx)
+	if !ok {
+		return Identity{}, errors.New("cannot determine caller identity")
+	}
+	tlsInfo, ok := peer.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return Identity{}, errors.New("no TLS info")
+	}
+	if len(tlsInfo.State.VerifiedChains) == 0 ||
+		len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return Identity{}, errors.New("cannot determine peer, empty TLS verification chain")
+	}
+	return CertIdentity(tlsInfo.State.VerifiedChains[0][0])
+}
@@ -0,0 +1,212 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIdentity(t *testing.T) {
+	for name, expected := range map[string]Identity{
+		"user.admin":           {Role: "user", ID: "admin"},
+		"host.host-0":          {Role: "host", ID: "host-0"},
+		"controller.foo.bar":   {Role: "controller", ID: "foo.bar"},
+		"component.registry":   {Role: "component", ID: "registry"},
+		"no-dot":               {},
+		".no-role":             {},
+		"no-id.":               {},
+		"":                     {},
+		"user.admin-site-a.de": {Role: "user", ID: "admin-site-a.de"},
+	} {
+		identity, err := ParseIdentity(name)
+		if expected.Role == "" {
+			assert.Error(t, err, name)
+			continue
+		}
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, expected, identity, name)
+			assert.Equal(t, name, identity.String(), name)
+		}
+	}
+}
+
+func TestParseIdentityURI(t *testing.T) {
+	for uri, expected := range map[string]Identity{
+		"spiffe://oim/host/host-0":       {Role: "host", ID: "host-0"},
+		"spiffe://oim/component/foo.bar": {Role: "component", ID: "foo.bar"},
+		"spiffe://other/host/host-0":     {},
+		"https://oim/host/host-0":        {},
+		"spiffe://oim/host":              {},
+		"spiffe://oim/host/host-0/x":     {},
+		"spiffe://oim//host-0":           {},
+	} {
+		u, err := url.Parse(uri)
+		require.NoError(t, err, uri)
+		identity, err := ParseIdentityURI(u)
+		if expected.Role == "" {
+			assert.Error(t, err, uri)
+			continue
+		}
+		if assert.NoError(t, err, uri) {
+			assert.Equal(t, expected, identity, uri)
+			assert.Equal(t, uri, identity.URI().String(), uri)
+		}
+	}
+}
+
+func TestCertIdentity(t *testing.T) {
+	spiffe, err := url.Parse("spiffe://oim/host/host-1")
+	require.NoError(t, err)
+	other, err := url.Parse("spiffe://other/host/host-2")
+	require.NoError(t, err)
+	invalid, err := url.Parse("spiffe://oim/host")
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		cert     x509.Certificate
+		expected string
+	}{
+		"common name": {
+			cert:     x509.Certificate{Subject: pkix.Name{CommonName: "host.host-0"}},
+			expected: "host.host-0",
+		},
+		"DNS": {
+			cert: x509.Certificate{
+				Subject:  pkix.Name{CommonName: "host.host-0"},
+				DNSNames: []string{"www.example.com", "controller.host-2"},
+			},
+			expected: "controller.host-2",
+		},
+		"unknown role in DNS": {
+			cert: x509.Certificate{
+				Subject:  pkix.Name{CommonName: "host.host-0"},
+				DNSNames: []string{"www.example.com"},
+			},
+			expected: "host.host-0",
+		},
+		"host name in DNS": {
+			cert: x509.Certificate{
+				Subject:  pkix.Name{CommonName: "host.host-0"},
+				DNSNames: []string{"host.example.com", "user.example.com"},
+			},
+			expected: "host.host-0",
+		},
+		"URI": {
+			cert: x509.Certificate{
+				Subject:  pkix.Name{CommonName: "host.host-0"},
+				DNSNames: []string{"controller.host-2"},
+				URIs:     []*url.URL{other, spiffe},
+			},
+			expected: "host.host-1",
+		},
+		"invalid URI": {
+			cert: x509.Certificate{
+				Subject:  pkix.Name{CommonName: "host.host-0"},
+				DNSNames: []string{"controller.host-2"},
+				URIs:     []*url.URL{invalid},
+			},
+		},
+		"none": {
+			cert: x509.Certificate{
+				Subject:  pkix.Name{CommonName: "foobar"},
+				DNSNames: []string{"www.example.com"},
+				URIs:     []*url.URL{other},
+			},
+		},
+	} {
+		identity, err := CertIdentity(&tc.cert)
+		if tc.expected == "" {
+			assert.Error(t, err, name)
+		} else if assert.NoError(t, err, name) {
+			assert.Equal(t, tc.expected, identity.String(), name)
+		}
+	}
+}
+
+// writeSPIFFEKey creates <base>.crt/key signed by the test CA with
+// nothing but a SPIFFE ID for the identity.
+func writeSPIFFEKey(t *testing.T, base string, identity Identity) {
+	caCert := readCert(t, os.ExpandEnv("${TEST_WORK}/ca/ca.crt"))
+	data, err := ioutil.ReadFile(os.ExpandEnv("${TEST_WORK}/ca/ca.key"))
+	require.NoError(t, err)
+	block, _ := pem.Decode(data)
+	require.NotNil(t, block, "ca.key")
+	caKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	require.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "no identity"},
+		URIs:         []*url.URL{identity.URI()},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(base+".crt", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(base+".key", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+}
+
+func TestSPIFFE(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "spiffe")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	ca := os.ExpandEnv("${TEST_WORK}/ca/ca.crt")
+	controllerKey := filepath.Join(tmp, "controller")
+	hostKey := filepath.Join(tmp, "host")
+	writeSPIFFEKey(t, controllerKey, ControllerIdentity("host-0"))
+	writeSPIFFEKey(t, hostKey, Identity{Role: RoleHost, ID: "host-0"})
+
+	// Server with SPIFFE ID, client with traditional certificate.
+	serverConfig, err := LoadTLSConfig(ca, controllerKey, "")
+	require.NoError(t, err)
+	clientConfig, err := LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "controller.host-0")
+	require.NoError(t, err)
+	_, _, err = handshake(t, clientConfig, serverConfig)
+	assert.NoError(t, err, "SPIFFE server")
+
+	clientConfig, err = LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "controller.host-1")
+	require.NoError(t, err)
+	_, _, err = handshake(t, clientConfig, serverConfig)
+	if assert.Error(t, err, "wrong SPIFFE server") {
+		assert.Contains(t, err.Error(), "controller.host-1")
+	}
+
+	// Client with SPIFFE ID.
+	serverConfig, err = LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "host.host-0")
+	require.NoError(t, err)
+	clientConfig, err = LoadTLSConfig(ca, hostKey, "component.registry")
+	require.NoError(t, err)
+	_, _, err = handshake(t, clientConfig, serverConfig)
+	assert.NoError(t, err, "SPIFFE client")
+
+	serverConfig, err = LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "host.host-1")
+	require.NoError(t, err)
+	_, _, err = handshake(t, clientConfig, serverConfig)
+	if assert.Error(t, err, "wrong SPIFFE client") {
+		assert.Contains(t, err.Error(), `expected identity "host.host-1", got "host.host-0"`)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// IQNPrefix is the beginning of all iSCSI qualified names
+	// (IQNs) of targets exported by OIM controllers.
+	IQNPrefix = "iqn.2018-09.io.oim:"
+
+	// MaxIQNLength is the maximum length of an IQN in bytes as
+	// defined by RFC 3720.
+	MaxIQNLength = 223
+)
+
+// iqnRe matches the characters that are valid in an IQN after
+// stringprep normalization. Mapping other characters would make
+// different volume IDs collide, so they are rejected instead.
+var iqnRe = regexp.MustCompile(`^[a-z0-9.:-]+$`)
+
+// VolumeIQN returns the IQN of the iSCSI target for a volume exported
+// by the controller with that ID. The OIM controller and the OIM CSI
+// driver both use this to identify the target.
+func VolumeIQN(controllerID, volumeID string) (string, error) {
+	if controllerID == "" || volumeID == "" {
+		return "", errors.New("controller ID and volume ID required")
+	}
+	iqn := IQNPrefix + controllerID + ":" + volumeID
+	if len(iqn) > MaxIQNLength {
+		return "", errors.Errorf("IQN %q longer than %d bytes", iqn, MaxIQNLength)
+	}
+	if !iqnRe.MatchString(iqn) {
+		return "", errors.Errorf("IQN %q may only contain lower case letters, digits, dot, colon and hyphen", iqn)
+	}
+	return iqn, nil
+}
+
+// InitiatorIQN returns the IQN which the OIM CSI driver uses as
+// initiator name when logging into targets of the controller with
+// that ID. The OIM controller only allows this initiator to log in.
+func InitiatorIQN(controllerID string) (string, error) {
+	if controllerID == "" {
+		return "", errors.New("controller ID required")
+	}
+	iqn := IQNPrefix + "host:" + controllerID
+	if len(iqn) > MaxIQNLength {
+		return "", errors.Errorf("IQN %q longer than %d bytes", iqn, MaxIQNLength)
+	}
+	if !iqnRe.MatchString(iqn) {
+		return "", errors.Errorf("IQN %q may only contain lower case letters, digits, dot, colon and hyphen", iqn)
+	}
+	return iqn, nil
+}
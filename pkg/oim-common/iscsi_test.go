@@ -0,0 +1,51 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolumeIQN(t *testing.T) {
+	cases := []struct {
+		controllerID, volumeID string
+		iqn                    string
+		err                    bool
+	}{
+		{"host-0", "vol-1", "iqn.2018-09.io.oim:host-0:vol-1", false},
+		{"", "vol-1", "", true},
+		{"host-0", "", "", true},
+		{"host-0", "Vol-1", "", true},
+		{"host-0", "vol_1", "", true},
+		{"host-0", strings.Repeat("x", MaxIQNLength), "", true},
+	}
+
+	for _, c := range cases {
+		iqn, err := VolumeIQN(c.controllerID, c.volumeID)
+		if c.err {
+			assert.Error(t, err, "VolumeIQN(%q, %q)", c.controllerID, c.volumeID)
+		} else if assert.NoError(t, err, "VolumeIQN(%q, %q)", c.controllerID, c.volumeID) {
+			assert.Equal(t, c.iqn, iqn)
+		}
+	}
+}
+
+func TestInitiatorIQN(t *testing.T) {
+	iqn, err := InitiatorIQN("host-0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "iqn.2018-09.io.oim:host:host-0", iqn)
+	}
+	_, err = InitiatorIQN("")
+	assert.Error(t, err, "empty controller ID")
+	_, err = InitiatorIQN("Host-0")
+	assert.Error(t, err, "upper case")
+	_, err = InitiatorIQN(strings.Repeat("x", MaxIQNLength))
+	assert.Error(t, err, "too long")
+}
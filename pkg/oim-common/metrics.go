@@ -0,0 +1,100 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+
+	"github.com/intel/oim/pkg/log"
+)
+
+func init() {
+	// Latencies are more interesting than the plain call
+	// counters, so always record them.
+	grpc_prometheus.EnableHandlingTimeHistogram()
+	grpc_prometheus.EnableClientHandlingTimeHistogram()
+}
+
+// MetricsGRPCServer returns a gRPC interceptor for a gRPC server which
+// counts calls and measures their duration in the default Prometheus
+// registry.
+func MetricsGRPCServer() grpc.UnaryServerInterceptor {
+	return grpc_prometheus.UnaryServerInterceptor
+}
+
+// MetricsGRPCServerStream does the same as MetricsGRPCServer for
+// streaming calls. Calls handled by the unknown service handler (like
+// the proxied calls in the OIM registry) are not counted, because
+// their method names are chosen by the client.
+func MetricsGRPCServerStream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if srv == nil {
+			return handler(srv, ss)
+		}
+		return grpc_prometheus.StreamServerInterceptor(srv, ss, info, handler)
+	}
+}
+
+// MetricsGRPCClient does the same as MetricsGRPCServer, only on the
+// client side.
+func MetricsGRPCClient() grpc.UnaryClientInterceptor {
+	return grpc_prometheus.UnaryClientInterceptor
+}
+
+// MetricsGRPCClientStream does the same as MetricsGRPCClient for
+// streaming calls.
+func MetricsGRPCClientStream() grpc.StreamClientInterceptor {
+	return grpc_prometheus.StreamClientInterceptor
+}
+
+// MetricsServer provides the metrics of the default Prometheus
+// registry via HTTP under /metrics.
+type MetricsServer struct {
+	server *http.Server
+	addr   net.Addr
+}
+
+// StartMetricsServer listens on the address (host:port) and serves
+// metrics in the background until Close is called.
+func StartMetricsServer(ctx context.Context, address string) (*MetricsServer, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, errors.Wrap(err, "listen for metrics requests")
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", prometheus.Handler())
+	m := &MetricsServer{
+		server: &http.Server{Handler: mux},
+		addr:   listener.Addr(),
+	}
+	logger := log.FromContext(ctx)
+	logger.Infow("serving metrics", "address", m.addr)
+	go func() {
+		if err := m.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Errorw("serving metrics", "error", err)
+		}
+	}()
+	return m, nil
+}
+
+// Addr returns the address on which the server is listening. Can be
+// used to find the actual port when using :0 as address.
+func (m *MetricsServer) Addr() net.Addr {
+	return m.addr
+}
+
+// Close stops serving metrics.
+func (m *MetricsServer) Close() error {
+	return m.server.Close()
+}
@@ -0,0 +1,61 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestMetricsServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmp, err := ioutil.TempDir("", "metrics")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	metrics, err := StartMetricsServer(ctx, "localhost:0")
+	require.NoError(t, err)
+	defer metrics.Close()
+
+	s := NonBlockingGRPCServer{
+		Endpoint: "unix://" + filepath.Join(tmp, "server.sock"),
+	}
+	require.NoError(t, s.Start(ctx))
+	defer func() {
+		s.ForceStop(ctx)
+		s.Wait(ctx)
+	}()
+	conn, err := grpc.Dial(s.Endpoint, ChooseDialOpts(s.Endpoint, grpc.WithInsecure())...)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	resp, err := http.Get("http://" + metrics.Addr().String() + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	for _, expected := range []string{
+		`grpc_server_handled_total{grpc_code="OK",grpc_method="Check",grpc_service="grpc.health.v1.Health",grpc_type="unary"}`,
+		`grpc_client_handled_total{grpc_code="OK",grpc_method="Check",grpc_service="grpc.health.v1.Health",grpc_type="unary"}`,
+		`grpc_server_handling_seconds_count{grpc_method="Check",grpc_service="grpc.health.v1.Health",grpc_type="unary"}`,
+	} {
+		assert.Contains(t, string(body), expected)
+	}
+}
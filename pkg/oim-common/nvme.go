@@ -0,0 +1,49 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	// NQNPrefix is the beginning of all NVMe qualified names
+	// (NQNs) of subsystems exported by OIM controllers.
+	NQNPrefix = "nqn.2018-09.io.oim:"
+
+	// MaxNQNLength is the maximum length of a NQN in bytes as
+	// defined by the NVMe specification.
+	MaxNQNLength = 223
+)
+
+// VolumeNQN returns the NQN of the NVMe-oF subsystem for a volume
+// exported by the controller with that ID. The OIM controller and
+// the OIM CSI driver both use this to identify the subsystem.
+func VolumeNQN(controllerID, volumeID string) (string, error) {
+	if controllerID == "" || volumeID == "" {
+		return "", errors.New("controller ID and volume ID required")
+	}
+	nqn := NQNPrefix + controllerID + ":" + volumeID
+	if len(nqn) > MaxNQNLength {
+		return "", errors.Errorf("NQN %q longer than %d bytes", nqn, MaxNQNLength)
+	}
+	return nqn, nil
+}
+
+// HostNQN returns the NQN which the OIM CSI driver uses as host NQN
+// when connecting to subsystems of the controller with that ID. The
+// OIM controller only allows this host to connect.
+func HostNQN(controllerID string) (string, error) {
+	if controllerID == "" {
+		return "", errors.New("controller ID required")
+	}
+	nqn := NQNPrefix + "host:" + controllerID
+	if len(nqn) > MaxNQNLength {
+		return "", errors.Errorf("NQN %q longer than %d bytes", nqn, MaxNQNLength)
+	}
+	return nqn, nil
+}
@@ -0,0 +1,47 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolumeNQN(t *testing.T) {
+	cases := []struct {
+		controllerID, volumeID string
+		nqn                    string
+		err                    bool
+	}{
+		{"host-0", "vol-1", "nqn.2018-09.io.oim:host-0:vol-1", false},
+		{"", "vol-1", "", true},
+		{"host-0", "", "", true},
+		{"host-0", strings.Repeat("x", MaxNQNLength), "", true},
+	}
+
+	for _, c := range cases {
+		nqn, err := VolumeNQN(c.controllerID, c.volumeID)
+		if c.err {
+			assert.Error(t, err, "VolumeNQN(%q, %q)", c.controllerID, c.volumeID)
+		} else if assert.NoError(t, err, "VolumeNQN(%q, %q)", c.controllerID, c.volumeID) {
+			assert.Equal(t, c.nqn, nqn)
+		}
+	}
+}
+
+func TestHostNQN(t *testing.T) {
+	nqn, err := HostNQN("host-0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "nqn.2018-09.io.oim:host:host-0", nqn)
+	}
+	_, err = HostNQN("")
+	assert.Error(t, err, "empty controller ID")
+	_, err = HostNQN(strings.Repeat("x", MaxNQNLength))
+	assert.Error(t, err, "too long")
+}
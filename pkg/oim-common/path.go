@@ -36,3 +36,21 @@ func SplitRegistryPath(path string) ([]string, error) {
 func JoinRegistryPath(elements []string) string {
 	return strings.Join(elements, "/")
 }
+
+// CheckPathElement ensures that an ID (for example, a controller ID)
+// can be used as a single element of a registry path. Besides the
+// restrictions of SplitRegistryPath, it also rejects characters which
+// have a special meaning in the patterns of a registry policy.
+func CheckPathElement(id string) error {
+	elements, err := SplitRegistryPath(id)
+	if err != nil {
+		return err
+	}
+	if len(elements) != 1 || elements[0] != id {
+		return errors.Errorf("%q: must be a single, non-empty path element", id)
+	}
+	if strings.ContainsAny(id, `*?[]\`) {
+		return errors.Errorf("%q: wildcard characters not allowed", id)
+	}
+	return nil
+}
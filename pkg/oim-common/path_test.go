@@ -34,3 +34,12 @@ func TestSplitRegistryPath(t *testing.T) {
 		assert.Equal(t, elements, c.elements)
 	}
 }
+
+func TestCheckPathElement(t *testing.T) {
+	for _, valid := range []string{"host-0", "admin", "foo.bar"} {
+		assert.NoError(t, CheckPathElement(valid), valid)
+	}
+	for _, invalid := range []string{"", ".", "..", "a/b", "/a", "a/", "*", "**", "host-?", "[a]", `a\b`} {
+		assert.Error(t, CheckPathElement(invalid), invalid)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// Redacted is what RedactSecrets puts into secret fields.
+const Redacted = "***stripped***"
+
+// RedactSecrets returns a copy of the message in which all non-empty
+// string fields whose protobuf name is "secret" or ends in "_secret"
+// are replaced with Redacted. That covers the secrets in OIM
+// messages, like the Ceph key and the iSCSI CHAP secret. The
+// original message is not modified.
+func RedactSecrets(msg proto.Message) proto.Message {
+	msg = proto.Clone(msg)
+	redact(reflect.ValueOf(msg))
+	return msg
+}
+
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			redact(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			redact(v.Index(i))
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			value := v.MapIndex(key)
+			if value.Kind() == reflect.Ptr {
+				redact(value)
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			if field.Kind() == reflect.String && isSecret(t.Field(i)) {
+				if field.String() != "" {
+					field.SetString(Redacted)
+				}
+				continue
+			}
+			redact(field)
+		}
+	}
+}
+
+func isSecret(field reflect.StructField) bool {
+	for _, part := range strings.Split(field.Tag.Get("protobuf"), ",") {
+		if strings.HasPrefix(part, "name=") {
+			name := strings.TrimPrefix(part, "name=")
+			return name == "secret" || strings.HasSuffix(name, "_secret")
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	in := &oim.MapVolumeRequest{
+		VolumeId: "my-volume",
+		Params: &oim.MapVolumeRequest_Ceph{
+			Ceph: &oim.CephParams{
+				UserId: "admin",
+				Secret: "ceph-key",
+			},
+		},
+		IscsiChap: &oim.ISCSICHAP{
+			User:   "chap-user",
+			Secret: "chap-secret",
+		},
+	}
+	out := RedactSecrets(in).(*oim.MapVolumeRequest)
+	assert.Equal(t, Redacted, out.GetCeph().GetSecret())
+	assert.Equal(t, Redacted, out.GetIscsiChap().GetSecret())
+	assert.Equal(t, "admin", out.GetCeph().GetUserId())
+	assert.Equal(t, "chap-user", out.GetIscsiChap().GetUser())
+	assert.Equal(t, "ceph-key", in.GetCeph().GetSecret(), "original modified")
+
+	empty := RedactSecrets(&oim.MapVolumeRequest{IscsiChap: &oim.ISCSICHAP{}}).(*oim.MapVolumeRequest)
+	assert.Equal(t, "", empty.GetIscsiChap().GetSecret(), "empty secret")
+}
+
+func TestStripSecretsFormatter(t *testing.T) {
+	var formatter StripSecretsFormatter
+	for name, payload := range map[string]interface{}{
+		"OIM": &oim.MapVolumeRequest{
+			Params: &oim.MapVolumeRequest_Ceph{
+				Ceph: &oim.CephParams{Secret: "my-secret"},
+			},
+			IscsiChap: &oim.ISCSICHAP{Secret: "my-secret"},
+		},
+		"CSI": &csi.NodeStageVolumeRequest{
+			VolumeId: "my-volume",
+			Secrets:  map[string]string{"key": "my-secret"},
+		},
+	} {
+		text := formatter.Sprint(payload)
+		assert.NotContains(t, text, "my-secret", name)
+		assert.Contains(t, text, "***stripped***", name)
+	}
+	assert.Equal(t, "null", formatter.Sprint((*oim.MapVolumeRequest)(nil)))
+}
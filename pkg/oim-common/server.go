@@ -14,9 +14,9 @@ import (
 	"strings"
 	"sync"
 
-	// "github.com/grpc-ecosystem/go-grpc-middleware"
-	// "github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
-	// "github.com/opentracing/opentracing-go"
+	"github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
+	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 
@@ -43,8 +43,12 @@ func ParseEndpoint(ep string) (string, string, error) {
 type NonBlockingGRPCServer struct {
 	Endpoint      string
 	ServerOptions []grpc.ServerOption
-	wg            sync.WaitGroup
-	server        *grpc.Server
+	// Health gets registered in addition to the services passed
+	// to Start. A health server without checks gets created if
+	// not set.
+	Health *HealthServer
+	wg     sync.WaitGroup
+	server *grpc.Server
 
 	addr net.Addr
 }
@@ -74,16 +78,22 @@ func (s *NonBlockingGRPCServer) Start(ctx context.Context, services ...RegisterS
 	s.addr = listener.Addr()
 
 	logger := log.FromContext(ctx)
-	formatter := CompletePayloadFormatter{}
-
-	// interceptor := grpc_middleware.ChainUnaryServer(
-	// 	otgrpc.OpenTracingServerInterceptor(
-	// 		opentracing.GlobalTracer(),
-	// 		otgrpc.SpanDecorator(TraceGRPCPayload(formatter))),
-	// 	LogGRPCServer(logger, formatter))
-	interceptor := LogGRPCServer(logger, formatter)
+	// Requests and responses end up in logs and in spans which
+	// get sent to the tracing backend, so secrets must be removed.
+	formatter := StripSecretsFormatter{}
+
+	interceptor := ChainUnaryServer(
+		otgrpc.OpenTracingServerInterceptor(
+			opentracing.GlobalTracer(),
+			otgrpc.SpanDecorator(TraceGRPCPayload(formatter))),
+		MetricsGRPCServer(),
+		LogGRPCServer(logger, formatter))
+	streamInterceptor := ChainStreamServer(
+		otgrpc.OpenTracingStreamServerInterceptor(opentracing.GlobalTracer()),
+		MetricsGRPCServerStream())
 	opts := []grpc.ServerOption{
 		grpc.UnaryInterceptor(interceptor),
+		grpc.StreamInterceptor(streamInterceptor),
 	}
 	opts = append(opts, s.ServerOptions...)
 	server := grpc.NewServer(opts...)
@@ -92,6 +102,11 @@ func (s *NonBlockingGRPCServer) Start(ctx context.Context, services ...RegisterS
 	for _, service := range services {
 		service(server)
 	}
+	if s.Health == nil {
+		s.Health = NewHealthServer()
+	}
+	s.Health.Register(server)
+	grpc_prometheus.Register(server)
 
 	logger.Infow("listening for connections", "address", listener.Addr())
 
@@ -0,0 +1,134 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"context"
+	"crypto/tls"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+	"gopkg.in/fsnotify/fsnotify.v1"
+
+	"github.com/intel/oim/pkg/log"
+)
+
+// WatchTLSConfig does the same as LoadTLSConfig, except that the
+// returned configuration always uses the current content of the
+// files (including the CRL, if one is used): they get reloaded whenever something changes in the
+// directories containing them, which also covers files that get
+// replaced (Kubernetes secrets). Errors while reloading are logged
+// and the previous files remain in use, so a new certificate may
+// be written before the corresponding key. Watching stops when the
+// context is done.
+//
+// Servers pick up the new files via GetConfigForClient. Clients get
+// their certificate via GetClientCertificate and verify the server
+// in VerifyConnection against the current CA bundle, with the
+// server name of the connection. Therefore the configuration may be
+// cloned and modified (ServerName) like the one from LoadTLSConfig.
+//
+// The configuration announces HTTP/2 via ALPN, as gRPC expects.
+// The server configurations returned by GetConfigForClient inherit
+// NextProtos from the returned configuration, not from copies of it.
+func WatchTLSConfig(ctx context.Context, caFile, key, peerName string, opts ...TLSOption) (*tls.Config, error) {
+	options := tlsOptions{}
+	for _, op := range opts {
+		op(&options)
+	}
+	crtFile, keyFile := KeyPairFiles(key)
+	files := []string{caFile, crtFile, keyFile}
+	if options.crlFile != "" {
+		files = append(files, options.crlFile)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "watch TLS files")
+	}
+	dirs := map[string]bool{}
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close() // nolint: gosec
+			return nil, errors.Wrap(err, "watch TLS files")
+		}
+	}
+	config, err := loadTLSConfig(caFile, key, peerName, options)
+	if err != nil {
+		watcher.Close() // nolint: gosec
+		return nil, err
+	}
+
+	var mutex sync.Mutex
+	current := func() *tls.Config {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return config
+	}
+
+	go func() {
+		defer watcher.Close()
+		logger := log.FromContext(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-watcher.Errors:
+				logger.Warnw("watch TLS files", "error", err)
+			case <-watcher.Events:
+				newConfig, err := loadTLSConfig(caFile, key, peerName, options)
+				if err != nil {
+					logger.Warnw("reload TLS files", "error", err)
+					continue
+				}
+				logger.Infow("reloaded TLS files", "ca", caFile, "key", key, "crl", options.crlFile)
+				mutex.Lock()
+				config = newConfig
+				mutex.Unlock()
+			}
+		}
+	}()
+
+	// credentials.NewTLS sets NextProtos only in its own copy,
+	// which GetConfigForClient cannot see.
+	var watched *tls.Config
+	watched = &tls.Config{
+		ServerName: peerName,
+		NextProtos: []string{"h2"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			config := current().Clone()
+			config.NextProtos = watched.NextProtos
+			return config, nil
+		},
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &current().Certificates[0], nil
+		},
+		// The server gets verified in VerifyConnection of the
+		// current configuration.
+		InsecureSkipVerify: true, // nolint: gosec
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return current().VerifyConnection(cs)
+		},
+	}
+	return watched, nil
+}
+
+// WatchTLS is like LoadTLS, but with files that get reloaded as in
+// WatchTLSConfig.
+func WatchTLS(ctx context.Context, caFile, key, peerName string, opts ...TLSOption) (credentials.TransportCredentials, error) {
+	tlsConfig, err := WatchTLSConfig(ctx, caFile, key, peerName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
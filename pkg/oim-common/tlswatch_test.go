@@ -0,0 +1,159 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// copyKey installs <name>.crt/key from the test CA as <to>.crt/key.
+func copyKey(t *testing.T, name, to string) {
+	for _, suffix := range []string{".crt", ".key"} {
+		data, err := ioutil.ReadFile(os.ExpandEnv("${TEST_WORK}/ca/" + name + suffix))
+		require.NoError(t, err)
+		// Write and rename, like Kubernetes does for secrets.
+		require.NoError(t, ioutil.WriteFile(to+suffix+".tmp", data, 0600))
+		require.NoError(t, os.Rename(to+suffix+".tmp", to+suffix))
+	}
+}
+
+// handshake connects a client and a server with the given
+// configurations and returns the common name of the peer as seen by
+// the client and the server.
+func handshake(t *testing.T, clientConfig, serverConfig *tls.Config) (serverCN, clientCN string, err error) {
+	client, server, err := handshakeStates(t, clientConfig, serverConfig)
+	if err != nil {
+		return "", "", err
+	}
+	return client.PeerCertificates[0].Subject.CommonName,
+		server.PeerCertificates[0].Subject.CommonName,
+		nil
+}
+
+// handshakeStates connects a client and a server with the given
+// configurations and returns the state of both connections.
+func handshakeStates(t *testing.T, clientConfig, serverConfig *tls.Config) (clientState, serverState tls.ConnectionState, err error) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	server := tls.Server(serverConn, serverConfig)
+	serverDone := make(chan error, 1)
+	go func() {
+		serverDone <- server.Handshake()
+		serverConn.Close()
+	}()
+	client := tls.Client(clientConn, clientConfig)
+	err = client.Handshake()
+	clientConn.Close()
+	serverErr := <-serverDone
+	if err != nil {
+		return clientState, serverState, err
+	}
+	if serverErr != nil {
+		return clientState, serverState, serverErr
+	}
+	return client.ConnectionState(), server.ConnectionState(), nil
+}
+
+func TestWatchTLSConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmp, err := ioutil.TempDir("", "tlswatch")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	ca := os.ExpandEnv("${TEST_WORK}/ca/ca.crt")
+	serverKey := filepath.Join(tmp, "server")
+	clientKey := filepath.Join(tmp, "client")
+	copyKey(t, "component.registry", serverKey)
+	copyKey(t, "controller.host-0", clientKey)
+
+	serverConfig, err := WatchTLSConfig(ctx, ca, serverKey+".key", "")
+	require.NoError(t, err)
+	clientConfig, err := WatchTLSConfig(ctx, ca, clientKey+".key", "component.registry")
+	require.NoError(t, err)
+
+	serverCN, clientCN, err := handshake(t, clientConfig, serverConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "component.registry", serverCN)
+	assert.Equal(t, "controller.host-0", clientCN)
+
+	// Without a peer name, the server name of a clone is used for
+	// verification, like the registry does it.
+	anyPeerConfig, err := WatchTLSConfig(ctx, ca, clientKey+".key", "")
+	require.NoError(t, err)
+	hostConfig := anyPeerConfig.Clone()
+	hostConfig.ServerName = "controller.host-1"
+	_, _, err = handshake(t, hostConfig, serverConfig)
+	assert.Error(t, err, "wrong server name")
+
+	// Replacing the files changes the certificates which get
+	// presented by both sides.
+	copyKey(t, "controller.host-1", serverKey)
+	copyKey(t, "controller.host-2", clientKey)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		serverCN, clientCN, err = handshake(t, hostConfig, serverConfig)
+		if err == nil && serverCN == "controller.host-1" && clientCN == "controller.host-2" ||
+			time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err, "reloaded certificates")
+	assert.Equal(t, "controller.host-1", serverCN)
+	assert.Equal(t, "controller.host-2", clientCN)
+
+	// The reloaded configuration still negotiates HTTP/2, also
+	// when used via a copy like the one from credentials.NewTLS.
+	h2Config := hostConfig.Clone()
+	h2Config.NextProtos = []string{"h2"}
+	clientState, serverState, err := handshakeStates(t, h2Config, serverConfig.Clone())
+	require.NoError(t, err, "ALPN")
+	assert.Equal(t, "h2", clientState.NegotiatedProtocol)
+	assert.Equal(t, "h2", serverState.NegotiatedProtocol)
+
+	// The original client now rejects the server.
+	_, _, err = handshake(t, clientConfig, serverConfig)
+	assert.Error(t, err, "server with new certificate")
+}
+
+func TestWatchTLSConfigInvalid(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmp, err := ioutil.TempDir("", "tlswatch")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	ca := os.ExpandEnv("${TEST_WORK}/ca/ca.crt")
+	key := filepath.Join(tmp, "server")
+	_, err = WatchTLSConfig(ctx, ca, key+".key", "")
+	assert.Error(t, err, "missing files")
+
+	copyKey(t, "component.registry", key)
+	serverConfig, err := WatchTLSConfig(ctx, ca, key+".key", "")
+	require.NoError(t, err)
+	clientConfig, err := LoadTLSConfig(ca, os.ExpandEnv("${TEST_WORK}/ca/controller.host-0.key"), "component.registry")
+	require.NoError(t, err)
+
+	// A broken certificate is ignored and the old one remains in use.
+	require.NoError(t, ioutil.WriteFile(key+".crt", []byte("garbage"), 0600))
+	time.Sleep(100 * time.Millisecond)
+	serverCN, _, err := handshake(t, clientConfig, serverConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "component.registry", serverCN)
+}
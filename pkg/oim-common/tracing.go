@@ -11,15 +11,16 @@ import (
 	"context"
 	"fmt"
 	"io"
-
+	"reflect"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
+	"github.com/opentracing/opentracing-go"
+	otlog "github.com/opentracing/opentracing-go/log"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
 	"google.golang.org/grpc"
 
-	// TODO: re-enable tracing once https://github.com/jaegertracing/jaeger-lib/issues/32 is addressed.
-	// "github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
-	// "github.com/opentracing/opentracing-go"
-	// otlog "github.com/opentracing/opentracing-go/log"
-	// jaegercfg "github.com/uber/jaeger-client-go/config"
-
 	"github.com/intel/oim/pkg/log"
 	"github.com/kubernetes-csi/csi-lib-utils/protosanitizer"
 )
@@ -47,12 +48,16 @@ func (c CompletePayloadFormatter) Sprint(payload interface{}) string {
 	return result
 }
 
-// StripSecretsFormatter removes secret fields from a CSI 0.3 or CSI 1.0 message
-// using the protosanitizer package.
+// StripSecretsFormatter removes secret fields from a CSI 1.0 message
+// using the protosanitizer package and from OIM messages with
+// RedactSecrets.
 type StripSecretsFormatter struct{}
 
-// Sprint strips messages for CSI >= 1.0.
+// Sprint strips messages for CSI >= 1.0 and OIM.
 func (s StripSecretsFormatter) Sprint(payload interface{}) string {
+	if msg, ok := payload.(proto.Message); ok && msg != nil && !reflect.ValueOf(msg).IsNil() {
+		payload = RedactSecrets(msg)
+	}
 	return protosanitizer.StripSecrets(payload).String()
 }
 
@@ -98,11 +103,11 @@ func LogGRPCServer(logger log.Logger, formatter PayloadFormatter) grpc.UnaryServ
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		ctx = logGRPCPre(ctx, logger, formatter, "received", info.FullMethod, req)
 		innerCtx := ctx
-		// if sp := opentracing.SpanFromContext(ctx); sp != nil {
-		// 	l := log.FromContext(ctx)
-		// 	l = newSpanLogger(sp, l)
-		// 	innerCtx = log.WithLogger(ctx, l)
-		// }
+		if sp := opentracing.SpanFromContext(ctx); sp != nil {
+			l := log.FromContext(ctx)
+			l = newSpanLogger(sp, l)
+			innerCtx = log.WithLogger(ctx, l)
+		}
 		resp, err := handler(innerCtx, req)
 		logGRPCPost(ctx, formatter, "sending", err, resp)
 		return resp, err
@@ -120,11 +125,11 @@ func LogGRPCClient(formatter PayloadFormatter) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		ctx = logGRPCPre(ctx, log.FromContext(ctx), formatter, "sending", method, req)
 		innerCtx := ctx
-		// if sp := opentracing.SpanFromContext(ctx); sp != nil {
-		// 	logger := log.FromContext(ctx)
-		// 	logger = newSpanLogger(sp, logger)
-		// 	innerCtx = log.WithLogger(ctx, logger)
-		// }
+		if sp := opentracing.SpanFromContext(ctx); sp != nil {
+			logger := log.FromContext(ctx)
+			logger = newSpanLogger(sp, logger)
+			innerCtx = log.WithLogger(ctx, logger)
+		}
 		err := invoker(innerCtx, method, req, reply, cc, opts...)
 		logGRPCPost(ctx, formatter, "received", err, reply)
 		return err
@@ -150,88 +155,91 @@ func logGRPCPost(ctx context.Context, formatter PayloadFormatter, msg string, er
 // TraceGRPCPayload returns a span decorator which adds the request
 // and response as tags to the call's span if (and only if) a
 // formatter is given.
-// func TraceGRPCPayload(formatter PayloadFormatter) otgrpc.SpanDecoratorFunc {
-// 	return func(sp opentracing.Span, method string, req, reply interface{}, err error) {
-// 		if formatter != nil {
-// 			sp.SetTag("request", &delayedFormatter{formatter, req})
-// 			if err == nil {
-// 				sp.SetTag("response", &delayedFormatter{formatter, reply})
-// 			}
-// 		}
-// 	}
-// }
-
-// type spanLogger struct {
-// 	log.LoggerBase
-// 	sp     opentracing.Span
-// 	logger log.Logger
-// }
-
-// func newSpanLogger(sp opentracing.Span, logger log.Logger) log.Logger {
-// 	l := &spanLogger{sp: sp, logger: logger}
-// 	l.LoggerBase.Init(l)
-// 	return l
-// }
-
-// func (sl *spanLogger) Output(threshold log.Threshold, args ...interface{}) {
-// 	sl.sp.LogFields(otlog.Lazy(func(fv otlog.Encoder) {
-// 		fv.EmitString("event", strings.ToLower(threshold.String()))
-// 		fv.EmitString("message", fmt.Sprint(args...))
-// 	}))
-// 	sl.logger.Output(threshold, args...)
-// }
-
-// func (sl *spanLogger) Outputf(threshold log.Threshold, format string, args ...interface{}) {
-// 	sl.sp.LogFields(otlog.Lazy(func(fv otlog.Encoder) {
-// 		fv.EmitString("event", strings.ToLower(threshold.String()))
-// 		fv.EmitString("message", fmt.Sprintf(format, args...))
-// 	}))
-// 	sl.logger.Outputf(threshold, format, args...)
-// }
-
-// func (sl *spanLogger) Outputw(threshold log.Threshold, msg string, keysAndValues ...interface{}) {
-// 	sl.sp.LogFields(otlog.Lazy(func(fv otlog.Encoder) {
-// 		fv.EmitString("event", strings.ToLower(threshold.String()))
-// 		fv.EmitString("message", msg)
-// 		for i := 0; i+1 < len(keysAndValues); i += 2 {
-// 			// We rely in reflection inside emitObject
-// 			// here instead of trying to switch by the
-// 			// type of the value ourselves, like
-// 			// otlog.InterleavedKVToFields does.
-// 			fv.EmitObject(fmt.Sprintf("%s", keysAndValues[i]),
-// 				keysAndValues[i+1])
-// 		}
-// 	}))
-// 	sl.logger.Outputw(threshold, msg, keysAndValues...)
-// }
-
-// // With creates a new instance with the same span and a logger
-// // which has the additional fields added.
-// func (sl *spanLogger) With(keysAndValues ...interface{}) log.Logger {
-// 	return newSpanLogger(sl.sp,
-// 		sl.logger.With(keysAndValues...))
-// }
-
-type nopCloser struct{}
-
-func (n nopCloser) Close() error { return nil }
+func TraceGRPCPayload(formatter PayloadFormatter) otgrpc.SpanDecoratorFunc {
+	return func(sp opentracing.Span, method string, req, reply interface{}, err error) {
+		if formatter != nil {
+			sp.SetTag("request", &delayedFormatter{formatter, req})
+			if err == nil {
+				sp.SetTag("response", &delayedFormatter{formatter, reply})
+			}
+		}
+	}
+}
+
+type spanLogger struct {
+	log.LoggerBase
+	sp     opentracing.Span
+	logger log.Logger
+}
+
+func newSpanLogger(sp opentracing.Span, logger log.Logger) log.Logger {
+	l := &spanLogger{sp: sp, logger: logger}
+	l.LoggerBase.Init(l)
+	return l
+}
+
+func (sl *spanLogger) Output(threshold log.Threshold, args ...interface{}) {
+	sl.sp.LogFields(otlog.Lazy(func(fv otlog.Encoder) {
+		fv.EmitString("event", strings.ToLower(threshold.String()))
+		fv.EmitString("message", fmt.Sprint(args...))
+	}))
+	sl.logger.Output(threshold, args...)
+}
+
+func (sl *spanLogger) Outputf(threshold log.Threshold, format string, args ...interface{}) {
+	sl.sp.LogFields(otlog.Lazy(func(fv otlog.Encoder) {
+		fv.EmitString("event", strings.ToLower(threshold.String()))
+		fv.EmitString("message", fmt.Sprintf(format, args...))
+	}))
+	sl.logger.Outputf(threshold, format, args...)
+}
+
+func (sl *spanLogger) Outputw(threshold log.Threshold, msg string, keysAndValues ...interface{}) {
+	sl.sp.LogFields(otlog.Lazy(func(fv otlog.Encoder) {
+		fv.EmitString("event", strings.ToLower(threshold.String()))
+		fv.EmitString("message", msg)
+		for i := 0; i+1 < len(keysAndValues); i += 2 {
+			// We rely in reflection inside emitObject
+			// here instead of trying to switch by the
+			// type of the value ourselves, like
+			// otlog.InterleavedKVToFields does.
+			fv.EmitObject(fmt.Sprintf("%s", keysAndValues[i]),
+				keysAndValues[i+1])
+		}
+	}))
+	sl.logger.Outputw(threshold, msg, keysAndValues...)
+}
+
+// With creates a new instance with the same span and a logger
+// which has the additional fields added.
+func (sl *spanLogger) With(keysAndValues ...interface{}) log.Logger {
+	return newSpanLogger(sl.sp,
+		sl.logger.With(keysAndValues...))
+}
 
 // InitTracer initializes the global OpenTracing tracer, using Jaeger
 // and the provided name for the current process. Must be called at
 // the start of main(). The result is a function which should be
 // called at the end of main() to clean up.
+//
+// The tracer is configured with the usual JAEGER_* env variables,
+// for example JAEGER_AGENT_HOST to send spans to a Jaeger agent
+// elsewhere, JAEGER_SAMPLER_TYPE=const and JAEGER_SAMPLER_PARAM=1
+// to trace all calls or JAEGER_DISABLED=true to turn tracing off.
+// JAEGER_SERVICE_NAME overrides the component name.
 func InitTracer(component string) (io.Closer, error) {
-	// // Add support for the usual env variables, in particular
-	// // JAEGER_AGENT_HOST, which is needed when running only one
-	// // Jaeger agent per cluster.
-	// cfg, err := jaegercfg.FromEnv()
-	// if err != nil {
-	// 	// parsing errors might happen here, such as when we get a string where we expect a number
-	// 	return nil, err
-	// }
-
-	// // Initialize tracer singleton.
-	// closer, err = cfg.InitGlobalTracer(component)
-	closer := nopCloser{}
-	return closer, nil
+	// Add support for the usual env variables, in particular
+	// JAEGER_AGENT_HOST, which is needed when running only one
+	// Jaeger agent per cluster.
+	cfg, err := jaegercfg.FromEnv()
+	if err != nil {
+		// parsing errors might happen here, such as when we get a string where we expect a number
+		return nil, err
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = component
+	}
+
+	// Initialize tracer singleton.
+	return cfg.InitGlobalTracer(cfg.ServiceName)
 }
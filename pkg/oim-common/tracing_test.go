@@ -0,0 +1,71 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcommon
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestTracing(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmp, err := ioutil.TempDir("", "tracing")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	s := NonBlockingGRPCServer{
+		Endpoint: "unix://" + filepath.Join(tmp, "server.sock"),
+	}
+	require.NoError(t, s.Start(ctx))
+	defer func() {
+		s.ForceStop(ctx)
+		s.Wait(ctx)
+	}()
+	conn, err := grpc.Dial(s.Endpoint, ChooseDialOpts(s.Endpoint, grpc.WithInsecure())...)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	parent := tracer.StartSpan("test")
+	_, err = healthpb.NewHealthClient(conn).Check(opentracing.ContextWithSpan(ctx, parent), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	parent.Finish()
+
+	// The server span is finished before the client receives
+	// the response.
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 3)
+	server, client := spans[0], spans[1]
+	parentContext := parent.Context().(mocktracer.MockSpanContext)
+	for _, span := range []*mocktracer.MockSpan{server, client} {
+		assert.Equal(t, "/grpc.health.v1.Health/Check", span.OperationName)
+		assert.Equal(t, parentContext.TraceID, span.SpanContext.TraceID)
+	}
+	assert.Equal(t, ext.SpanKindRPCServerEnum, server.Tag(string(ext.SpanKind)))
+	assert.Equal(t, client.SpanContext.SpanID, server.ParentID)
+	assert.Equal(t, ext.SpanKindRPCClientEnum, client.Tag(string(ext.SpanKind)))
+	assert.Equal(t, parentContext.SpanID, client.ParentID)
+	for _, span := range []*mocktracer.MockSpan{server, client} {
+		assert.NotNil(t, span.Tag("request"), "request tag")
+		assert.NotNil(t, span.Tag("response"), "response tag")
+	}
+}
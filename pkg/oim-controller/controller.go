@@ -8,9 +8,14 @@ package oimcontroller
 
 import (
 	"context"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/gogo/protobuf/types"
 	"github.com/pkg/errors"
 
 	"google.golang.org/grpc"
@@ -27,18 +32,75 @@ import (
 
 // Controller implements oim.Controller.
 type Controller struct {
-	creds           credentials.TransportCredentials
-	registryAddress string
-	registryDelay   time.Duration
-	controllerID    string
-	controllerAddr  string
-	spdkPath        string
-	SPDK            *spdk.Client
-	vhostSCSI       string
-	vhostDev        *oim.PCIAddress
+	creds             credentials.TransportCredentials
+	registryAddress   string
+	registryDelay     time.Duration
+	registryTTL       time.Duration
+	controllerID      string
+	controllerAddr    string
+	spdkPath          string
+	SPDK              *spdk.Client
+	vhostSCSI         string
+	vhostDev          *oim.PCIAddress
+	moreSCSI          []vhostController
+	vhostBLK          []vhostController
+	nvmeofAddress     string
+	nvmeofPort        string
+	nvmeofAnyHost     bool
+	iscsiAddress      string
+	iscsiPort         string
+	iscsiAnyInitiator bool
+	journalPath       string
+	journal           *journal
 
 	wg   sync.WaitGroup
 	stop chan<- interface{}
+
+	registrationMutex sync.Mutex
+	registrationErr   error
+	// registrationRevision is the registry revision of our
+	// address entry after the last successful registration.
+	registrationRevision int64
+
+	mappedMutex sync.Mutex
+	mapped      map[string]bool
+
+	// iscsiMutex serializes the creation of shared iSCSI
+	// objects and the allocation of auth group tags.
+	iscsiMutex sync.Mutex
+
+	// reconcileWG tracks Reconcile calls which were triggered by
+	// reconnecting to SPDK. No new ones are started once closing
+	// is set.
+	reconcileMutex sync.Mutex
+	reconcileWG    sync.WaitGroup
+	closing        bool
+}
+
+// vhostController is the name of a vhost controller in SPDK together
+// with the PCI address of the corresponding virtio device in the VM.
+// vhost-scsi controllers must exist, vhost-blk controllers get
+// created for a volume when needed.
+type vhostController struct {
+	controller string
+	dev        *oim.PCIAddress
+}
+
+// scsiControllers returns all configured vhost-scsi controllers in the
+// order in which they get filled.
+func (c *Controller) scsiControllers() []vhostController {
+	var controllers []vhostController
+	if c.vhostSCSI != "" {
+		controllers = append(controllers, vhostController{controller: c.vhostSCSI, dev: c.vhostDev})
+	}
+	return append(controllers, c.moreSCSI...)
+}
+
+// isController checks whether the controller name reported by SPDK
+// refers to the configured one. SPDK also accepts the path of the
+// vhost socket as name and then reports only the base name.
+func (vhost vhostController) isController(name string) bool {
+	return vhost.controller == name || filepath.Base(vhost.controller) == name
 }
 
 var (
@@ -50,6 +112,23 @@ var (
 	volumeMutex = keymutex.NewHashed(-1)
 )
 
+const (
+	// iscsiPortalGroup and iscsiInitiatorGroup are the tags of
+	// the portal group and initiator group which are created by
+	// the controller and shared by all targets. The shared
+	// initiator group is only used when any initiator is allowed.
+	// Otherwise initiator groups are created per target, like the
+	// auth groups, with the next unused tag.
+	iscsiPortalGroup    = 1
+	iscsiInitiatorGroup = 1
+	// iscsiQueueDepth is the maximum number of outstanding
+	// commands per connection.
+	iscsiQueueDepth = 64
+	// spdkProbeInterval is how often Start checks whether SPDK
+	// is still available.
+	spdkProbeInterval = 30 * time.Second
+)
+
 // MapVolume ensures that there is a BDev for the volume and makes it
 // available as block device.
 func (c *Controller) MapVolume(ctx context.Context, in *oim.MapVolumeRequest) (*oim.MapVolumeReply, error) {
@@ -60,99 +139,676 @@ func (c *Controller) MapVolume(ctx context.Context, in *oim.MapVolumeRequest) (*
 	if c.SPDK == nil {
 		return nil, errors.New("not connected to SPDK")
 	}
-	if c.vhostSCSI == "" {
-		return nil, errors.New("no VHost SCSI controller configured")
-	}
-	if c.vhostDev == nil {
-		return nil, errors.New("no PCI BDF configured")
+	if c.nvmeofAddress == "" && c.iscsiAddress == "" && len(c.vhostBLK) == 0 {
+		scsi := c.scsiControllers()
+		if len(scsi) == 0 {
+			return nil, errors.New("no VHost SCSI controller configured")
+		}
+		for _, vhost := range scsi {
+			if vhost.dev == nil {
+				return nil, errors.Errorf("no PCI BDF configured for VHost SCSI controller %s", vhost.controller)
+			}
+		}
 	}
 
 	// Serialize by volume.
 	volumeMutex.LockKey(volumeID)
 	defer volumeMutex.UnlockKey(volumeID)
 
+	// A volume which is in the journal was mapped before and
+	// should get the same SCSI target or vhost controller again,
+	// for example after SPDK was restarted while the controller
+	// kept running.
+	var preferred *allocation
+	if c.journal != nil {
+		if entry, ok := c.journal.lookup(volumeID); ok {
+			preferred = &entry.Allocation
+		}
+	}
+	reply, alloc, err := c.mapVolume(ctx, in, preferred)
+	if err != nil {
+		return nil, err
+	}
+	if c.journal != nil {
+		entry, err := newJournalEntry(in, alloc)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.journal.put(volumeID, entry); err != nil {
+			return nil, status.Errorf(codes.Internal, "volume %s mapped, but not recorded: %s", volumeID, err)
+		}
+	}
+	return reply, nil
+}
+
+// mapVolume does the actual work for MapVolume while holding the lock
+// for the volume. It also returns where the volume was mapped. When
+// restoring a volume, the allocation from the journal is tried first.
+func (c *Controller) mapVolume(ctx context.Context, in *oim.MapVolumeRequest, preferred *allocation) (*oim.MapVolumeReply, allocation, error) {
+	volumeID := in.GetVolumeId()
+
 	// Reuse or create BDev.
 	if _, err := spdk.GetBDevs(ctx, c.SPDK, spdk.GetBDevsArgs{Name: volumeID}); err != nil {
 		// TODO: check error more carefully instead of assuming that it merely
 		// wasn't found.
 		switch x := in.Params.(type) {
 		case *oim.MapVolumeRequest_Malloc:
-			return nil, errors.Errorf("no existing MallocBDev with name %s found", volumeID)
+			return nil, allocation{}, errors.Errorf("no existing MallocBDev with name %s found", volumeID)
 		case *oim.MapVolumeRequest_Ceph:
 			if err := c.mapCeph(ctx, volumeID, x.Ceph); err != nil {
-				return nil, err
+				return nil, allocation{}, err
 			}
 		case nil:
-			return nil, errors.New("missing volume parameters")
+			return nil, allocation{}, errors.New("missing volume parameters")
 		default:
-			return nil, errors.Errorf("unsupported params type %T", x)
+			return nil, allocation{}, errors.Errorf("unsupported params type %T", x)
 		}
 	} else {
 		// BDev with the intended name already exists. Assume that it is the right one.
 		log.FromContext(ctx).Infof("reusing existing BDev %s", volumeID)
 	}
 
-	var err error
+	if c.nvmeofAddress != "" {
+		reply, err := c.mapNVMeoF(ctx, volumeID, in.GetHostNqn())
+		return reply, allocation{}, err
+	}
+	if c.iscsiAddress != "" {
+		reply, err := c.mapISCSI(ctx, volumeID, in.GetIscsiInitiator(), in.GetIscsiChap())
+		return reply, allocation{}, err
+	}
 
 	// If this BDev is active as LUN, do nothing because a previous MapVolume
 	// call must have succeeded (idempotency!).
 	controllers, err := spdk.GetVHostControllers(ctx, c.SPDK)
 	if err != nil {
-		return nil, errors.Wrap(err, "GetVHostControllers")
+		return nil, allocation{}, errors.Wrap(err, "GetVHostControllers")
+	}
+	if len(c.vhostBLK) > 0 {
+		return c.mapBLK(ctx, volumeID, controllers, preferred)
+	}
+	reply, alloc, err := c.findSCSI(volumeID, controllers)
+	if err != nil {
+		return nil, allocation{}, err
+	}
+	if reply != nil {
+		// BDev already active.
+		c.volumeMapped(volumeID, true)
+		return reply, alloc, nil
+	}
+
+	// Add a new SCSI target with the BDev as LUN to the first
+	// controller which has an unused target. SPDK picks the
+	// target, except when restoring a volume: then the target
+	// from before is used again if possible, because the SCSI
+	// device in the VM must not change. Existing targets are never
+	// shared: SPDK allows only one LUN per target and only removes
+	// complete targets, so UnmapVolume could not detach just one
+	// of several volumes in a target.
+	// TODO: document that the BDev is not going to get deleted.
+	// To remove it, UnmapVolume must be called.
+	type candidate struct {
+		vhost  vhostController
+		target int32
 	}
+	var candidates []candidate
+	scsi := c.scsiControllers()
+	if preferred != nil {
+		for _, vhost := range scsi {
+			if vhost.controller == preferred.Controller {
+				candidates = append(candidates, candidate{vhost, int32(preferred.Target)})
+			}
+		}
+	}
+	for _, vhost := range scsi {
+		candidates = append(candidates, candidate{vhost, spdk.AnySCSITarget})
+	}
+	for _, candidate := range candidates {
+		vhost := candidate.vhost
+		if candidate.target == spdk.AnySCSITarget && scsiTargetsInUse(vhost, controllers) >= spdk.MaxSCSITargets {
+			log.FromContext(ctx).Infow("all SCSI targets in use", "controller", vhost.controller)
+			continue
+		}
+		args := spdk.AddVHostSCSILUNArgs{
+			Controller:    vhost.controller,
+			SCSITargetNum: candidate.target,
+			BDevName:      volumeID,
+		}
+		target, err := spdk.AddVHostSCSILUN(ctx, c.SPDK, args)
+		if candidate.target != spdk.AnySCSITarget && err != nil {
+			log.FromContext(ctx).Warnw("previous SCSI target not available",
+				"controller", vhost.controller,
+				"target", candidate.target,
+				"error", err,
+			)
+			continue
+		}
+		if err != nil {
+			return nil, allocation{}, errors.Wrapf(err, "AddVHostSCSILUN %s", vhost.controller)
+		}
+
+		// SPDK currently always uses LUN 0 for new targets,
+		// but ask instead of assuming that.
+		controllers, err = spdk.GetVHostControllers(ctx, c.SPDK)
+		if err != nil {
+			return nil, allocation{}, errors.Wrap(err, "GetVHostControllers")
+		}
+		reply, alloc, err = c.findSCSI(volumeID, controllers)
+		if err != nil {
+			return nil, allocation{}, err
+		}
+		if reply == nil {
+			return nil, allocation{}, errors.Errorf("BDev %s not found after adding it to SCSI target %d of %s", volumeID, target, vhost.controller)
+		}
+		c.volumeMapped(volumeID, true)
+		return reply, alloc, nil
+	}
+	return nil, allocation{}, status.Errorf(codes.ResourceExhausted, "all SCSI targets of the %d VHost SCSI controllers are in use", len(scsi))
+}
+
+// findSCSI returns the reply and allocation for a BDev which is
+// already a LUN of one of the configured vhost-scsi controllers, nil
+// if it is not used by any controller.
+func (c *Controller) findSCSI(volumeID string, controllers spdk.GetVHostControllersResponse) (*oim.MapVolumeReply, allocation, error) {
 	for _, controller := range controllers {
-		for key, value := range controller.BackendSpecific {
-			switch key {
-			case "scsi":
-				if scsi, ok := value.(spdk.SCSIControllerSpecific); ok {
-					for _, target := range scsi {
-						for _, lun := range target.LUNs {
-							if lun.BDevName == volumeID {
-								// BDev already active.
-								return &oim.MapVolumeReply{
-									PciAddress: c.vhostDev,
-									ScsiDisk: &oim.SCSIDisk{
-										Target: target.SCSIDevNum,
-										Lun:    0,
-									},
-								}, nil
-							}
-						}
+		scsi, ok := controller.BackendSpecific["scsi"].(spdk.SCSIControllerSpecific)
+		if !ok {
+			continue
+		}
+		for _, target := range scsi {
+			for _, lun := range target.LUNs {
+				if lun.BDevName != volumeID {
+					continue
+				}
+				for _, vhost := range c.scsiControllers() {
+					if vhost.isController(controller.Controller) {
+						return &oim.MapVolumeReply{
+							PciAddress: vhost.dev,
+							ScsiDisk: &oim.SCSIDisk{
+								Target: target.SCSIDevNum,
+								Lun:    uint32(lun.LUN),
+							},
+						}, allocation{Controller: vhost.controller, Target: target.SCSIDevNum}, nil
 					}
 				}
+				return nil, allocation{}, errors.Errorf("BDev %s is used by VHost SCSI controller %s, which is not configured for the VM", volumeID, controller.Controller)
 			}
 		}
 	}
+	return nil, allocation{}, nil
+}
 
-	// Create a new SCSI target with a LUN connected to this BDev. We iterate over all available
-	// targets and attempt to use them.
-	// TODO: we don't know the SPDK limit for targets. 8 is just the default.
-	// TODO: let vhost pick an unused one (https://github.com/spdk/spdk/issues/328)
-	for target := uint32(0); target < 8; target++ {
-		args := spdk.AddVHostSCSILUNArgs{
-			Controller:    c.vhostSCSI,
-			SCSITargetNum: target,
-			BDevName:      volumeID,
+// scsiTargetsInUse counts the SCSI targets of the vhost-scsi
+// controller. SPDK reports a full controller only with the generic
+// invalid parameters error code when asked to pick a target
+// (https://github.com/spdk/spdk/issues/319), so that must be checked
+// before adding a target.
+func scsiTargetsInUse(vhost vhostController, controllers spdk.GetVHostControllersResponse) int {
+	for _, controller := range controllers {
+		if !vhost.isController(controller.Controller) {
+			continue
 		}
-		err = spdk.AddVHostSCSILUN(ctx, c.SPDK, args)
-		if err == nil {
-			// Success!
-			return &oim.MapVolumeReply{
-				PciAddress: c.vhostDev,
-				ScsiDisk: &oim.SCSIDisk{
-					Target: target,
-					Lun:    0,
-				},
-			}, nil
+		if scsi, ok := controller.BackendSpecific["scsi"].(spdk.SCSIControllerSpecific); ok {
+			return len(scsi)
 		}
 	}
+	return 0
+}
 
-	// TODO: document that the BDev is not going to get deleted.
-	// To remove it, UnmapVolume must be called.
+// mapBLK makes the BDev available through the first unused vhost-blk
+// controller, or the preferred one if that is unused. Each of those
+// controllers has exactly one BDev.
+func (c *Controller) mapBLK(ctx context.Context, volumeID string, controllers spdk.GetVHostControllersResponse, preferred *allocation) (*oim.MapVolumeReply, allocation, error) {
+	// Keyed by the configured controller name, which is not
+	// necessarily the name reported by SPDK.
+	exists := map[string]bool{}
+	for _, controller := range controllers {
+		for _, vhost := range c.vhostBLK {
+			if vhost.isController(controller.Controller) {
+				exists[vhost.controller] = true
+			}
+		}
+		if blk, ok := controller.BackendSpecific["block"].(spdk.BLKControllerSpecific); ok && blk.BDevName == volumeID {
+			for _, vhost := range c.vhostBLK {
+				if vhost.isController(controller.Controller) {
+					// BDev already active.
+					c.volumeMapped(volumeID, true)
+					return &oim.MapVolumeReply{
+						PciAddress: vhost.dev,
+						BlkDisk:    &oim.BlkDisk{},
+					}, allocation{Controller: vhost.controller}, nil
+				}
+			}
+			return nil, allocation{}, errors.Errorf("BDev %s is used by vhost-blk controller %s, which is not configured for the VM", volumeID, controller.Controller)
+		}
+	}
+
+	var candidates []vhostController
+	for _, vhost := range c.vhostBLK {
+		if preferred != nil && vhost.controller == preferred.Controller {
+			candidates = append([]vhostController{vhost}, candidates...)
+		} else {
+			candidates = append(candidates, vhost)
+		}
+	}
+	for _, vhost := range candidates {
+		if exists[vhost.controller] {
+			continue
+		}
+		args := spdk.ConstructVHostBLKControllerArgs{
+			Controller: vhost.controller,
+			DevName:    volumeID,
+		}
+		if err := spdk.ConstructVHostBLKController(ctx, c.SPDK, args); err != nil {
+			return nil, allocation{}, errors.Wrapf(err, "ConstructVHostBLKController %s", vhost.controller)
+		}
+		c.volumeMapped(volumeID, true)
+		return &oim.MapVolumeReply{
+			PciAddress: vhost.dev,
+			BlkDisk:    &oim.BlkDisk{},
+		}, allocation{Controller: vhost.controller}, nil
+	}
+	return nil, allocation{}, status.Errorf(codes.ResourceExhausted, "all %d vhost-blk controllers are in use", len(c.vhostBLK))
+}
 
-	// Return the last SPDK error.
-	errorResult := errors.Wrap(err, "AddVHostSCSILUN failed for all LUNs, last error")
-	return nil, errorResult
+// nvmeofListener returns the address on which subsystems listen.
+func (c *Controller) nvmeofListener() spdk.NVMfListenAddress {
+	adrfam := "IPv4"
+	if ip := net.ParseIP(c.nvmeofAddress); ip != nil && ip.To4() == nil {
+		adrfam = "IPv6"
+	}
+	return spdk.NVMfListenAddress{
+		TRType:  "TCP",
+		AdrFam:  adrfam,
+		TRAddr:  c.nvmeofAddress,
+		TRSvcID: c.nvmeofPort,
+	}
+}
+
+// mapNVMeoF exports the BDev as the only namespace of a NVMe-oF
+// subsystem which listens on TCP. Only the host with the given NQN
+// may connect, unless any host is allowed. Steps which were already
+// done by an earlier, partially failed call are skipped.
+func (c *Controller) mapNVMeoF(ctx context.Context, volumeID, hostNQN string) (*oim.MapVolumeReply, error) {
+	nqn, err := oimcommon.VolumeNQN(c.controllerID, volumeID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if hostNQN == "" && !c.nvmeofAnyHost {
+		return nil, status.Error(codes.InvalidArgument, "host NQN required")
+	}
+
+	transports, err := spdk.GetNVMfTransports(ctx, c.SPDK)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetNVMfTransports")
+	}
+	haveTCP := false
+	for _, transport := range transports {
+		if strings.EqualFold(transport.TRType, "TCP") {
+			haveTCP = true
+		}
+	}
+	if !haveTCP {
+		if err := spdk.NVMfCreateTransport(ctx, c.SPDK, spdk.NVMfCreateTransportArgs{TRType: "TCP"}); err != nil {
+			return nil, errors.Wrap(err, "NVMfCreateTransport")
+		}
+	}
+
+	subsystems, err := spdk.GetNVMfSubsystems(ctx, c.SPDK)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetNVMfSubsystems")
+	}
+	subsystem := spdk.NVMfSubsystem{NQN: nqn}
+	exists := false
+	for _, s := range subsystems {
+		if s.NQN == nqn {
+			subsystem = s
+			exists = true
+		}
+	}
+	if !exists {
+		args := spdk.NVMfSubsystemCreateArgs{
+			NQN:           nqn,
+			AllowAnyHost:  c.nvmeofAnyHost,
+			MaxNamespaces: 1,
+		}
+		if err := spdk.NVMfSubsystemCreate(ctx, c.SPDK, args); err != nil {
+			return nil, errors.Wrapf(err, "NVMfSubsystemCreate %s", nqn)
+		}
+	} else if subsystem.AllowAnyHost != c.nvmeofAnyHost {
+		// Created with a different configuration.
+		args := spdk.NVMfSubsystemAllowAnyHostArgs{
+			NQN:          nqn,
+			AllowAnyHost: c.nvmeofAnyHost,
+		}
+		if err := spdk.NVMfSubsystemAllowAnyHost(ctx, c.SPDK, args); err != nil {
+			return nil, errors.Wrapf(err, "NVMfSubsystemAllowAnyHost %s", nqn)
+		}
+	}
+
+	if !c.nvmeofAnyHost {
+		allowed := false
+		for _, host := range subsystem.Hosts {
+			if host.NQN == hostNQN {
+				allowed = true
+			}
+		}
+		if !allowed {
+			args := spdk.NVMfSubsystemAddHostArgs{
+				NQN:  nqn,
+				Host: hostNQN,
+			}
+			if err := spdk.NVMfSubsystemAddHost(ctx, c.SPDK, args); err != nil {
+				return nil, errors.Wrapf(err, "NVMfSubsystemAddHost %s", nqn)
+			}
+		}
+	}
+
+	listener := c.nvmeofListener()
+	listening := false
+	for _, address := range subsystem.ListenAddresses {
+		if strings.EqualFold(address.TRType, listener.TRType) &&
+			address.TRAddr == listener.TRAddr &&
+			address.TRSvcID == listener.TRSvcID {
+			listening = true
+		}
+	}
+	if !listening {
+		args := spdk.NVMfSubsystemAddListenerArgs{
+			NQN:           nqn,
+			ListenAddress: listener,
+		}
+		if err := spdk.NVMfSubsystemAddListener(ctx, c.SPDK, args); err != nil {
+			return nil, errors.Wrapf(err, "NVMfSubsystemAddListener %s", nqn)
+		}
+	}
+
+	var nsid uint32
+	for _, namespace := range subsystem.Namespaces {
+		if namespace.BDevName == volumeID {
+			nsid = namespace.NSID
+		}
+	}
+	if nsid == 0 {
+		args := spdk.NVMfSubsystemAddNSArgs{
+			NQN:       nqn,
+			Namespace: spdk.NVMfNamespace{BDevName: volumeID},
+		}
+		response, err := spdk.NVMfSubsystemAddNS(ctx, c.SPDK, args)
+		if err != nil {
+			return nil, errors.Wrapf(err, "NVMfSubsystemAddNS %s", nqn)
+		}
+		nsid = uint32(response)
+	}
+
+	c.volumeMapped(volumeID, true)
+	return &oim.MapVolumeReply{
+		NvmeofDisk: &oim.NVMeoFDisk{
+			Nqn:         nqn,
+			Transport:   "tcp",
+			Address:     c.nvmeofAddress,
+			Port:        c.nvmeofPort,
+			NamespaceId: nsid,
+		},
+	}, nil
+}
+
+// mapISCSI exports the BDev as LUN 0 of an iSCSI target which is
+// reachable through the configured portal. Only the given initiator
+// may log in, unless any initiator is allowed. With CHAP credentials,
+// initiators must authenticate. An existing target for the volume is
+// reused if it grants access in the same way.
+func (c *Controller) mapISCSI(ctx context.Context, volumeID, initiator string, chap *oim.ISCSICHAP) (*oim.MapVolumeReply, error) {
+	iqn, err := oimcommon.VolumeIQN(c.controllerID, volumeID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if initiator == "" && !c.iscsiAnyInitiator {
+		return nil, status.Error(codes.InvalidArgument, "initiator IQN required")
+	}
+	if chap != nil && (chap.GetUser() == "" || chap.GetSecret() == "") {
+		return nil, status.Error(codes.InvalidArgument, "CHAP user and secret required")
+	}
+	reply := func(lun int32) *oim.MapVolumeReply {
+		return &oim.MapVolumeReply{
+			IscsiDisk: &oim.ISCSIDisk{
+				Iqn:    iqn,
+				Portal: net.JoinHostPort(c.iscsiAddress, c.iscsiPort),
+				Lun:    uint32(lun),
+			},
+		}
+	}
+
+	c.iscsiMutex.Lock()
+	defer c.iscsiMutex.Unlock()
+
+	targets, err := spdk.GetTargetNodes(ctx, c.SPDK)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetTargetNodes")
+	}
+	for _, target := range targets {
+		if target.Name != iqn {
+			continue
+		}
+		for _, lun := range target.LUNs {
+			if lun.BDevName == volumeID {
+				// Target already active.
+				if err := c.checkISCSIAccess(ctx, target, initiator, chap); err != nil {
+					return nil, err
+				}
+				c.volumeMapped(volumeID, true)
+				return reply(lun.LUNID), nil
+			}
+		}
+		return nil, errors.Errorf("iSCSI target %s exists without BDev %s", iqn, volumeID)
+	}
+
+	if err := c.ensureISCSIGroups(ctx); err != nil {
+		return nil, err
+	}
+	args := spdk.ConstructTargetNodeArgs{
+		Name:       iqn,
+		AliasName:  volumeID,
+		LUNs:       []spdk.TargetNodeLUN{{BDevName: volumeID, LUNID: 0}},
+		QueueDepth: iscsiQueueDepth,
+	}
+	initiatorGroup := int32(iscsiInitiatorGroup)
+	if !c.iscsiAnyInitiator {
+		tag, err := c.addISCSIInitiatorGroup(ctx, initiator)
+		if err != nil {
+			return nil, err
+		}
+		initiatorGroup = tag
+	}
+	args.PGIGMaps = []spdk.PGIGMap{{PGTag: iscsiPortalGroup, IGTag: initiatorGroup}}
+	cleanup := func() {
+		if initiatorGroup != iscsiInitiatorGroup {
+			spdk.DeleteInitiatorGroup(ctx, c.SPDK, spdk.DeleteInitiatorGroupArgs{Tag: initiatorGroup})
+		}
+		if args.CHAPGroup != 0 {
+			spdk.DeleteISCSIAuthGroup(ctx, c.SPDK, spdk.DeleteISCSIAuthGroupArgs{Tag: args.CHAPGroup})
+		}
+	}
+	if chap != nil {
+		tag, err := c.addISCSIAuthGroup(ctx, chap)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		args.RequireCHAP = true
+		args.CHAPGroup = tag
+	} else {
+		args.DisableCHAP = true
+	}
+	if err := spdk.ConstructTargetNode(ctx, c.SPDK, args); err != nil {
+		cleanup()
+		return nil, errors.Wrapf(err, "ConstructTargetNode %s", iqn)
+	}
+	c.volumeMapped(volumeID, true)
+	return reply(0), nil
+}
+
+// checkISCSIAccess returns an error if an existing target does not
+// grant access exactly as a new target for the request would. Using
+// it nonetheless would ignore the initiator and CHAP settings of the
+// request.
+func (c *Controller) checkISCSIAccess(ctx context.Context, target spdk.TargetNode, initiator string, chap *oim.ISCSICHAP) error {
+	mismatch := func(what string) error {
+		return status.Errorf(codes.FailedPrecondition, "iSCSI target %s exists with different %s", target.Name, what)
+	}
+
+	if chap == nil {
+		if target.RequireCHAP || target.CHAPGroup != 0 {
+			return mismatch("CHAP settings")
+		}
+	} else {
+		if !target.RequireCHAP || target.CHAPGroup == 0 {
+			return mismatch("CHAP settings")
+		}
+		authGroups, err := spdk.GetISCSIAuthGroups(ctx, c.SPDK)
+		if err != nil {
+			return errors.Wrap(err, "GetISCSIAuthGroups")
+		}
+		same := false
+		for _, authGroup := range authGroups {
+			if authGroup.Tag == target.CHAPGroup &&
+				len(authGroup.Secrets) == 1 &&
+				authGroup.Secrets[0].User == chap.GetUser() &&
+				authGroup.Secrets[0].Secret == chap.GetSecret() {
+				same = true
+			}
+		}
+		if !same {
+			return mismatch("CHAP credentials")
+		}
+	}
+
+	initiatorGroups, err := spdk.GetInitiatorGroups(ctx, c.SPDK)
+	if err != nil {
+		return errors.Wrap(err, "GetInitiatorGroups")
+	}
+	allowed := false
+	for _, pgig := range target.PGIGMaps {
+		if pgig.IGTag == iscsiInitiatorGroup {
+			// The shared group accepts any initiator.
+			if !c.iscsiAnyInitiator {
+				return mismatch("initiators")
+			}
+			allowed = true
+			continue
+		}
+		for _, initiatorGroup := range initiatorGroups {
+			if initiatorGroup.Tag != pgig.IGTag {
+				continue
+			}
+			for _, name := range initiatorGroup.Initiators {
+				if name == initiator {
+					allowed = true
+				}
+			}
+		}
+	}
+	if !allowed {
+		return mismatch("initiators")
+	}
+	return nil
+}
+
+// ensureISCSIGroups creates the shared portal group and, if any
+// initiator is allowed, the shared initiator group which accepts all
+// initiators, unless they already exist.
+func (c *Controller) ensureISCSIGroups(ctx context.Context) error {
+	portalGroups, err := spdk.GetPortalGroups(ctx, c.SPDK)
+	if err != nil {
+		return errors.Wrap(err, "GetPortalGroups")
+	}
+	havePortalGroup := false
+	for _, portalGroup := range portalGroups {
+		if portalGroup.Tag == iscsiPortalGroup {
+			havePortalGroup = true
+		}
+	}
+	if !havePortalGroup {
+		args := spdk.AddPortalGroupArgs{
+			Tag:     iscsiPortalGroup,
+			Portals: []spdk.ISCSIPortal{{Host: c.iscsiAddress, Port: c.iscsiPort}},
+		}
+		if err := spdk.AddPortalGroup(ctx, c.SPDK, args); err != nil {
+			return errors.Wrap(err, "AddPortalGroup")
+		}
+	}
+
+	if !c.iscsiAnyInitiator {
+		return nil
+	}
+	initiatorGroups, err := spdk.GetInitiatorGroups(ctx, c.SPDK)
+	if err != nil {
+		return errors.Wrap(err, "GetInitiatorGroups")
+	}
+	haveInitiatorGroup := false
+	for _, initiatorGroup := range initiatorGroups {
+		if initiatorGroup.Tag == iscsiInitiatorGroup {
+			haveInitiatorGroup = true
+		}
+	}
+	if !haveInitiatorGroup {
+		args := spdk.AddInitiatorGroupArgs{
+			Tag:        iscsiInitiatorGroup,
+			Initiators: []string{"ANY"},
+			Netmasks:   []string{"ANY"},
+		}
+		if err := spdk.AddInitiatorGroup(ctx, c.SPDK, args); err != nil {
+			return errors.Wrap(err, "AddInitiatorGroup")
+		}
+	}
+	return nil
+}
+
+// addISCSIInitiatorGroup creates a new initiator group which only
+// accepts the initiator and returns its tag. The tag of the shared
+// initiator group is never used for that.
+func (c *Controller) addISCSIInitiatorGroup(ctx context.Context, initiator string) (int32, error) {
+	initiatorGroups, err := spdk.GetInitiatorGroups(ctx, c.SPDK)
+	if err != nil {
+		return 0, errors.Wrap(err, "GetInitiatorGroups")
+	}
+	tag := int32(iscsiInitiatorGroup + 1)
+	for _, initiatorGroup := range initiatorGroups {
+		if initiatorGroup.Tag >= tag {
+			tag = initiatorGroup.Tag + 1
+		}
+	}
+	args := spdk.AddInitiatorGroupArgs{
+		Tag:        tag,
+		Initiators: []string{initiator},
+		Netmasks:   []string{"ANY"},
+	}
+	if err := spdk.AddInitiatorGroup(ctx, c.SPDK, args); err != nil {
+		return 0, errors.Wrapf(err, "AddInitiatorGroup %d", tag)
+	}
+	return tag, nil
+}
+
+// addISCSIAuthGroup creates a new auth group with the CHAP
+// credentials and returns its tag.
+func (c *Controller) addISCSIAuthGroup(ctx context.Context, chap *oim.ISCSICHAP) (int32, error) {
+	authGroups, err := spdk.GetISCSIAuthGroups(ctx, c.SPDK)
+	if err != nil {
+		return 0, errors.Wrap(err, "GetISCSIAuthGroups")
+	}
+	tag := int32(1)
+	for _, authGroup := range authGroups {
+		if authGroup.Tag >= tag {
+			tag = authGroup.Tag + 1
+		}
+	}
+	args := spdk.AddISCSIAuthGroupArgs{
+		Tag:     tag,
+		Secrets: []spdk.CHAPSecret{{User: chap.GetUser(), Secret: chap.GetSecret()}},
+	}
+	if err := spdk.AddISCSIAuthGroup(ctx, c.SPDK, args); err != nil {
+		return 0, errors.Wrapf(err, "AddISCSIAuthGroup %d", tag)
+	}
+	return tag, nil
 }
 
 // UnmapVolume removes the block device for a BDev and (if not a local Malloc BDev) the BDev itself.
@@ -169,9 +825,43 @@ func (c *Controller) UnmapVolume(ctx context.Context, in *oim.UnmapVolumeRequest
 	volumeMutex.LockKey(volumeID)
 	defer volumeMutex.UnlockKey(volumeID)
 
+	var err error
+	switch {
+	case c.nvmeofAddress != "":
+		err = c.unmapNVMeoF(ctx, volumeID)
+	case c.iscsiAddress != "":
+		err = c.unmapISCSI(ctx, volumeID)
+	default:
+		err = c.unmapVHost(ctx, volumeID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't fail when the BDev is not found (idempotency).
+	// Check whether this is really a BDev created by MapVolume (i.e. everything except MallocBDevs).
+	// TODO: detect "not found" errors (https://github.com/spdk/spdk/issues/319)
+	if bdev, err := spdk.GetBDevs(ctx, c.SPDK, spdk.GetBDevsArgs{Name: volumeID}); err == nil && len(bdev) > 0 && bdev[0].ProductName != "Malloc disk" {
+		if err := spdk.DeleteBDev(ctx, c.SPDK, spdk.DeleteBDevArgs{Name: volumeID}); err != nil {
+			// TODO: detect "not found" error (https://github.com/spdk/spdk/issues/319)
+		}
+	}
+
+	if c.journal != nil {
+		if err := c.journal.remove(volumeID); err != nil {
+			return nil, status.Errorf(codes.Internal, "volume %s unmapped, but still recorded: %s", volumeID, err)
+		}
+	}
+
+	c.volumeMapped(volumeID, false)
+	return &oim.UnmapVolumeReply{}, nil
+}
+
+// unmapVHost removes the BDev from all vhost controllers.
+func (c *Controller) unmapVHost(ctx context.Context, volumeID string) error {
 	controllers, err := spdk.GetVHostControllers(ctx, c.SPDK)
 	if err != nil {
-		return nil, errors.Wrap(err, "GetVHostControllers")
+		return errors.Wrap(err, "GetVHostControllers")
 	}
 	// For the sake of completeness we keep iterating even after having found
 	// something.
@@ -189,26 +879,85 @@ func (c *Controller) UnmapVolume(ctx context.Context, in *oim.UnmapVolumeRequest
 									SCSITargetNum: target.SCSIDevNum,
 								}
 								if err := spdk.RemoveVHostSCSITarget(ctx, c.SPDK, removeArgs); err != nil {
-									return nil, errors.Wrap(err, "RemoveVHostSCSITarget")
+									return errors.Wrap(err, "RemoveVHostSCSITarget")
 								}
 							}
 						}
 					}
 				}
+			case "block":
+				if blk, ok := value.(spdk.BLKControllerSpecific); ok && blk.BDevName == volumeID {
+					// The controller exists only for this BDev.
+					removeArgs := spdk.RemoveVHostControllerArgs{
+						Controller: controller.Controller,
+					}
+					if err := spdk.RemoveVHostController(ctx, c.SPDK, removeArgs); err != nil {
+						return errors.Wrap(err, "RemoveVHostController")
+					}
+				}
 			}
 		}
 	}
+	return nil
+}
 
-	// Don't fail when the BDev is not found (idempotency).
-	// Check whether this is really a BDev created by MapVolume (i.e. everything except MallocBDevs).
-	// TODO: detect "not found" errors (https://github.com/spdk/spdk/issues/319)
-	if bdev, err := spdk.GetBDevs(ctx, c.SPDK, spdk.GetBDevsArgs{Name: volumeID}); err == nil && len(bdev) > 0 && bdev[0].ProductName != "Malloc disk" {
-		if err := spdk.DeleteBDev(ctx, c.SPDK, spdk.DeleteBDevArgs{Name: volumeID}); err != nil {
-			// TODO: detect "not found" error (https://github.com/spdk/spdk/issues/319)
+// unmapNVMeoF removes the NVMe-oF subsystem of the volume, if there
+// is one.
+func (c *Controller) unmapNVMeoF(ctx context.Context, volumeID string) error {
+	nqn, err := oimcommon.VolumeNQN(c.controllerID, volumeID)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	subsystems, err := spdk.GetNVMfSubsystems(ctx, c.SPDK)
+	if err != nil {
+		return errors.Wrap(err, "GetNVMfSubsystems")
+	}
+	for _, subsystem := range subsystems {
+		if subsystem.NQN == nqn {
+			if err := spdk.DeleteNVMfSubsystem(ctx, c.SPDK, spdk.DeleteNVMfSubsystemArgs{NQN: nqn}); err != nil {
+				return errors.Wrapf(err, "DeleteNVMfSubsystem %s", nqn)
+			}
 		}
 	}
+	return nil
+}
+
+// unmapISCSI removes the iSCSI target of the volume and its own
+// initiator and auth groups, if there are any.
+func (c *Controller) unmapISCSI(ctx context.Context, volumeID string) error {
+	iqn, err := oimcommon.VolumeIQN(c.controllerID, volumeID)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
 
-	return &oim.UnmapVolumeReply{}, nil
+	c.iscsiMutex.Lock()
+	defer c.iscsiMutex.Unlock()
+
+	targets, err := spdk.GetTargetNodes(ctx, c.SPDK)
+	if err != nil {
+		return errors.Wrap(err, "GetTargetNodes")
+	}
+	for _, target := range targets {
+		if target.Name != iqn {
+			continue
+		}
+		if err := spdk.DeleteTargetNode(ctx, c.SPDK, spdk.DeleteTargetNodeArgs{Name: iqn}); err != nil {
+			return errors.Wrapf(err, "DeleteTargetNode %s", iqn)
+		}
+		for _, pgig := range target.PGIGMaps {
+			if pgig.IGTag != iscsiInitiatorGroup {
+				if err := spdk.DeleteInitiatorGroup(ctx, c.SPDK, spdk.DeleteInitiatorGroupArgs{Tag: pgig.IGTag}); err != nil {
+					return errors.Wrapf(err, "DeleteInitiatorGroup %d", pgig.IGTag)
+				}
+			}
+		}
+		if target.CHAPGroup != 0 {
+			if err := spdk.DeleteISCSIAuthGroup(ctx, c.SPDK, spdk.DeleteISCSIAuthGroupArgs{Tag: target.CHAPGroup}); err != nil {
+				return errors.Wrapf(err, "DeleteISCSIAuthGroup %d", target.CHAPGroup)
+			}
+		}
+	}
+	return nil
 }
 
 // ProvisionMallocBDev creates a new local Malloc BDev.
@@ -273,6 +1022,11 @@ func (c *Controller) CheckMallocBDev(ctx context.Context, in *oim.CheckMallocBDe
 	if err == nil && len(bdevs) == 1 {
 		return &oim.CheckMallocBDevReply{}, nil
 	}
+	if err != nil && !spdk.IsJSONError(err, 0) {
+		// SPDK did not answer at all, which is different from
+		// not finding the BDev.
+		return nil, status.Errorf(codes.Unavailable, "SPDK: %s", err)
+	}
 	// TODO: detect "not found" error (https://github.com/spdk/spdk/issues/319)
 	return nil, status.Error(codes.NotFound, "")
 }
@@ -316,6 +1070,17 @@ func WithRegistryDelay(delay time.Duration) Option {
 	}
 }
 
+// WithRegistryTTL sets how long the registry keeps the controller's
+// address after the last self-registration. The default is three
+// times the registry delay, which tolerates a few failed
+// registration attempts.
+func WithRegistryTTL(ttl time.Duration) Option {
+	return func(c *Controller) error {
+		c.registryTTL = ttl
+		return nil
+	}
+}
+
 // WithCreds sets the secret key and CA used by the controller for
 // mutual TLS.
 func WithCreds(creds credentials.TransportCredentials) Option {
@@ -375,11 +1140,124 @@ func WithVHostDev(dev string) Option {
 	}
 }
 
+// WithVHostSCSI adds another existing vhost-scsi controller and the
+// PCI address of the corresponding SCSI device in the VM. MapVolume
+// uses it once all SCSI targets of the controllers set with
+// WithVHostController and earlier WithVHostSCSI calls are in use.
+func WithVHostSCSI(controller, dev string) Option {
+	return func(c *Controller) error {
+		if controller == "" {
+			return errors.New("empty vhost-scsi controller name")
+		}
+		d, err := oimcommon.ParseBDFString(dev)
+		if err != nil {
+			return errors.Wrapf(err, "vhost-scsi controller %s", controller)
+		}
+		c.moreSCSI = append(c.moreSCSI, vhostController{controller: controller, dev: d})
+		return nil
+	}
+}
+
+// WithVHostBLK adds a vhost-blk controller name and the PCI address
+// of the virtio-blk device for it in the VM. When at least one such
+// controller is configured, MapVolume creates vhost-blk controllers
+// instead of adding LUNs to the SCSI controller.
+func WithVHostBLK(controller, dev string) Option {
+	return func(c *Controller) error {
+		if controller == "" {
+			return errors.New("empty vhost-blk controller name")
+		}
+		d, err := oimcommon.ParseBDFString(dev)
+		if err != nil {
+			return errors.Wrapf(err, "vhost-blk controller %s", controller)
+		}
+		c.vhostBLK = append(c.vhostBLK, vhostController{controller: controller, dev: d})
+		return nil
+	}
+}
+
+// WithNVMeoF sets the IP address and port (<ip>:<port>) on which
+// volumes are exported via NVMe over Fabrics with the TCP transport.
+// Hosts connect to that address, so it must be reachable from them.
+// When set, MapVolume creates one NVMe-oF subsystem per volume
+// instead of using vhost controllers.
+func WithNVMeoF(address string) Option {
+	return func(c *Controller) error {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return errors.Wrap(err, "NVMe-oF address")
+		}
+		if net.ParseIP(host) == nil {
+			return errors.Errorf("NVMe-oF address %q: %q is not an IP address", address, host)
+		}
+		c.nvmeofAddress = host
+		c.nvmeofPort = port
+		return nil
+	}
+}
+
+// WithNVMeoFAllowAnyHost disables the host check for NVMe-oF
+// subsystems. By default, only the host whose NQN is in the
+// MapVolumeRequest may connect. With this option, any host which can
+// reach the NVMe-oF address has access to all mapped volumes,
+// regardless of the authorization done by the OIM registry.
+func WithNVMeoFAllowAnyHost() Option {
+	return func(c *Controller) error {
+		c.nvmeofAnyHost = true
+		return nil
+	}
+}
+
+// WithISCSI sets the IP address and port (<ip>:<port>) of the portal
+// through which volumes are exported via iSCSI. Hosts connect to that
+// address, so it must be reachable from them. When set, MapVolume
+// creates one iSCSI target per volume instead of using vhost
+// controllers.
+func WithISCSI(address string) Option {
+	return func(c *Controller) error {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return errors.Wrap(err, "iSCSI portal")
+		}
+		if net.ParseIP(host) == nil {
+			return errors.Errorf("iSCSI portal %q: %q is not an IP address", address, host)
+		}
+		c.iscsiAddress = host
+		c.iscsiPort = port
+		return nil
+	}
+}
+
+// WithISCSIAllowAnyInitiator disables the initiator check for iSCSI
+// targets. By default, only the initiator whose IQN is in the
+// MapVolumeRequest may log in. With this option, any initiator which
+// can reach the portal may log into targets without CHAP credentials.
+func WithISCSIAllowAnyInitiator() Option {
+	return func(c *Controller) error {
+		c.iscsiAnyInitiator = true
+		return nil
+	}
+}
+
+// WithJournal sets the file in which the controller records all
+// mapped volumes. After a restart, Start restores them in SPDK with
+// the same SCSI targets or vhost controllers as before, so the block
+// devices in the VM remain the same. The file contains the volume
+// parameters including secrets. Without a journal, nothing is
+// restored.
+func WithJournal(path string) Option {
+	return func(c *Controller) error {
+		c.journalPath = path
+		return nil
+	}
+}
+
 // New constructs a new OIM controller instance.
 func New(options ...Option) (*Controller, error) {
 	c := Controller{
 		controllerID:  "unset-controller-id",
 		registryDelay: time.Minute,
+		mapped:        map[string]bool{},
 	}
 	for _, op := range options {
 		err := op(&c)
@@ -388,12 +1266,24 @@ func New(options ...Option) (*Controller, error) {
 		}
 	}
 
+	if c.nvmeofAddress != "" && c.iscsiAddress != "" {
+		return nil, errors.New("NVMe-oF and iSCSI export are mutually exclusive")
+	}
+
 	if c.spdkPath != "" {
 		client, err := spdk.New(c.spdkPath)
 		if err != nil {
 			return nil, err
 		}
 		c.SPDK = client
+		client.OnReconnect(c.spdkReconnected)
+	}
+
+	if c.registryTTL == 0 {
+		c.registryTTL = 3 * c.registryDelay
+	}
+	if c.registryTTL <= c.registryDelay {
+		return nil, errors.New("registry TTL must be longer than the registry delay")
 	}
 
 	if c.registryAddress != "" && (c.controllerID == "" || c.controllerAddr == "") {
@@ -404,17 +1294,134 @@ func New(options ...Option) (*Controller, error) {
 		return nil, errors.New("transport credentials missing")
 	}
 
+	if c.registryAddress != "" {
+		c.registrationErr = errors.New("not registered yet")
+	}
+
+	if c.journalPath != "" {
+		j, err := openJournal(c.journalPath)
+		if err != nil {
+			return nil, err
+		}
+		c.journal = j
+	}
+
 	return &c, nil
 }
 
-// Start begins the interaction with the OIM Registry, if one was configured.
-func (c *Controller) Start() error {
-	if c.registryAddress == "" {
+// Reconcile maps all volumes from the journal again. This is
+// necessary after SPDK was restarted, because SPDK does not persist
+// its configuration. Volumes which are still mapped are left alone.
+// All volumes are tried, the error lists those that failed.
+func (c *Controller) Reconcile(ctx context.Context) error {
+	if c.journal == nil {
+		return nil
+	}
+	if c.SPDK == nil {
+		return errors.New("not connected to SPDK")
+	}
+	var failed []string
+	for volumeID := range c.journal.get() {
+		if err := c.restoreVolume(ctx, volumeID); err != nil {
+			log.FromContext(ctx).Errorw("restoring volume", "volume", volumeID, "error", err)
+			failed = append(failed, volumeID)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return errors.Errorf("failed to restore volumes: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// spdkReconnected restores the volumes from the journal after the
+// SPDK client had to connect again. SPDK probably was restarted and
+// then has lost them.
+func (c *Controller) spdkReconnected() {
+	c.reconcileMutex.Lock()
+	if c.closing {
+		c.reconcileMutex.Unlock()
+		return
+	}
+	c.reconcileWG.Add(1)
+	c.reconcileMutex.Unlock()
+	defer c.reconcileWG.Done()
+
+	if err := c.Reconcile(context.Background()); err != nil {
+		log.L().Errorw("restoring volumes after reconnecting to SPDK", "error", err)
+	}
+}
+
+// restoreVolume maps one volume with the parameters and allocation
+// from the journal.
+func (c *Controller) restoreVolume(ctx context.Context, volumeID string) error {
+	volumeMutex.LockKey(volumeID)
+	defer volumeMutex.UnlockKey(volumeID)
+
+	// The volume might have been unmapped in the meantime.
+	entry, ok := c.journal.lookup(volumeID)
+	if !ok {
 		return nil
 	}
+	var in oim.MapVolumeRequest
+	if err := in.Unmarshal(entry.Request); err != nil {
+		return errors.Wrap(err, "decode MapVolumeRequest")
+	}
+
+	_, alloc, err := c.mapVolume(ctx, &in, &entry.Allocation)
+	if err != nil {
+		return err
+	}
+	if alloc != entry.Allocation {
+		log.FromContext(ctx).Warnw("volume mapped differently than before",
+			"volume", volumeID,
+			"old-controller", entry.Allocation.Controller,
+			"old-target", entry.Allocation.Target,
+			"controller", alloc.Controller,
+			"target", alloc.Target,
+		)
+		entry.Allocation = alloc
+		return c.journal.put(volumeID, entry)
+	}
+	log.FromContext(ctx).Infow("restored volume", "volume", volumeID)
+	return nil
+}
+
+// Start restores the volumes from the journal and then begins the
+// interaction with the OIM Registry, if those were configured.
+// Volumes which cannot be restored are logged, but do not prevent
+// starting the controller. With a journal, SPDK gets checked
+// regularly, so that a restart of SPDK is noticed and the volumes
+// get restored even when there are no requests.
+func (c *Controller) Start() error {
+	if err := c.Reconcile(context.Background()); err != nil {
+		log.L().Errorw("restoring volumes", "error", err)
+	}
 
 	stop := make(chan interface{})
 	c.stop = stop
+
+	if c.journal != nil && c.SPDK != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-time.After(spdkProbeInterval):
+					if err := c.checkSPDK(context.Background()); err != nil {
+						log.L().Warnw("SPDK not available", "error", err)
+					}
+				}
+			}
+		}()
+	}
+
+	if c.registryAddress == "" {
+		return nil
+	}
+
 	c.wg.Add(1)
 	go func() {
 		defer c.wg.Done()
@@ -446,44 +1453,133 @@ func (c *Controller) Start() error {
 }
 
 func (c *Controller) register(ctx context.Context) {
+	log.L().Infof("Registering OIM controller %s at address %s with OIM registry %s", c.controllerID, c.controllerAddr, c.registryAddress)
+	revision, err := c.setAddress(ctx, c.controllerAddr, c.registryTTL, nil)
+	if err != nil {
+		log.L().Infow("registering with OIM registry", "error", err)
+	}
+	c.setRegistration(revision, err)
+}
+
+func (c *Controller) setRegistration(revision int64, err error) {
+	c.registrationMutex.Lock()
+	defer c.registrationMutex.Unlock()
+	c.registrationErr = err
+	if err == nil {
+		c.registrationRevision = revision
+	}
+}
+
+// checkRegistration fails if the last attempt to register with the
+// OIM registry failed.
+func (c *Controller) checkRegistration(ctx context.Context) error {
+	c.registrationMutex.Lock()
+	defer c.registrationMutex.Unlock()
+	return errors.Wrap(c.registrationErr, "registration")
+}
+
+// checkSPDK ensures that SPDK responds.
+func (c *Controller) checkSPDK(ctx context.Context) error {
+	if c.SPDK == nil {
+		return errors.New("not connected to SPDK")
+	}
+	_, err := spdk.GetBDevs(ctx, c.SPDK, spdk.GetBDevsArgs{})
+	return errors.Wrap(err, "SPDK")
+}
+
+// deregister removes the controller from the registry so that it
+// does not have to wait for the TTL to expire. The entry is only
+// removed if it still is the one written by this instance, because
+// a new instance with the same controller ID may already have
+// replaced it.
+func (c *Controller) deregister() {
+	c.registrationMutex.Lock()
+	revision := c.registrationRevision
+	c.registrationMutex.Unlock()
+	if revision == 0 {
+		// Never registered successfully.
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	log.L().Infof("Removing OIM controller %s from OIM registry %s", c.controllerID, c.registryAddress)
+	_, err := c.setAddress(ctx, "", 0, &types.Int64Value{Value: revision})
+	switch {
+	case status.Code(err) == codes.FailedPrecondition:
+		log.L().Infow("not deregistering from OIM registry, registration was replaced", "error", err)
+	case err != nil:
+		log.L().Infow("deregistering from OIM registry", "error", err)
+	}
+}
+
+// setAddress stores the address of the controller in the registry
+// and returns the resulting revision of the entry. If expected is
+// set, the entry is only modified if it still has that revision.
+func (c *Controller) setAddress(ctx context.Context, address string, ttl time.Duration, expected *types.Int64Value) (int64, error) {
 	// Dial anew, because a) when the registry is down
 	// and our address uses Unix domain sockets, dialing
 	// will fail permanently and b) we don't want to keep
 	// a permanent connection from each controller to
 	// the registry.
-	log.L().Infof("Registering OIM controller %s at address %s with OIM registry %s", c.controllerID, c.controllerAddr, c.registryAddress)
 	opts := oimcommon.ChooseDialOpts(c.registryAddress, grpc.WithTransportCredentials(c.creds))
 	conn, err := grpc.DialContext(ctx, c.registryAddress, opts...)
 	if err != nil {
-		log.L().Infow("connecting to OIM registry", "error", err)
+		return 0, errors.Wrap(err, "connecting to OIM registry")
 	}
 	defer conn.Close()
 	registry := oim.NewRegistryClient(conn)
-	registry.SetValue(ctx, &oim.SetValueRequest{
+	reply, err := registry.SetValue(ctx, &oim.SetValueRequest{
 		Value: &oim.Value{
 			Path:  c.controllerID + "/" + oimcommon.RegistryAddress,
-			Value: c.controllerAddr,
+			Value: address,
 		},
+		TtlSeconds:       uint32((ttl + time.Second - 1) / time.Second),
+		ExpectedRevision: expected,
 	})
+	if err != nil {
+		return 0, err
+	}
+	return reply.GetRevision(), nil
 }
 
 // Close ends the interaction with the OIM Registry, if one was configured,
-// and frees all resources.
+// and frees all resources. The controller removes itself from the
+// registry.
 func (c *Controller) Close() {
 	if c.stop != nil {
 		close(c.stop)
 		c.wg.Wait()
+		c.deregister()
 	}
+	c.reconcileMutex.Lock()
+	c.closing = true
+	c.reconcileMutex.Unlock()
 	if c.SPDK != nil {
 		if err := c.SPDK.Close(); err != nil {
 			log.L().Errorw("close SPDK", "error", err)
 		}
 	}
+	c.reconcileWG.Wait()
+	if c.journal != nil {
+		if err := c.journal.close(); err != nil {
+			log.L().Errorw("close journal", "error", err)
+		}
+	}
 }
 
 // Server returns a new gRPC server listening on the given endpoint.
+// Its health service reports the status of the SPDK connection as
+// "oim.v0.Controller" and the status of the self-registration as
+// "registration".
 func (c *Controller) Server(endpoint string) (*oimcommon.NonBlockingGRPCServer, func(*grpc.Server)) {
-	return Server(endpoint, c, c.creds)
+	server, service := Server(endpoint, c, c.creds)
+	server.Health = oimcommon.NewHealthServer()
+	server.Health.AddCheck("oim.v0.Controller", c.checkSPDK)
+	if c.registryAddress != "" {
+		server.Health.AddCheck("registration", c.checkRegistration)
+	}
+	return server, service
 }
 
 // Server configures an arbitrary OIM controller implementation as a gRPC server.
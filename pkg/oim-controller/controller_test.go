@@ -10,10 +10,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 
 	"github.com/intel/oim/pkg/log"
 	"github.com/intel/oim/pkg/log/level"
@@ -29,6 +36,26 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// mappedVolumes returns the current value of the mapped volumes
+// metric for the controller.
+func mappedVolumes(controllerID string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	Expect(err).NotTo(HaveOccurred())
+	for _, family := range families {
+		if family.GetName() != "oim_controller_mapped_volumes" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "controllerid" && label.GetValue() == controllerID {
+					return metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
 var _ = Describe("OIM Controller", func() {
 	var (
 		controllerCreds credentials.TransportCredentials
@@ -48,7 +75,7 @@ var _ = Describe("OIM Controller", func() {
 			registryAddress string
 			ctx             context.Context
 
-			getDB = func() map[string]string {
+			getDB = func() (map[string]string, error) {
 				return oimregistry.GetRegistryEntries(db)
 			}
 		)
@@ -99,9 +126,7 @@ var _ = Describe("OIM Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 			defer c.Close()
 
-			Eventually(func() map[string]string {
-				return oimregistry.GetRegistryEntries(db)
-			}).Should(Equal(map[string]string{controllerID + "/" + oimcommon.RegistryAddress: addr}))
+			Eventually(getDB).Should(Equal(map[string]string{controllerID + "/" + oimcommon.RegistryAddress: addr}))
 		})
 
 		It("should re-register", func() {
@@ -121,11 +146,126 @@ var _ = Describe("OIM Controller", func() {
 
 			Eventually(getDB, 1*time.Second).Should(Equal(map[string]string{controllerID + "/" + oimcommon.RegistryAddress: addr}))
 			// Remove entry.
-			db.Store(controllerID+"/"+oimcommon.RegistryAddress, "")
+			_, err = db.Store(ctx, controllerID+"/"+oimcommon.RegistryAddress, "", 0, oimregistry.AnyRevision)
+			Expect(err).NotTo(HaveOccurred())
 			Consistently(getDB, 4*time.Second).Should(Equal(map[string]string{}))
 			Eventually(getDB, 120*time.Second).Should(Equal(map[string]string{controllerID + "/" + oimcommon.RegistryAddress: addr}))
 		})
 
+		It("should deregister", func() {
+			addr := "foo://bar"
+			controllerID := "host-0"
+			c, err := oimcontroller.New(
+				oimcontroller.WithRegistry(registryAddress),
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithControllerID(controllerID),
+				oimcontroller.WithControllerAddress(addr),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			err = c.Start()
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(getDB, 1*time.Second).Should(Equal(map[string]string{controllerID + "/" + oimcommon.RegistryAddress: addr}))
+			c.Close()
+			Expect(getDB()).To(Equal(map[string]string{}))
+		})
+
+		It("should not deregister a replacement", func() {
+			addr := "foo://bar"
+			controllerID := "host-0"
+			c, err := oimcontroller.New(
+				oimcontroller.WithRegistry(registryAddress),
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithControllerID(controllerID),
+				oimcontroller.WithControllerAddress(addr),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			err = c.Start()
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(getDB, 1*time.Second).Should(Equal(map[string]string{controllerID + "/" + oimcommon.RegistryAddress: addr}))
+			// Another instance registers under the same ID.
+			_, err = db.Store(ctx, controllerID+"/"+oimcommon.RegistryAddress, "foo://baz", 0, oimregistry.AnyRevision)
+			Expect(err).NotTo(HaveOccurred())
+			c.Close()
+			Expect(getDB()).To(Equal(map[string]string{controllerID + "/" + oimcommon.RegistryAddress: "foo://baz"}))
+		})
+
+		It("should keep registration alive", func() {
+			addr := "foo://bar"
+			controllerID := "host-0"
+			c, err := oimcontroller.New(
+				oimcontroller.WithRegistry(registryAddress),
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithControllerID(controllerID),
+				oimcontroller.WithControllerAddress(addr),
+				oimcontroller.WithRegistryDelay(1*time.Second),
+				oimcontroller.WithRegistryTTL(3*time.Second),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			err = c.Start()
+			Expect(err).NotTo(HaveOccurred())
+			defer c.Close()
+
+			Eventually(getDB, 1*time.Second).Should(Equal(map[string]string{controllerID + "/" + oimcommon.RegistryAddress: addr}))
+			Consistently(getDB, 6*time.Second).Should(Equal(map[string]string{controllerID + "/" + oimcommon.RegistryAddress: addr}))
+		})
+
+		It("should report health", func() {
+			controllerID := "host-0"
+			tmpDir, err := ioutil.TempDir("", "oim-controller-test")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(tmpDir)
+			addr := "unix://" + filepath.Join(tmpDir, "controller.sock")
+			c, err := oimcontroller.New(
+				oimcontroller.WithRegistry(registryAddress),
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithControllerID(controllerID),
+				oimcontroller.WithControllerAddress(addr),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			server, service := c.Server(addr)
+			err = server.Start(ctx, service)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() {
+				server.ForceStop(ctx)
+				server.Wait(ctx)
+			}()
+
+			clientCreds, err := oimcommon.LoadTLS(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "controller."+controllerID)
+			Expect(err).NotTo(HaveOccurred())
+			conn, err := grpc.Dial(addr, oimcommon.ChooseDialOpts(addr, grpc.WithTransportCredentials(clientCreds))...)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+			health := healthpb.NewHealthClient(conn)
+			check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+				reply, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+				Expect(err).NotTo(HaveOccurred())
+				return reply.Status
+			}
+
+			// Not connected to SPDK.
+			Expect(check("oim.v0.Controller")).To(Equal(healthpb.HealthCheckResponse_NOT_SERVING))
+			Expect(check("")).To(Equal(healthpb.HealthCheckResponse_NOT_SERVING))
+
+			Expect(check("registration")).To(Equal(healthpb.HealthCheckResponse_NOT_SERVING))
+			err = c.Start()
+			Expect(err).NotTo(HaveOccurred())
+			defer c.Close()
+			Eventually(func() healthpb.HealthCheckResponse_ServingStatus {
+				return check("registration")
+			}).Should(Equal(healthpb.HealthCheckResponse_SERVING))
+		})
+
+		It("should reject TTL shorter than delay", func() {
+			_, err := oimcontroller.New(
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithRegistryDelay(time.Minute),
+				oimcontroller.WithRegistryTTL(time.Second),
+			)
+			Expect(err).To(HaveOccurred())
+		})
+
 		It("should really stop", func() {
 			addr := "foo://bar"
 			controllerID := "host-0"
@@ -143,7 +283,8 @@ var _ = Describe("OIM Controller", func() {
 			Eventually(getDB, 1*time.Second).Should(Equal(map[string]string{controllerID + "/" + oimcommon.RegistryAddress: addr}))
 			c.Close()
 			// Remove entry.
-			db.Store(controllerID+"/"+oimcommon.RegistryAddress, "")
+			_, err = db.Store(ctx, controllerID+"/"+oimcommon.RegistryAddress, "", 0, oimregistry.AnyRevision)
+			Expect(err).NotTo(HaveOccurred())
 			Consistently(getDB, 10*time.Second).Should(Equal(map[string]string{}))
 		})
 	})
@@ -284,12 +425,15 @@ var _ = Describe("OIM Controller", func() {
 			By("mapping a volume")
 			add, controllers := mapVolume()
 
+			Expect(mappedVolumes("unset-controller-id")).To(Equal(1.0))
+
 			By("mapping again")
 			_, err = c.MapVolume(context.Background(), &add)
 			Expect(err).NotTo(HaveOccurred())
 			controllers2, err := spdk.GetVHostControllers(ctx, c.SPDK)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(controllers2).To(Equal(controllers))
+			Expect(mappedVolumes("unset-controller-id")).To(Equal(1.0))
 
 			By("unmapping")
 			remove := oim.UnmapVolumeRequest{
@@ -297,6 +441,7 @@ var _ = Describe("OIM Controller", func() {
 			}
 			_, err = c.UnmapVolume(context.Background(), &remove)
 			Expect(err).NotTo(HaveOccurred())
+			Expect(mappedVolumes("unset-controller-id")).To(Equal(0.0))
 
 			By("unmapping twice")
 			_, err = c.UnmapVolume(context.Background(), &remove)
@@ -339,4 +484,732 @@ var _ = Describe("OIM Controller", func() {
 			})
 		})
 	})
+
+	Describe("attaching many volumes via vhost-scsi", func() {
+		var (
+			volumePrefix = "controller-scsi-test-"
+			numVolumes   = 10
+			vhost2       = "controller-scsi-test-vhost2"
+			vhostDev2    = "00:18.0"
+			c            *oimcontroller.Controller
+		)
+
+		volumeID := func(i int) string {
+			return fmt.Sprintf("%s%d", volumePrefix, i)
+		}
+
+		It("should reject invalid controllers", func() {
+			for _, option := range []oimcontroller.Option{
+				oimcontroller.WithVHostSCSI("", vhostDev2),
+				oimcontroller.WithVHostSCSI(vhost2, "foo"),
+			} {
+				_, err := oimcontroller.New(
+					oimcontroller.WithCreds(controllerCreds),
+					option,
+				)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+
+		Context("with SPDK", func() {
+			BeforeEach(func() {
+				err := testspdk.Init(testspdk.WithVHostSCSI())
+				Expect(err).NotTo(HaveOccurred())
+				if testspdk.SPDK == nil {
+					Skip("No SPDK vhost.")
+				}
+				err = spdk.ConstructVHostSCSIController(context.Background(), testspdk.SPDK,
+					spdk.ConstructVHostSCSIControllerArgs{Controller: vhost2})
+				Expect(err).NotTo(HaveOccurred())
+
+				c, err = oimcontroller.New(oimcontroller.WithSPDK(testspdk.SPDKPath),
+					oimcontroller.WithCreds(controllerCreds),
+					oimcontroller.WithVHostDev(testspdk.VHostDev),
+					oimcontroller.WithVHostController(testspdk.VHostPath),
+					oimcontroller.WithVHostSCSI(vhost2, vhostDev2))
+				Expect(err).NotTo(HaveOccurred())
+
+				for i := 0; i < numVolumes; i++ {
+					_, err = c.ProvisionMallocBDev(context.Background(), &oim.ProvisionMallocBDevRequest{
+						BdevName: volumeID(i),
+						Size_:    1 * 1024 * 1024,
+					})
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			AfterEach(func() {
+				if testspdk.SPDK != nil {
+					ctx := context.Background()
+					for i := 0; i < numVolumes; i++ {
+						c.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: volumeID(i)})
+						spdk.DeleteBDev(ctx, c.SPDK, spdk.DeleteBDevArgs{Name: volumeID(i)})
+					}
+					spdk.RemoveVHostController(ctx, c.SPDK, spdk.RemoveVHostControllerArgs{Controller: vhost2})
+					c.Close()
+				}
+				err := testspdk.Finalize()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should spill over to the next controller", func() {
+				ctx := context.Background()
+				d, err := oimcommon.ParseBDFString(testspdk.VHostDev)
+				Expect(err).NotTo(HaveOccurred())
+				d2, err := oimcommon.ParseBDFString(vhostDev2)
+				Expect(err).NotTo(HaveOccurred())
+				mapVolume := func(i int) *oim.MapVolumeReply {
+					reply, err := c.MapVolume(ctx, &oim.MapVolumeRequest{
+						VolumeId: volumeID(i),
+						Params: &oim.MapVolumeRequest_Malloc{
+							Malloc: &oim.MallocParams{},
+						},
+					})
+					Expect(err).NotTo(HaveOccurred(), volumeID(i))
+					return reply
+				}
+
+				By("filling the first controller")
+				for i := 0; i < 8; i++ {
+					Expect(mapVolume(i)).To(Equal(&oim.MapVolumeReply{
+						PciAddress: d,
+						ScsiDisk:   &oim.SCSIDisk{Target: uint32(i)},
+					}))
+				}
+
+				By("using the second controller")
+				expected := &oim.MapVolumeReply{
+					PciAddress: d2,
+					ScsiDisk:   &oim.SCSIDisk{Target: 0},
+				}
+				Expect(mapVolume(8)).To(Equal(expected))
+				Expect(mapVolume(8)).To(Equal(expected), "idempotent")
+
+				By("reusing a target")
+				_, err = c.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: volumeID(3)})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mapVolume(9)).To(Equal(&oim.MapVolumeReply{
+					PciAddress: d,
+					ScsiDisk:   &oim.SCSIDisk{Target: 3},
+				}))
+			})
+		})
+	})
+
+	Describe("attaching a volume via vhost-blk", func() {
+		var (
+			volumeID = "controller-blk-test"
+			vhost    = "controller-blk-test-vhost"
+			vhostDev = "00:16.0"
+			c        *oimcontroller.Controller
+		)
+
+		It("should reject invalid PCI addresses", func() {
+			_, err := oimcontroller.New(
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithVHostBLK(vhost, "foo"),
+			)
+			Expect(err).To(HaveOccurred())
+		})
+
+		Context("with SPDK", func() {
+			BeforeEach(func() {
+				err := testspdk.Init()
+				Expect(err).NotTo(HaveOccurred())
+				if testspdk.SPDK == nil {
+					Skip("No SPDK vhost.")
+				}
+				c = nil
+
+				ctx := context.Background()
+				for _, name := range []string{volumeID, volumeID + "2"} {
+					_, err = spdk.ConstructMallocBDev(ctx, testspdk.SPDK, spdk.ConstructMallocBDevArgs{
+						ConstructBDevArgs: spdk.ConstructBDevArgs{
+							NumBlocks: 1 * 1024 * 1024 / 512,
+							BlockSize: 512,
+							Name:      name,
+						},
+					})
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			AfterEach(func() {
+				if testspdk.SPDK != nil {
+					ctx := context.Background()
+					controllers, _ := spdk.GetVHostControllers(ctx, testspdk.SPDK)
+					for _, controller := range controllers {
+						if _, ok := controller.BackendSpecific["block"]; ok {
+							spdk.RemoveVHostController(ctx, testspdk.SPDK, spdk.RemoveVHostControllerArgs{Controller: controller.Controller})
+						}
+					}
+					for _, name := range []string{volumeID, volumeID + "2"} {
+						spdk.DeleteBDev(ctx, testspdk.SPDK, spdk.DeleteBDevArgs{Name: name})
+					}
+					if c != nil {
+						c.Close()
+					}
+				}
+				err := testspdk.Finalize()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			mapUnmap := func(controllerName, reportedName string) {
+				var err error
+				c, err = oimcontroller.New(oimcontroller.WithSPDK(testspdk.SPDKPath),
+					oimcontroller.WithCreds(controllerCreds),
+					oimcontroller.WithVHostBLK(controllerName, vhostDev))
+				Expect(err).NotTo(HaveOccurred())
+
+				ctx := context.Background()
+				d, err := oimcommon.ParseBDFString(vhostDev)
+				Expect(err).NotTo(HaveOccurred())
+				expected := &oim.MapVolumeReply{
+					PciAddress: d,
+					BlkDisk:    &oim.BlkDisk{},
+				}
+				add := oim.MapVolumeRequest{
+					VolumeId: volumeID,
+					Params: &oim.MapVolumeRequest_Malloc{
+						Malloc: &oim.MallocParams{},
+					},
+				}
+
+				By("mapping a volume")
+				reply, err := c.MapVolume(ctx, &add)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply).To(Equal(expected))
+				controllers, err := spdk.GetVHostControllers(ctx, c.SPDK)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(controllers).To(HaveLen(1))
+				Expect(controllers[0].Controller).To(Equal(reportedName))
+				Expect(controllers[0].BackendSpecific).To(HaveKeyWithValue("block", spdk.BLKControllerSpecific{BDevName: volumeID}))
+
+				By("mapping again")
+				reply, err = c.MapVolume(ctx, &add)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply).To(Equal(expected))
+
+				By("mapping another volume")
+				add2 := add
+				add2.VolumeId = volumeID + "2"
+				_, err = c.MapVolume(ctx, &add2)
+				Expect(err).To(HaveOccurred())
+				Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+
+				By("unmapping")
+				_, err = c.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: volumeID})
+				Expect(err).NotTo(HaveOccurred())
+				controllers, err = spdk.GetVHostControllers(ctx, c.SPDK)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(controllers).To(BeEmpty())
+
+				By("mapping the other volume")
+				_, err = c.MapVolume(ctx, &add2)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = c.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: add2.VolumeId})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			It("should work without QEMU", func() {
+				mapUnmap(vhost, vhost)
+			})
+
+			It("should work with a socket path as controller name", func() {
+				// SPDK creates the vhost sockets next to its RPC socket.
+				mapUnmap(filepath.Join(filepath.Dir(testspdk.SPDKPath), vhost), vhost)
+			})
+		})
+	})
+
+	Describe("exporting a volume via NVMe-oF", func() {
+		var (
+			volumeID     = "controller-nvmeof-test"
+			controllerID = "host-0"
+			hostNQN      = "nqn.2018-09.io.oim:host:host-0"
+			address      = "127.0.0.1"
+			port         = "4420"
+			c            *oimcontroller.Controller
+		)
+
+		It("should reject invalid addresses", func() {
+			for _, address := range []string{"127.0.0.1", "localhost:4420", ":4420"} {
+				_, err := oimcontroller.New(
+					oimcontroller.WithCreds(controllerCreds),
+					oimcontroller.WithNVMeoF(address),
+				)
+				Expect(err).To(HaveOccurred(), address)
+			}
+		})
+
+		Context("with SPDK", func() {
+			BeforeEach(func() {
+				err := testspdk.Init()
+				Expect(err).NotTo(HaveOccurred())
+				if testspdk.SPDK == nil {
+					Skip("No SPDK vhost.")
+				}
+				if _, err := spdk.GetNVMfTransports(context.Background(), testspdk.SPDK); spdk.IsJSONError(err, spdk.ERROR_METHOD_NOT_FOUND) {
+					Skip("No NVMe-oF target in SPDK.")
+				}
+
+				c, err = oimcontroller.New(oimcontroller.WithSPDK(testspdk.SPDKPath),
+					oimcontroller.WithCreds(controllerCreds),
+					oimcontroller.WithControllerID(controllerID),
+					oimcontroller.WithNVMeoF(address+":"+port))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = c.ProvisionMallocBDev(context.Background(), &oim.ProvisionMallocBDevRequest{
+					BdevName: volumeID,
+					Size_:    1 * 1024 * 1024,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				if c != nil {
+					ctx := context.Background()
+					nqn, _ := oimcommon.VolumeNQN(controllerID, volumeID)
+					spdk.DeleteNVMfSubsystem(ctx, c.SPDK, spdk.DeleteNVMfSubsystemArgs{NQN: nqn})
+					spdk.DeleteBDev(ctx, c.SPDK, spdk.DeleteBDevArgs{Name: volumeID})
+					c.Close()
+					c = nil
+				}
+				err := testspdk.Finalize()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should require the host NQN", func() {
+				_, err := c.MapVolume(context.Background(), &oim.MapVolumeRequest{
+					VolumeId: volumeID,
+					Params: &oim.MapVolumeRequest_Malloc{
+						Malloc: &oim.MallocParams{},
+					},
+				})
+				Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			})
+
+			It("should allow any host when configured to", func() {
+				ctx := context.Background()
+				c.Close()
+				var err error
+				c, err = oimcontroller.New(oimcontroller.WithSPDK(testspdk.SPDKPath),
+					oimcontroller.WithCreds(controllerCreds),
+					oimcontroller.WithControllerID(controllerID),
+					oimcontroller.WithNVMeoF(address+":"+port),
+					oimcontroller.WithNVMeoFAllowAnyHost())
+				Expect(err).NotTo(HaveOccurred())
+				_, err = c.MapVolume(ctx, &oim.MapVolumeRequest{
+					VolumeId: volumeID,
+					Params: &oim.MapVolumeRequest_Malloc{
+						Malloc: &oim.MallocParams{},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				nqn, err := oimcommon.VolumeNQN(controllerID, volumeID)
+				Expect(err).NotTo(HaveOccurred())
+				subsystems, err := spdk.GetNVMfSubsystems(ctx, c.SPDK)
+				Expect(err).NotTo(HaveOccurred())
+				var found *spdk.NVMfSubsystem
+				for i := range subsystems {
+					if subsystems[i].NQN == nqn {
+						found = &subsystems[i]
+					}
+				}
+				Expect(found).NotTo(BeNil())
+				Expect(found.AllowAnyHost).To(BeTrue())
+			})
+
+			It("should work without a host", func() {
+				ctx := context.Background()
+				nqn, err := oimcommon.VolumeNQN(controllerID, volumeID)
+				Expect(err).NotTo(HaveOccurred())
+				expected := &oim.MapVolumeReply{
+					NvmeofDisk: &oim.NVMeoFDisk{
+						Nqn:         nqn,
+						Transport:   "tcp",
+						Address:     address,
+						Port:        port,
+						NamespaceId: 1,
+					},
+				}
+				add := oim.MapVolumeRequest{
+					VolumeId: volumeID,
+					Params: &oim.MapVolumeRequest_Malloc{
+						Malloc: &oim.MallocParams{},
+					},
+					HostNqn: hostNQN,
+				}
+				findSubsystem := func() *spdk.NVMfSubsystem {
+					subsystems, err := spdk.GetNVMfSubsystems(ctx, c.SPDK)
+					Expect(err).NotTo(HaveOccurred())
+					for _, subsystem := range subsystems {
+						if subsystem.NQN == nqn {
+							return &subsystem
+						}
+					}
+					return nil
+				}
+
+				By("mapping a volume")
+				reply, err := c.MapVolume(ctx, &add)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply).To(Equal(expected))
+				subsystem := findSubsystem()
+				Expect(subsystem).NotTo(BeNil())
+				Expect(subsystem.Namespaces).To(Equal([]spdk.NVMfNamespace{{NSID: 1, BDevName: volumeID}}))
+				Expect(subsystem.ListenAddresses).To(HaveLen(1))
+				Expect(subsystem.AllowAnyHost).To(BeFalse())
+				Expect(subsystem.Hosts).To(Equal([]spdk.NVMfHost{{NQN: hostNQN}}))
+				Expect(mappedVolumes(controllerID)).To(Equal(1.0))
+
+				By("mapping again")
+				reply, err = c.MapVolume(ctx, &add)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply).To(Equal(expected))
+				Expect(findSubsystem()).To(Equal(subsystem))
+
+				By("unmapping")
+				_, err = c.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: volumeID})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(findSubsystem()).To(BeNil())
+				Expect(mappedVolumes(controllerID)).To(Equal(0.0))
+
+				By("unmapping twice")
+				_, err = c.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: volumeID})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("exporting a volume via iSCSI", func() {
+		var (
+			volumeID     = "controller-iscsi-test"
+			controllerID = "host-0"
+			initiator    = "iqn.2018-09.io.oim:host:host-0"
+			portal       = "127.0.0.1:3260"
+			c            *oimcontroller.Controller
+		)
+
+		It("should reject invalid portals", func() {
+			for _, portal := range []string{"127.0.0.1", "localhost:3260", ":3260"} {
+				_, err := oimcontroller.New(
+					oimcontroller.WithCreds(controllerCreds),
+					oimcontroller.WithISCSI(portal),
+				)
+				Expect(err).To(HaveOccurred(), portal)
+			}
+		})
+
+		It("should not be combined with NVMe-oF", func() {
+			_, err := oimcontroller.New(
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithISCSI(portal),
+				oimcontroller.WithNVMeoF("127.0.0.1:4420"),
+			)
+			Expect(err).To(HaveOccurred())
+		})
+
+		Context("with SPDK", func() {
+			BeforeEach(func() {
+				err := testspdk.Init()
+				Expect(err).NotTo(HaveOccurred())
+				if testspdk.SPDK == nil {
+					Skip("No SPDK vhost.")
+				}
+				if _, err := spdk.GetPortalGroups(context.Background(), testspdk.SPDK); spdk.IsJSONError(err, spdk.ERROR_METHOD_NOT_FOUND) {
+					Skip("No iSCSI target in SPDK.")
+				}
+
+				c, err = oimcontroller.New(oimcontroller.WithSPDK(testspdk.SPDKPath),
+					oimcontroller.WithCreds(controllerCreds),
+					oimcontroller.WithControllerID(controllerID),
+					oimcontroller.WithISCSI(portal))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = c.ProvisionMallocBDev(context.Background(), &oim.ProvisionMallocBDevRequest{
+					BdevName: volumeID,
+					Size_:    1 * 1024 * 1024,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				if c != nil {
+					ctx := context.Background()
+					iqn, _ := oimcommon.VolumeIQN(controllerID, volumeID)
+					spdk.DeleteTargetNode(ctx, c.SPDK, spdk.DeleteTargetNodeArgs{Name: iqn})
+					spdk.DeleteBDev(ctx, c.SPDK, spdk.DeleteBDevArgs{Name: volumeID})
+					c.Close()
+					c = nil
+				}
+				err := testspdk.Finalize()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should require the initiator IQN", func() {
+				_, err := c.MapVolume(context.Background(), &oim.MapVolumeRequest{
+					VolumeId: volumeID,
+					Params: &oim.MapVolumeRequest_Malloc{
+						Malloc: &oim.MallocParams{},
+					},
+				})
+				Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			})
+
+			It("should work without a host", func() {
+				ctx := context.Background()
+				iqn, err := oimcommon.VolumeIQN(controllerID, volumeID)
+				Expect(err).NotTo(HaveOccurred())
+				expected := &oim.MapVolumeReply{
+					IscsiDisk: &oim.ISCSIDisk{
+						Iqn:    iqn,
+						Portal: portal,
+						Lun:    0,
+					},
+				}
+				add := oim.MapVolumeRequest{
+					VolumeId: volumeID,
+					Params: &oim.MapVolumeRequest_Malloc{
+						Malloc: &oim.MallocParams{},
+					},
+					IscsiInitiator: initiator,
+					IscsiChap: &oim.ISCSICHAP{
+						User:   "user",
+						Secret: "0123456789ab",
+					},
+				}
+				findTarget := func() *spdk.TargetNode {
+					targets, err := spdk.GetTargetNodes(ctx, c.SPDK)
+					Expect(err).NotTo(HaveOccurred())
+					for _, target := range targets {
+						if target.Name == iqn {
+							return &target
+						}
+					}
+					return nil
+				}
+				findAuthGroup := func(tag int32) *spdk.ISCSIAuthGroup {
+					authGroups, err := spdk.GetISCSIAuthGroups(ctx, c.SPDK)
+					Expect(err).NotTo(HaveOccurred())
+					for _, authGroup := range authGroups {
+						if authGroup.Tag == tag {
+							return &authGroup
+						}
+					}
+					return nil
+				}
+				findInitiatorGroup := func(tag int32) *spdk.InitiatorGroup {
+					initiatorGroups, err := spdk.GetInitiatorGroups(ctx, c.SPDK)
+					Expect(err).NotTo(HaveOccurred())
+					for _, initiatorGroup := range initiatorGroups {
+						if initiatorGroup.Tag == tag {
+							return &initiatorGroup
+						}
+					}
+					return nil
+				}
+
+				By("mapping a volume")
+				reply, err := c.MapVolume(ctx, &add)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply).To(Equal(expected))
+				target := findTarget()
+				Expect(target).NotTo(BeNil())
+				Expect(target.LUNs).To(Equal([]spdk.TargetNodeLUN{{BDevName: volumeID, LUNID: 0}}))
+				Expect(target.RequireCHAP).To(BeTrue())
+				authGroup := findAuthGroup(target.CHAPGroup)
+				Expect(authGroup).NotTo(BeNil())
+				Expect(authGroup.Secrets).To(Equal([]spdk.CHAPSecret{{User: "user", Secret: "0123456789ab"}}))
+				Expect(target.PGIGMaps).To(HaveLen(1))
+				initiatorGroup := findInitiatorGroup(target.PGIGMaps[0].IGTag)
+				Expect(initiatorGroup).NotTo(BeNil())
+				Expect(initiatorGroup.Initiators).To(Equal([]string{initiator}))
+				Expect(mappedVolumes(controllerID)).To(Equal(1.0))
+
+				By("mapping again")
+				reply, err = c.MapVolume(ctx, &add)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply).To(Equal(expected))
+				Expect(findTarget()).To(Equal(target))
+
+				By("mapping with different CHAP credentials")
+				other := add
+				other.IscsiChap = &oim.ISCSICHAP{User: "user", Secret: "ba9876543210"}
+				_, err = c.MapVolume(ctx, &other)
+				Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+				other.IscsiChap = nil
+				_, err = c.MapVolume(ctx, &other)
+				Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+
+				By("mapping for a different initiator")
+				other = add
+				other.IscsiInitiator = "iqn.2018-09.io.oim:host:host-1"
+				_, err = c.MapVolume(ctx, &other)
+				Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+
+				By("unmapping")
+				_, err = c.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: volumeID})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(findTarget()).To(BeNil())
+				Expect(findAuthGroup(target.CHAPGroup)).To(BeNil())
+				Expect(findInitiatorGroup(initiatorGroup.Tag)).To(BeNil())
+				Expect(mappedVolumes(controllerID)).To(Equal(0.0))
+
+				By("unmapping twice")
+				_, err = c.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: volumeID})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("journal", func() {
+		var (
+			volumePrefix = "controller-journal-test-"
+			numVolumes   = 4
+			tmpDir       string
+			journalPath  string
+			c            *oimcontroller.Controller
+		)
+
+		volumeID := func(i int) string {
+			return fmt.Sprintf("%s%d", volumePrefix, i)
+		}
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "oim-controller-journal")
+			Expect(err).NotTo(HaveOccurred())
+			journalPath = filepath.Join(tmpDir, "journal")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		It("should be locked", func() {
+			c, err := oimcontroller.New(
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithJournal(journalPath),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = oimcontroller.New(
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithJournal(journalPath),
+			)
+			Expect(err).To(HaveOccurred(), "second controller")
+			c.Close()
+
+			c, err = oimcontroller.New(
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithJournal(journalPath),
+			)
+			Expect(err).NotTo(HaveOccurred(), "after closing")
+			c.Close()
+		})
+
+		It("should reject a corrupt journal", func() {
+			err := ioutil.WriteFile(journalPath, []byte("garbage"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = oimcontroller.New(
+				oimcontroller.WithCreds(controllerCreds),
+				oimcontroller.WithJournal(journalPath),
+			)
+			Expect(err).To(HaveOccurred())
+		})
+
+		Context("with SPDK", func() {
+			newController := func() *oimcontroller.Controller {
+				c, err := oimcontroller.New(oimcontroller.WithSPDK(testspdk.SPDKPath),
+					oimcontroller.WithCreds(controllerCreds),
+					oimcontroller.WithControllerID(volumePrefix+"controller"),
+					oimcontroller.WithVHostDev(testspdk.VHostDev),
+					oimcontroller.WithVHostController(testspdk.VHostPath),
+					oimcontroller.WithJournal(journalPath))
+				Expect(err).NotTo(HaveOccurred())
+				return c
+			}
+
+			BeforeEach(func() {
+				err := testspdk.Init(testspdk.WithVHostSCSI())
+				Expect(err).NotTo(HaveOccurred())
+				if testspdk.SPDK == nil {
+					Skip("No SPDK vhost.")
+				}
+				c = newController()
+				for i := 0; i < numVolumes; i++ {
+					_, err = c.ProvisionMallocBDev(context.Background(), &oim.ProvisionMallocBDevRequest{
+						BdevName: volumeID(i),
+						Size_:    1 * 1024 * 1024,
+					})
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			AfterEach(func() {
+				if testspdk.SPDK != nil {
+					ctx := context.Background()
+					for i := 0; i < numVolumes; i++ {
+						c.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: volumeID(i)})
+						spdk.DeleteBDev(ctx, c.SPDK, spdk.DeleteBDevArgs{Name: volumeID(i)})
+					}
+					c.Close()
+				}
+				err := testspdk.Finalize()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should restore SCSI targets", func() {
+				ctx := context.Background()
+				d, err := oimcommon.ParseBDFString(testspdk.VHostDev)
+				Expect(err).NotTo(HaveOccurred())
+				mapVolume := func(i int) *oim.MapVolumeReply {
+					reply, err := c.MapVolume(ctx, &oim.MapVolumeRequest{
+						VolumeId: volumeID(i),
+						Params: &oim.MapVolumeRequest_Malloc{
+							Malloc: &oim.MallocParams{},
+						},
+					})
+					Expect(err).NotTo(HaveOccurred(), volumeID(i))
+					return reply
+				}
+				scsiTarget := func(target uint32) *oim.MapVolumeReply {
+					return &oim.MapVolumeReply{
+						PciAddress: d,
+						ScsiDisk:   &oim.SCSIDisk{Target: target},
+					}
+				}
+
+				By("mapping volumes")
+				for i := 0; i < numVolumes; i++ {
+					Expect(mapVolume(i)).To(Equal(scsiTarget(uint32(i))))
+				}
+				_, err = c.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: volumeID(0)})
+				Expect(err).NotTo(HaveOccurred())
+
+				By("losing the SPDK configuration")
+				for i := 1; i < numVolumes; i++ {
+					err = spdk.RemoveVHostSCSITarget(ctx, c.SPDK, spdk.RemoveVHostSCSITargetArgs{
+						Controller:    testspdk.VHostPath,
+						SCSITargetNum: uint32(i),
+					})
+					Expect(err).NotTo(HaveOccurred())
+				}
+				// Cannot be restored.
+				err = spdk.DeleteBDev(ctx, c.SPDK, spdk.DeleteBDevArgs{Name: volumeID(3)})
+				Expect(err).NotTo(HaveOccurred())
+				c.Close()
+
+				By("restarting the controller")
+				c = newController()
+				err = c.Start()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mappedVolumes(volumePrefix + "controller")).To(Equal(2.0))
+				Expect(mapVolume(1)).To(Equal(scsiTarget(1)), "target 0 is free, but must not be used")
+				Expect(mapVolume(2)).To(Equal(scsiTarget(2)))
+				err = c.Reconcile(ctx)
+				Expect(err).To(MatchError("failed to restore volumes: " + volumeID(3)))
+			})
+		})
+	})
 })
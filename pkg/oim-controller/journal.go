@@ -0,0 +1,191 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcontroller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+// journal remembers all mapped volumes across restarts of the
+// controller. SPDK keeps its configuration only in memory, so this
+// is the only record of what needs to be restored after a restart
+// of SPDK.
+//
+// The entries are few and change rarely, therefore the file simply
+// gets rewritten completely for each change. The new content is
+// written under a temporary name and then atomically renamed, so
+// there always is a complete copy of the data on disk. The file
+// contains secrets (for example, Ceph keys) and thus is only
+// accessible by the owner.
+type journal struct {
+	path string
+	// lock is a separate file because the journal itself gets
+	// replaced.
+	lock *os.File
+
+	mutex   sync.Mutex
+	entries map[string]journalEntry
+}
+
+// journalEntry describes how one volume was mapped.
+type journalEntry struct {
+	// Request is the serialized MapVolumeRequest.
+	Request []byte `json:"request"`
+	// Allocation is where the volume was mapped.
+	Allocation allocation `json:"allocation"`
+}
+
+// allocation records the vhost controller and, for vhost-scsi, the
+// SCSI target which were picked for a volume. Empty for NVMe-oF and
+// iSCSI, where subsystem and target names are derived from the
+// volume ID.
+type allocation struct {
+	Controller string `json:"controller,omitempty"`
+	Target     uint32 `json:"target,omitempty"`
+}
+
+// newJournalEntry serializes the request.
+func newJournalEntry(in *oim.MapVolumeRequest, alloc allocation) (journalEntry, error) {
+	request, err := in.Marshal()
+	if err != nil {
+		return journalEntry{}, errors.Wrap(err, "encode MapVolumeRequest")
+	}
+	return journalEntry{Request: request, Allocation: alloc}, nil
+}
+
+// openJournal loads the journal file, if there is one. The journal is
+// locked against concurrent use by other processes until close is
+// called.
+func openJournal(path string) (*journal, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "open journal lock")
+	}
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		lock.Close() // nolint: gosec
+		return nil, errors.Wrapf(err, "lock journal %s", path)
+	}
+	j := &journal{
+		path:    path,
+		lock:    lock,
+		entries: map[string]journalEntry{},
+	}
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		// Nothing mapped yet.
+	case err != nil:
+		j.close()
+		return nil, errors.Wrap(err, "read journal")
+	default:
+		if err := json.Unmarshal(data, &j.entries); err != nil {
+			j.close()
+			return nil, errors.Wrapf(err, "parse journal %s", path)
+		}
+	}
+	return j, nil
+}
+
+// get returns a copy of all entries.
+func (j *journal) get() map[string]journalEntry {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	entries := map[string]journalEntry{}
+	for volumeID, entry := range j.entries {
+		entries[volumeID] = entry
+	}
+	return entries
+}
+
+// lookup returns the entry for the volume, if there is one.
+func (j *journal) lookup(volumeID string) (journalEntry, bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	entry, ok := j.entries[volumeID]
+	return entry, ok
+}
+
+// put adds or replaces the entry for the volume.
+func (j *journal) put(volumeID string, entry journalEntry) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	old, ok := j.entries[volumeID]
+	j.entries[volumeID] = entry
+	if err := j.write(); err != nil {
+		if ok {
+			j.entries[volumeID] = old
+		} else {
+			delete(j.entries, volumeID)
+		}
+		return err
+	}
+	return nil
+}
+
+// remove deletes the entry for the volume, if there is one.
+func (j *journal) remove(volumeID string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	old, ok := j.entries[volumeID]
+	if !ok {
+		return nil
+	}
+	delete(j.entries, volumeID)
+	if err := j.write(); err != nil {
+		j.entries[volumeID] = old
+		return err
+	}
+	return nil
+}
+
+// write replaces the file with the current entries. Must be called
+// while holding the mutex.
+func (j *journal) write() error {
+	data, err := json.Marshal(j.entries)
+	if err != nil {
+		return errors.Wrap(err, "encode journal")
+	}
+	tmpPath := j.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "write journal")
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err2 := tmp.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, j.path)
+	}
+	if err != nil {
+		os.Remove(tmpPath) // nolint: gosec
+		return errors.Wrap(err, "write journal")
+	}
+	// Make the rename itself durable.
+	if dir, err := os.Open(filepath.Dir(j.path)); err == nil {
+		dir.Sync()  // nolint: gosec
+		dir.Close() // nolint: gosec
+	}
+	return nil
+}
+
+// close releases the lock.
+func (j *journal) close() error {
+	return j.lock.Close()
+}
@@ -0,0 +1,35 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcontroller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var mappedVolumes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "oim",
+	Subsystem: "controller",
+	Name:      "mapped_volumes",
+	Help:      "Number of volumes currently mapped through the controller, not counting those mapped before it was started unless they were restored from the journal.",
+}, []string{"controllerid"})
+
+func init() {
+	prometheus.MustRegister(mappedVolumes)
+}
+
+// volumeMapped updates the set of mapped volumes and the
+// corresponding metric.
+func (c *Controller) volumeMapped(volumeID string, mapped bool) {
+	c.mappedMutex.Lock()
+	defer c.mappedMutex.Unlock()
+	if mapped {
+		c.mapped[volumeID] = true
+	} else {
+		delete(c.mapped, volumeID)
+	}
+	mappedVolumes.WithLabelValues(c.controllerID).Set(float64(len(c.mapped)))
+}
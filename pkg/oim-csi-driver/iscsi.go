@@ -0,0 +1,290 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcsidriver
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/intel/oim/pkg/log"
+	csi0 "github.com/intel/oim/pkg/spec/csi/v0"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+const (
+	// iscsiadm is the open-iscsi command line tool. It needs
+	// iscsid, usually the one running on the host.
+	iscsiadm = "iscsiadm"
+	// sysISCSISession has one entry per iSCSI session.
+	sysISCSISession = "/sys/class/iscsi_session"
+
+	// iscsiCHAPUser and iscsiCHAPSecret are the keys of the CHAP
+	// credentials in the node stage secrets. They are the same
+	// as for the Kubernetes iSCSI volume plugin.
+	iscsiCHAPUser   = "node.session.auth.username"
+	iscsiCHAPSecret = "node.session.auth.password"
+
+	// Exit codes of iscsiadm which are not errors for us.
+	iscsiErrSessExists  = 15
+	iscsiErrNoObjsFound = 21
+)
+
+// iscsiNodes are the directories in which open-iscsi stores node
+// records, depending on how it was built.
+var iscsiNodes = []string{"/etc/iscsi/nodes", "/var/lib/iscsi/nodes"}
+
+// iscsiCHAP returns the CHAP credentials from the secrets of a CSI
+// NodeStageVolumeRequest, nil if there are none.
+func iscsiCHAP(request interface{}) *oim.ISCSICHAP {
+	var secrets map[string]string
+	switch r := request.(type) {
+	case *csi.NodeStageVolumeRequest:
+		secrets = r.GetSecrets()
+	case *csi0.NodeStageVolumeRequest:
+		secrets = r.GetNodeStageSecrets()
+	}
+	user := secrets[iscsiCHAPUser]
+	if user == "" {
+		return nil
+	}
+	return &oim.ISCSICHAP{
+		User:   user,
+		Secret: secrets[iscsiCHAPSecret],
+	}
+}
+
+// runISCSIAdm invokes iscsiadm. The listed exit codes are treated as
+// success. Secrets must never be passed as arguments because those
+// are visible to other processes.
+func runISCSIAdm(ctx context.Context, cmd string, okCodes []int, args ...string) error {
+	log.FromContext(ctx).Debugw("running", "cmd", cmd, "args", args)
+	output, err := exec.CommandContext(ctx, cmd, args...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			for _, code := range okCodes {
+				if ws.ExitStatus() == code {
+					return nil
+				}
+			}
+		}
+	}
+	return errors.Wrapf(err, "%s %s: %s", cmd, strings.Join(args, " "), strings.TrimSpace(string(output)))
+}
+
+// loginISCSI creates the node record for the target and logs into it
+// with the initiator name, unless there already is a session. The
+// node records are stored in one of the directories.
+func loginISCSI(ctx context.Context, cmd string, nodes []string, initiator string, disk *oim.ISCSIDisk, chap *oim.ISCSICHAP) error {
+	node := []string{"-m", "node", "-T", disk.GetIqn(), "-p", disk.GetPortal()}
+	update := func(name, value string) error {
+		return runISCSIAdm(ctx, cmd, nil, append(node, "-o", "update", "-n", name, "-v", value)...)
+	}
+
+	// A static node record avoids the discovery, which would need
+	// its own authentication.
+	if err := runISCSIAdm(ctx, cmd, nil, append(node, "-o", "new")...); err != nil {
+		return err
+	}
+	if initiator != "" {
+		if err := update("iface.initiatorname", initiator); err != nil {
+			return err
+		}
+	}
+	if chap != nil {
+		if err := update("node.session.auth.authmethod", "CHAP"); err != nil {
+			return err
+		}
+		if err := update(iscsiCHAPUser, chap.GetUser()); err != nil {
+			return err
+		}
+		if err := writeISCSISecret(nodes, disk, chap.GetSecret()); err != nil {
+			return err
+		}
+	} else if err := update("node.session.auth.authmethod", "None"); err != nil {
+		return err
+	}
+	log.FromContext(ctx).Infow("logging in", "iqn", disk.GetIqn(), "portal", disk.GetPortal(), "chap", chap != nil)
+	return runISCSIAdm(ctx, cmd, []int{iscsiErrSessExists}, append(node, "--login")...)
+}
+
+// writeISCSISecret stores the CHAP secret directly in the node
+// records of the target at the portal. Passing it to iscsiadm on the
+// command line would make it visible to everyone who can read
+// /proc/<pid>/cmdline.
+func writeISCSISecret(nodes []string, disk *oim.ISCSIDisk, secret string) error {
+	host, port, err := net.SplitHostPort(disk.GetPortal())
+	if err != nil {
+		return errors.Wrap(err, "iSCSI portal")
+	}
+	// <nodes>/<IQN>/<address>,<port>,<portal group tag>/<interface>
+	var records []string
+	for _, dir := range nodes {
+		matches, err := filepath.Glob(filepath.Join(dir, disk.GetIqn(), host+","+port+",*", "*"))
+		if err != nil {
+			return err
+		}
+		records = append(records, matches...)
+	}
+	if len(records) == 0 {
+		return errors.Errorf("no node record for iSCSI target %s at %s in %s", disk.GetIqn(), disk.GetPortal(), strings.Join(nodes, ", "))
+	}
+	for _, record := range records {
+		if err := setISCSIRecordValue(record, iscsiCHAPSecret, secret); err != nil {
+			return errors.Wrapf(err, "set CHAP secret in %s", record)
+		}
+	}
+	return nil
+}
+
+// setISCSIRecordValue replaces or adds a "<name> = <value>" line in a
+// node record file. The file gets replaced atomically and is only
+// readable by the owner.
+func setISCSIRecordValue(record, name, value string) error {
+	data, err := ioutil.ReadFile(record)
+	if err != nil {
+		return err
+	}
+	entry := name + " = " + value
+	var lines []string
+	found := false
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, name+" ="):
+			if found {
+				continue
+			}
+			line = entry
+			found = true
+		case line == "# END RECORD" && !found:
+			lines = append(lines, entry)
+			found = true
+		}
+		lines = append(lines, line)
+	}
+	if !found {
+		lines = append(lines, entry)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(record), filepath.Base(record))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write([]byte(strings.Join(lines, "\n") + "\n")); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), record)
+}
+
+// logoutISCSI logs out of all sessions with the target and removes its
+// node records. Not being logged in is not an error.
+func logoutISCSI(ctx context.Context, cmd, iqn string) error {
+	if _, err := exec.LookPath(cmd); err != nil {
+		// Without iscsiadm we cannot have logged in.
+		return nil
+	}
+	node := []string{"-m", "node", "-T", iqn}
+	log.FromContext(ctx).Infow("logging out", "iqn", iqn)
+	if err := runISCSIAdm(ctx, cmd, []int{iscsiErrNoObjsFound}, append(node, "--logout")...); err != nil {
+		return err
+	}
+	return runISCSIAdm(ctx, cmd, []int{iscsiErrNoObjsFound}, append(node, "-o", "delete")...)
+}
+
+// findISCSIDev looks for the block device of the LUN in all sessions
+// with the target. The SCSI devices are below the session:
+// session1/device/target2:0:0/2:0:0:<lun>/block/sdb
+func findISCSIDev(ctx context.Context, sys, iqn string, lun uint32) (string, int, int, error) {
+	entries, err := ioutil.ReadDir(sys)
+	if os.IsNotExist(err) {
+		// No iSCSI support loaded, so also no sessions.
+		return "", 0, 0, nil
+	}
+	if err != nil {
+		return "", 0, 0, err
+	}
+	for _, entry := range entries {
+		data, err := ioutil.ReadFile(filepath.Join(sys, entry.Name(), "targetname"))
+		if err != nil || strings.TrimSpace(string(data)) != iqn {
+			continue
+		}
+		blocks, err := filepath.Glob(filepath.Join(sys, entry.Name(), "device", "target*", "*:*:*:"+strconv.FormatUint(uint64(lun), 10), "block", "*"))
+		if err != nil {
+			return "", 0, 0, err
+		}
+		for _, block := range blocks {
+			data, err := ioutil.ReadFile(filepath.Join(block, "dev"))
+			if err != nil {
+				return "", 0, 0, err
+			}
+			parts := majorMinor.FindStringSubmatch(strings.TrimSpace(string(data)))
+			if parts == nil {
+				return "", 0, 0, errors.Errorf("unexpected content in %s/dev, not major:minor: %q", block, string(data))
+			}
+			dev := filepath.Base(block)
+			log.FromContext(ctx).Debugw("found block device",
+				"iqn", iqn,
+				"lun", lun,
+				"session", entry.Name(),
+				"dev", dev,
+			)
+			// The regex has already ensured that we have a valid integer.
+			// nolint: gosec
+			major, _ := strconv.Atoi(parts[1])
+			minor, _ := strconv.Atoi(parts[2])
+			return dev, major, minor, nil
+		}
+	}
+	return "", 0, 0, nil
+}
+
+// attachISCSI logs into the target and waits for the block device of
+// the LUN. Like attachNVMeoF, this has to poll.
+func attachISCSI(ctx context.Context, cmd, sys string, nodes []string, initiator string, disk *oim.ISCSIDisk, chap *oim.ISCSICHAP) (string, int, int, error) {
+	if err := loginISCSI(ctx, cmd, nodes, initiator, disk, chap); err != nil {
+		return "", 0, 0, err
+	}
+	log.FromContext(ctx).Infow("waiting for block device",
+		"sys", sys,
+		"iqn", disk.GetIqn(),
+		"lun", disk.GetLun(),
+	)
+	for {
+		dev, major, minor, err := findISCSIDev(ctx, sys, disk.GetIqn(), disk.GetLun())
+		if err != nil {
+			return "", 0, 0, status.Error(codes.Internal, err.Error())
+		}
+		if dev != "" {
+			return dev, major, minor, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", 0, 0, status.Errorf(codes.DeadlineExceeded, "timed out waiting for LUN %d of iSCSI target %s",
+				disk.GetLun(), disk.GetIqn())
+		case <-time.After(time.Second):
+		}
+	}
+}
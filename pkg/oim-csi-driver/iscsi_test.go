@@ -0,0 +1,209 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcsidriver
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/intel/oim/pkg/log/testlog"
+	csi0 "github.com/intel/oim/pkg/spec/csi/v0"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+func TestISCSICHAP(t *testing.T) {
+	secrets := map[string]string{
+		"node.session.auth.username": "user",
+		"node.session.auth.password": "secret",
+	}
+	expected := &oim.ISCSICHAP{User: "user", Secret: "secret"}
+	assert.Equal(t, expected, iscsiCHAP(&csi.NodeStageVolumeRequest{Secrets: secrets}))
+	assert.Equal(t, expected, iscsiCHAP(&csi0.NodeStageVolumeRequest{NodeStageSecrets: secrets}))
+	assert.Nil(t, iscsiCHAP(&csi.NodeStageVolumeRequest{}))
+	assert.Nil(t, iscsiCHAP(nil))
+}
+
+// fakeISCSIAdm creates a script which records its arguments in a log
+// file and exits with the code stored in another file.
+func fakeISCSIAdm(t *testing.T, dir string) (cmd string, exitCode func(int), invocations func() []string) {
+	cmd = filepath.Join(dir, "iscsiadm")
+	logFile := filepath.Join(dir, "iscsiadm.log")
+	codeFile := filepath.Join(dir, "iscsiadm.exit")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" >>%s\nexit $(cat %s)\n", logFile, codeFile)
+	require.NoError(t, ioutil.WriteFile(cmd, []byte(script), 0755))
+	exitCode = func(code int) {
+		require.NoError(t, ioutil.WriteFile(codeFile, []byte(fmt.Sprintf("%d\n", code)), 0644))
+	}
+	exitCode(0)
+	invocations = func() []string {
+		data, err := ioutil.ReadFile(logFile)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		require.NoError(t, err)
+		os.Remove(logFile)
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	return
+}
+
+func TestISCSI(t *testing.T) {
+	defer testlog.SetGlobal(t)()
+	ctx := context.Background()
+
+	tmp, err := ioutil.TempDir("", "iscsi")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	sys := filepath.Join(tmp, "class/iscsi_session")
+	cmd, exitCode, invocations := fakeISCSIAdm(t, tmp)
+	iqn := "iqn.2018-09.io.oim:host-0:vol-1"
+	disk := &oim.ISCSIDisk{
+		Iqn:    iqn,
+		Portal: "192.168.7.1:3260",
+		Lun:    0,
+	}
+	chap := &oim.ISCSICHAP{User: "user", Secret: "0123456789ab"}
+	initiator := "iqn.2018-09.io.oim:host:host-0"
+	node := "-m node -T " + iqn + " -p 192.168.7.1:3260"
+	nodes := []string{filepath.Join(tmp, "etc/iscsi/nodes"), filepath.Join(tmp, "var/lib/iscsi/nodes")}
+	record := filepath.Join(nodes[1], iqn, "192.168.7.1,3260,-1", "default")
+
+	// The secret must go into the node record, which iscsiadm
+	// would have created.
+	err = loginISCSI(ctx, cmd, nodes, initiator, disk, chap)
+	assert.Error(t, err, "no node record")
+	invocations()
+	writeSysfs(t, tmp, map[string]string{
+		"var/lib/iscsi/nodes/" + iqn + "/192.168.7.1,3260,-1/default": "# BEGIN RECORD 2.0-874\nnode.name = " + iqn + "\n# END RECORD\n",
+	})
+
+	// Logging in with CHAP.
+	err = loginISCSI(ctx, cmd, nodes, initiator, disk, chap)
+	assert.NoError(t, err)
+	calls := invocations()
+	assert.Equal(t, []string{
+		node + " -o new",
+		node + " -o update -n iface.initiatorname -v " + initiator,
+		node + " -o update -n node.session.auth.authmethod -v CHAP",
+		node + " -o update -n node.session.auth.username -v user",
+		node + " --login",
+	}, calls)
+	for _, call := range calls {
+		assert.NotContains(t, call, chap.Secret)
+	}
+	data, err := ioutil.ReadFile(record)
+	require.NoError(t, err)
+	assert.Equal(t, "# BEGIN RECORD 2.0-874\nnode.name = "+iqn+"\nnode.session.auth.password = 0123456789ab\n# END RECORD\n", string(data))
+	fi, err := os.Stat(record)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	// Logging in again replaces the secret.
+	chap.Secret = "ba9876543210"
+	err = loginISCSI(ctx, cmd, nodes, initiator, disk, chap)
+	assert.NoError(t, err)
+	invocations()
+	data, err = ioutil.ReadFile(record)
+	require.NoError(t, err)
+	assert.Equal(t, "# BEGIN RECORD 2.0-874\nnode.name = "+iqn+"\nnode.session.auth.password = ba9876543210\n# END RECORD\n", string(data))
+
+	// Without CHAP and initiator name.
+	err = loginISCSI(ctx, cmd, nodes, "", disk, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		node + " -o new",
+		node + " -o update -n node.session.auth.authmethod -v None",
+		node + " --login",
+	}, invocations())
+
+	// Failures include the command.
+	exitCode(1)
+	err = runISCSIAdm(ctx, cmd, nil, "--login")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "--login")
+	}
+	invocations()
+	exitCode(iscsiErrSessExists)
+	err = runISCSIAdm(ctx, cmd, []int{iscsiErrSessExists}, "--login")
+	assert.NoError(t, err, "already logged in")
+	invocations()
+
+	// Logging out tolerates missing sessions and records.
+	exitCode(iscsiErrNoObjsFound)
+	err = logoutISCSI(ctx, cmd, iqn)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"-m node -T " + iqn + " --logout",
+		"-m node -T " + iqn + " -o delete",
+	}, invocations())
+	assert.NoError(t, logoutISCSI(ctx, filepath.Join(tmp, "no-such-iscsiadm"), iqn), "without iscsiadm")
+
+	// Nothing there yet, in particular no iSCSI support.
+	dev, _, _, err := findISCSIDev(ctx, sys, iqn, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "", dev)
+
+	// One session for a different target and one for ours.
+	writeSysfs(t, tmp, map[string]string{
+		"devices/platform/host2/session1/iscsi_session/session1/targetname": "iqn.2018-09.io.oim:host-0:other\n",
+		"devices/platform/host2/session1/target2:0:0/2:0:0:0/block/sdb/dev": "8:16\n",
+		"devices/platform/host3/session2/iscsi_session/session2/targetname": iqn + "\n",
+		"devices/platform/host3/session2/target3:0:0/3:0:0:1/block/sdc/dev": "8:32\n",
+		"devices/platform/host2/session1/iscsi_session/session1/device":     "->../../../session1",
+		"devices/platform/host3/session2/iscsi_session/session2/device":     "->../../../session2",
+		"class/iscsi_session/session1":                                      "->../../devices/platform/host2/session1/iscsi_session/session1",
+		"class/iscsi_session/session2":                                      "->../../devices/platform/host3/session2/iscsi_session/session2",
+	})
+
+	// Wrong LUN.
+	dev, _, _, err = findISCSIDev(ctx, sys, iqn, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "", dev)
+
+	// Find sdc.
+	dev, major, minor, err := findISCSIDev(ctx, sys, iqn, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "sdc", dev)
+	assert.Equal(t, 8, major)
+	assert.Equal(t, 32, minor)
+
+	// Waiting finds the device once it appears.
+	exitCode(0)
+	disk.Lun = 2
+	timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	timer := time.AfterFunc(2*time.Second, func() {
+		writeSysfs(t, tmp, map[string]string{
+			"devices/platform/host3/session2/target3:0:0/3:0:0:2/block/sdd/dev": "8:48\n",
+		})
+	})
+	defer timer.Stop()
+	dev, major, minor, err = attachISCSI(timeout, cmd, sys, nodes, initiator, disk, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "sdd", dev)
+	assert.Equal(t, 8, major)
+	assert.Equal(t, 48, minor)
+
+	// Timeout aborts waiting.
+	disk.Lun = 3
+	timeout2, cancel2 := context.WithTimeout(ctx, time.Second)
+	defer cancel2()
+	_, _, _, err = attachISCSI(timeout2, cmd, sys, nodes, initiator, disk, nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, "rpc error: code = DeadlineExceeded desc = timed out waiting for LUN 3 of iSCSI target "+iqn, err.Error())
+	}
+}
@@ -116,7 +116,17 @@ func (l *localSPDK) checkVolumeExists(ctx context.Context, volumeID string) erro
 	return status.Error(codes.NotFound, "")
 }
 
-func (l *localSPDK) createDevice(ctx context.Context, volumeID string, request interface{}) (string, cleanup, error) {
+func (l *localSPDK) checkHealth(ctx context.Context) error {
+	client, err := spdk.New(l.vhostEndpoint)
+	if err != nil {
+		return errors.Wrap(err, "connect to SPDK")
+	}
+	defer client.Close()
+	_, err = spdk.GetBDevs(ctx, client, spdk.GetBDevsArgs{})
+	return errors.Wrap(err, "SPDK")
+}
+
+func (l *localSPDK) createDevice(ctx context.Context, volumeID, stagingTargetPath string, request interface{}) (string, cleanup, error) {
 	// Connect to SPDK.
 	client, err := spdk.New(l.vhostEndpoint)
 	if err != nil {
@@ -185,7 +195,7 @@ func (l *localSPDK) createDevice(ctx context.Context, volumeID string, request i
 	return nbdDevice, nil, nil
 }
 
-func (l *localSPDK) deleteDevice(ctx context.Context, volumeID string) error {
+func (l *localSPDK) deleteDevice(ctx context.Context, volumeID, stagingTargetPath string) error {
 	// Connect to SPDK.
 	client, err := spdk.New(l.vhostEndpoint)
 	if err != nil {
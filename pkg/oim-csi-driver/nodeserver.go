@@ -192,7 +192,7 @@ func (od *oimDriver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolu
 		"flags", mountFlags,
 	)
 
-	device, cleanup, err := od.backend.createDevice(ctx, volumeID, req)
+	device, cleanup, err := od.backend.createDevice(ctx, volumeID, targetPath, req)
 	if cleanup != nil {
 		defer cleanup()
 	}
@@ -235,7 +235,7 @@ func (od *oimDriver) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstage
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	if err := od.backend.deleteDevice(ctx, volumeID); err != nil {
+	if err := od.backend.deleteDevice(ctx, volumeID, targetPath); err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
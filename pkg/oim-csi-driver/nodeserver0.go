@@ -201,7 +201,7 @@ func (od *oimDriver03) NodeStageVolume(ctx context.Context, req *csi.NodeStageVo
 		"flags", mountFlags,
 	)
 
-	device, cleanup, err := od.backend.createDevice(ctx, volumeID, req)
+	device, cleanup, err := od.backend.createDevice(ctx, volumeID, targetPath, req)
 	if cleanup != nil {
 		defer cleanup()
 	}
@@ -244,7 +244,7 @@ func (od *oimDriver03) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnsta
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	if err := od.backend.deleteDevice(ctx, volumeID); err != nil {
+	if err := od.backend.deleteDevice(ctx, volumeID, targetPath); err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
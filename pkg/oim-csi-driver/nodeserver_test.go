@@ -35,7 +35,7 @@ func TestFindDev(t *testing.T) {
 	defer os.RemoveAll(tmp)
 
 	// Nothing in empty dir.
-	dev, _, _, err = findDev(ctx, tmp, &oim.PCIAddress{}, &oim.SCSIDisk{})
+	dev, _, _, err = findDev(ctx, tmp, &oim.PCIAddress{}, &oim.SCSIDisk{}, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, "", dev)
 
@@ -66,7 +66,7 @@ func TestFindDev(t *testing.T) {
 		err = os.Symlink(to, filepath.Join(tmp, from))
 		require.NoError(t, err)
 	}
-	dev, _, _, err = findDev(ctx, tmp, &oim.PCIAddress{}, &oim.SCSIDisk{})
+	dev, _, _, err = findDev(ctx, tmp, &oim.PCIAddress{}, &oim.SCSIDisk{}, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, "", dev)
 
@@ -78,6 +78,7 @@ func TestFindDev(t *testing.T) {
 		&oim.SCSIDisk{
 			Target: 5,
 		},
+		nil,
 	)
 	assert.NoError(t, err)
 	assert.Equal(t, "", dev)
@@ -88,6 +89,7 @@ func TestFindDev(t *testing.T) {
 			Device: 0x17,
 		},
 		&oim.SCSIDisk{},
+		nil,
 	)
 	assert.NoError(t, err)
 	assert.Equal(t, "sda", dev)
@@ -100,6 +102,7 @@ func TestFindDev(t *testing.T) {
 			Device: 0x18,
 		},
 		nil,
+		nil,
 	)
 	assert.NoError(t, err)
 	assert.Equal(t, "", dev)
@@ -110,6 +113,7 @@ func TestFindDev(t *testing.T) {
 			Device: 0x17,
 		},
 		nil,
+		nil,
 	)
 	assert.NoError(t, err)
 	assert.Equal(t, "sda", dev)
@@ -122,6 +126,7 @@ func TestFindDev(t *testing.T) {
 			Device: 0x17,
 		},
 		&oim.SCSIDisk{},
+		nil,
 	)
 	assert.NoError(t, err)
 	assert.Equal(t, "sda", dev)
@@ -138,6 +143,7 @@ func TestFindDev(t *testing.T) {
 		&oim.SCSIDisk{
 			Target: 1,
 		},
+		nil,
 	)
 	assert.Error(t, err)
 	assert.Equal(t, "rpc error: code = DeadlineExceeded desc = timed out waiting for device 0000:00:17.0, SCSI disk 'target:1 '", err.Error())
@@ -157,12 +163,52 @@ func TestFindDev(t *testing.T) {
 		&oim.SCSIDisk{
 			Target: 1,
 		},
+		nil,
 	)
 	assert.NoError(t, err)
 	assert.Equal(t, "sdc", dev)
 	assert.Equal(t, major, 9)
 	assert.Equal(t, minor, 0)
 
+	// A virtio-blk device is identified by its PCI address alone.
+	err = os.Symlink("../../devices/pci0000:00/0000:00:16.0/virtio4/block/vda", filepath.Join(tmp, "252:0"))
+	require.NoError(t, err)
+	err = os.Symlink("../../devices/pci0000:00/0000:00:16.0/virtio4/block/vda/vda1", filepath.Join(tmp, "252:1"))
+	require.NoError(t, err)
+	dev, major, minor, err = findDev(ctx, tmp,
+		&oim.PCIAddress{
+			Device: 0x16,
+		},
+		nil,
+		&oim.BlkDisk{},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "vda", dev)
+	assert.Equal(t, major, 252)
+	assert.Equal(t, minor, 0)
+
+	// It is not a SCSI disk.
+	dev, _, _, err = findDev(ctx, tmp,
+		&oim.PCIAddress{
+			Device: 0x16,
+		},
+		&oim.SCSIDisk{},
+		nil,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "", dev)
+
+	// And sda is not a virtio-blk disk.
+	dev, _, _, err = findDev(ctx, tmp,
+		&oim.PCIAddress{
+			Device: 0x17,
+		},
+		nil,
+		&oim.BlkDisk{},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "", dev)
+
 	// Broken entry.
 	err = os.Symlink("../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:1/0:0:2:0/block/sdd", filepath.Join(tmp, "a:b"))
 	require.NoError(t, err)
@@ -173,6 +219,7 @@ func TestFindDev(t *testing.T) {
 		&oim.SCSIDisk{
 			Target: 2,
 		},
+		nil,
 	)
 	if assert.Error(t, err) {
 		assert.Equal(t, fmt.Sprintf("Unexpected entry in %s, not a major:minor symlink: a:b", tmp), err.Error())
@@ -0,0 +1,195 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcsidriver
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/intel/oim/pkg/log"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+const (
+	// nvmeFabrics is the kernel interface for connecting to
+	// NVMe-oF subsystems. It is provided by the nvme-fabrics
+	// kernel module, the TCP transport by nvme-tcp.
+	nvmeFabrics = "/dev/nvme-fabrics"
+	// sysNVMe has one entry per NVMe controller.
+	sysNVMe = "/sys/class/nvme"
+	// sysBlock has one entry per block device.
+	sysBlock = "/sys/block"
+)
+
+// nvmeNamespaceRe matches the block devices of NVMe namespaces, but
+// not the hidden per-path devices (nvme0c1n1) used for multipathing.
+var nvmeNamespaceRe = regexp.MustCompile(`^nvme\d+n\d+$`)
+
+// nvmeControllers returns the names of all NVMe controllers which are
+// connected to the subsystem.
+func nvmeControllers(sys, nqn string) ([]string, error) {
+	entries, err := ioutil.ReadDir(sys)
+	if os.IsNotExist(err) {
+		// No NVMe support loaded, so also not connected.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var controllers []string
+	for _, entry := range entries {
+		data, err := ioutil.ReadFile(filepath.Join(sys, entry.Name(), "subsysnqn"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(data)) == nqn {
+			controllers = append(controllers, entry.Name())
+		}
+	}
+	return controllers, nil
+}
+
+// connectNVMe connects the kernel's NVMe-oF initiator to the
+// subsystem, unless it is already connected. The host NQN is
+// what the target checks when it only allows certain hosts.
+func connectNVMe(ctx context.Context, fabrics, sys, hostNQN string, disk *oim.NVMeoFDisk) error {
+	controllers, err := nvmeControllers(sys, disk.GetNqn())
+	if err != nil {
+		return errors.Wrap(err, "find NVMe controllers")
+	}
+	if len(controllers) > 0 {
+		log.FromContext(ctx).Infow("already connected", "nqn", disk.GetNqn(), "controllers", controllers)
+		return nil
+	}
+
+	options := fmt.Sprintf("nqn=%s,transport=%s,traddr=%s,trsvcid=%s",
+		disk.GetNqn(), disk.GetTransport(), disk.GetAddress(), disk.GetPort())
+	if hostNQN != "" {
+		options += ",hostnqn=" + hostNQN
+	}
+	f, err := os.OpenFile(fabrics, os.O_RDWR, 0)
+	if err != nil {
+		return errors.Wrap(err, "the nvme-fabrics and nvme-tcp kernel modules must be loaded")
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte(options)); err != nil {
+		return errors.Wrapf(err, "connect to NVMe-oF subsystem %s at %s:%s", disk.GetNqn(), disk.GetAddress(), disk.GetPort())
+	}
+	// The kernel describes the new controller as
+	// "instance=<n>,cntlid=<id>". Only used for logging.
+	buffer := make([]byte, 256)
+	n, _ := f.Read(buffer)
+	log.FromContext(ctx).Infow("connected", "nqn", disk.GetNqn(), "controller", strings.TrimSpace(string(buffer[:n])))
+	return nil
+}
+
+// disconnectNVMe removes all NVMe controllers which are connected to
+// the subsystem. Not being connected is not an error.
+func disconnectNVMe(ctx context.Context, sys, nqn string) error {
+	controllers, err := nvmeControllers(sys, nqn)
+	if err != nil {
+		return errors.Wrap(err, "find NVMe controllers")
+	}
+	for _, controller := range controllers {
+		log.FromContext(ctx).Infow("disconnecting", "nqn", nqn, "controller", controller)
+		if err := ioutil.WriteFile(filepath.Join(sys, controller, "delete_controller"), []byte("1"), 0); err != nil {
+			return errors.Wrapf(err, "disconnect NVMe controller %s", controller)
+		}
+	}
+	return nil
+}
+
+// findNVMeDev looks for the block device of the namespace in the
+// subsystem. The subsysnqn attribute is in the parent of the
+// namespace, which is either the controller or, with native
+// multipathing, the subsystem.
+func findNVMeDev(ctx context.Context, sys, nqn string, nsid uint32) (string, int, int, error) {
+	entries, err := ioutil.ReadDir(sys)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	for _, entry := range entries {
+		if !nvmeNamespaceRe.MatchString(entry.Name()) {
+			continue
+		}
+		dir := filepath.Join(sys, entry.Name())
+		data, err := ioutil.ReadFile(filepath.Join(dir, "nsid"))
+		if err != nil || strings.TrimSpace(string(data)) != strconv.FormatUint(uint64(nsid), 10) {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		data, err = ioutil.ReadFile(filepath.Join(filepath.Dir(resolved), "subsysnqn"))
+		if err != nil || strings.TrimSpace(string(data)) != nqn {
+			continue
+		}
+		data, err = ioutil.ReadFile(filepath.Join(dir, "dev"))
+		if err != nil {
+			return "", 0, 0, err
+		}
+		parts := majorMinor.FindStringSubmatch(strings.TrimSpace(string(data)))
+		if parts == nil {
+			return "", 0, 0, errors.Errorf("unexpected content in %s/dev, not major:minor: %q", dir, string(data))
+		}
+		log.FromContext(ctx).Debugw("found block device",
+			"nqn", nqn,
+			"nsid", nsid,
+			"dev", entry.Name(),
+		)
+		// The regex has already ensured that we have a valid integer.
+		// nolint: gosec
+		major, _ := strconv.Atoi(parts[1])
+		minor, _ := strconv.Atoi(parts[2])
+		return entry.Name(), major, minor, nil
+	}
+	return "", 0, 0, nil
+}
+
+// attachNVMeoF connects to the subsystem and waits for the block
+// device of the namespace. sysfs does not support inotify, so this
+// has to poll.
+func attachNVMeoF(ctx context.Context, fabrics, sysNVMe, sysBlock, hostNQN string, disk *oim.NVMeoFDisk) (string, int, int, error) {
+	if err := connectNVMe(ctx, fabrics, sysNVMe, hostNQN, disk); err != nil {
+		return "", 0, 0, err
+	}
+	log.FromContext(ctx).Infow("waiting for block device",
+		"sys", sysBlock,
+		"nqn", disk.GetNqn(),
+		"nsid", disk.GetNamespaceId(),
+	)
+	for {
+		dev, major, minor, err := findNVMeDev(ctx, sysBlock, disk.GetNqn(), disk.GetNamespaceId())
+		if err != nil {
+			return "", 0, 0, status.Error(codes.Internal, err.Error())
+		}
+		if dev != "" {
+			return dev, major, minor, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", 0, 0, status.Errorf(codes.DeadlineExceeded, "timed out waiting for namespace %d of NVMe-oF subsystem %s",
+				disk.GetNamespaceId(), disk.GetNqn())
+		case <-time.After(time.Second):
+		}
+	}
+}
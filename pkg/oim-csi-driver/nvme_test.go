@@ -0,0 +1,163 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcsidriver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/intel/oim/pkg/log/testlog"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+// writeSysfs creates files with the given content and symlinks (when
+// the content starts with "->") below the directory.
+func writeSysfs(t *testing.T, dir string, entries map[string]string) {
+	for path, content := range entries {
+		fullpath := filepath.Join(dir, path)
+		err := os.MkdirAll(filepath.Dir(fullpath), 0755)
+		require.NoError(t, err)
+		if len(content) > 2 && content[0:2] == "->" {
+			err = os.Symlink(content[2:], fullpath)
+		} else {
+			err = ioutil.WriteFile(fullpath, []byte(content), 0644)
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestNVMe(t *testing.T) {
+	defer testlog.SetGlobal(t)()
+	ctx := context.Background()
+
+	tmp, err := ioutil.TempDir("", "nvme")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	sysNVMe := filepath.Join(tmp, "class/nvme")
+	sysBlock := filepath.Join(tmp, "block")
+	fabrics := filepath.Join(tmp, "nvme-fabrics")
+	nqn := "nqn.2018-09.io.oim:host-0:vol-1"
+	hostNQN := "nqn.2018-09.io.oim:host:host-0"
+	disk := &oim.NVMeoFDisk{
+		Nqn:         nqn,
+		Transport:   "tcp",
+		Address:     "192.168.7.1",
+		Port:        "4420",
+		NamespaceId: 1,
+	}
+
+	// Nothing there yet, in particular no NVMe support.
+	controllers, err := nvmeControllers(sysNVMe, nqn)
+	assert.NoError(t, err)
+	assert.Empty(t, controllers)
+	assert.NoError(t, disconnectNVMe(ctx, sysNVMe, nqn))
+	err = connectNVMe(ctx, fabrics, sysNVMe, hostNQN, disk)
+	assert.Error(t, err, "missing nvme-fabrics")
+
+	// Connecting writes the options.
+	require.NoError(t, ioutil.WriteFile(fabrics, nil, 0644))
+	err = connectNVMe(ctx, fabrics, sysNVMe, hostNQN, disk)
+	assert.NoError(t, err)
+	data, err := ioutil.ReadFile(fabrics)
+	require.NoError(t, err)
+	assert.Equal(t, "nqn="+nqn+",transport=tcp,traddr=192.168.7.1,trsvcid=4420,hostnqn="+hostNQN, string(data))
+
+	// One controller for a different subsystem and one for ours,
+	// both without multipathing.
+	writeSysfs(t, tmp, map[string]string{
+		"devices/virtual/nvme-fabrics/ctl/nvme0/subsysnqn":         "nqn.2018-09.io.oim:host-0:other\n",
+		"devices/virtual/nvme-fabrics/ctl/nvme0/nvme0n1/nsid":      "1\n",
+		"devices/virtual/nvme-fabrics/ctl/nvme0/nvme0n1/dev":       "259:0\n",
+		"devices/virtual/nvme-fabrics/ctl/nvme1/subsysnqn":         nqn + "\n",
+		"devices/virtual/nvme-fabrics/ctl/nvme1/delete_controller": "",
+		"devices/virtual/nvme-fabrics/ctl/nvme1/nvme1n2/nsid":      "2\n",
+		"devices/virtual/nvme-fabrics/ctl/nvme1/nvme1n2/dev":       "259:1\n",
+		"class/nvme/nvme0": "->../../devices/virtual/nvme-fabrics/ctl/nvme0",
+		"class/nvme/nvme1": "->../../devices/virtual/nvme-fabrics/ctl/nvme1",
+		"block/nvme0n1":    "->../devices/virtual/nvme-fabrics/ctl/nvme0/nvme0n1",
+		"block/nvme1n2":    "->../devices/virtual/nvme-fabrics/ctl/nvme1/nvme1n2",
+	})
+	controllers, err = nvmeControllers(sysNVMe, nqn)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"nvme1"}, controllers)
+
+	// Already connected, so nothing gets written.
+	require.NoError(t, ioutil.WriteFile(fabrics, nil, 0644))
+	err = connectNVMe(ctx, fabrics, sysNVMe, hostNQN, disk)
+	assert.NoError(t, err)
+	data, err = ioutil.ReadFile(fabrics)
+	require.NoError(t, err)
+	assert.Equal(t, "", string(data))
+
+	// Wrong namespace ID.
+	dev, _, _, err := findNVMeDev(ctx, sysBlock, nqn, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "", dev)
+
+	// Find nvme1n2.
+	dev, major, minor, err := findNVMeDev(ctx, sysBlock, nqn, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "nvme1n2", dev)
+	assert.Equal(t, 259, major)
+	assert.Equal(t, 1, minor)
+
+	// With native multipathing the namespace is below the
+	// subsystem.
+	nqn2 := "nqn.2018-09.io.oim:host-0:vol-2"
+	writeSysfs(t, tmp, map[string]string{
+		"devices/virtual/nvme-subsystem/nvme-subsys2/subsysnqn":    nqn2 + "\n",
+		"devices/virtual/nvme-subsystem/nvme-subsys2/nvme2n1/nsid": "1\n",
+		"devices/virtual/nvme-subsystem/nvme-subsys2/nvme2n1/dev":  "259:2\n",
+		"block/nvme2n1": "->../devices/virtual/nvme-subsystem/nvme-subsys2/nvme2n1",
+	})
+	dev, major, minor, err = findNVMeDev(ctx, sysBlock, nqn2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "nvme2n1", dev)
+	assert.Equal(t, 259, major)
+	assert.Equal(t, 2, minor)
+
+	// Waiting finds the device once it appears.
+	disk.NamespaceId = 3
+	timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	timer := time.AfterFunc(2*time.Second, func() {
+		writeSysfs(t, tmp, map[string]string{
+			"devices/virtual/nvme-fabrics/ctl/nvme1/nvme1n3/nsid": "3\n",
+			"devices/virtual/nvme-fabrics/ctl/nvme1/nvme1n3/dev":  "259:3\n",
+			"block/nvme1n3": "->../devices/virtual/nvme-fabrics/ctl/nvme1/nvme1n3",
+		})
+	})
+	defer timer.Stop()
+	dev, major, minor, err = attachNVMeoF(timeout, fabrics, sysNVMe, sysBlock, hostNQN, disk)
+	assert.NoError(t, err)
+	assert.Equal(t, "nvme1n3", dev)
+	assert.Equal(t, 259, major)
+	assert.Equal(t, 3, minor)
+
+	// Timeout aborts waiting.
+	disk.NamespaceId = 4
+	timeout2, cancel2 := context.WithTimeout(ctx, time.Second)
+	defer cancel2()
+	_, _, _, err = attachNVMeoF(timeout2, fabrics, sysNVMe, sysBlock, hostNQN, disk)
+	if assert.Error(t, err) {
+		assert.Equal(t, "rpc error: code = DeadlineExceeded desc = timed out waiting for namespace 4 of NVMe-oF subsystem "+nqn, err.Error())
+	}
+
+	// Disconnecting writes to delete_controller.
+	err = disconnectNVMe(ctx, sysNVMe, nqn)
+	assert.NoError(t, err)
+	data, err = ioutil.ReadFile(filepath.Join(sysNVMe, "nvme1", "delete_controller"))
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(data))
+}
@@ -73,8 +73,14 @@ type OIMBackend interface {
 	deleteVolume(ctx context.Context, volumeID string) error
 	checkVolumeExists(ctx context.Context, volumeID string) error
 
-	createDevice(ctx context.Context, volumeID string, request interface{}) (string, cleanup, error)
-	deleteDevice(ctx context.Context, volumeID string) error
+	// createDevice and deleteDevice get the staging target path
+	// of the volume. The backend may keep information about the
+	// device next to it.
+	createDevice(ctx context.Context, volumeID, stagingTargetPath string, request interface{}) (string, cleanup, error)
+	deleteDevice(ctx context.Context, volumeID, stagingTargetPath string) error
+
+	// checkHealth returns an error if the backend cannot be used.
+	checkHealth(ctx context.Context) error
 }
 
 // EmulateCSI0Driver deals with parameters meant for some other CSI v0.3 driver.
@@ -275,7 +281,16 @@ func New(options ...Option) (Driver, error) {
 func (od *oimDriver03) Start(ctx context.Context) (*oimcommon.NonBlockingGRPCServer, error) {
 	s := oimcommon.NonBlockingGRPCServer{
 		Endpoint: od.csiEndpoint,
+		Health:   oimcommon.NewHealthServer(),
 	}
+	// Node and controller operations depend on the backend.
+	prefix := "csi.v1."
+	if od.csiVersion == csi03 {
+		prefix = "csi.v0."
+	}
+	s.Health.AddCheck(prefix+"Identity", nil)
+	s.Health.AddCheck(prefix+"Node", od.backend.checkHealth)
+	s.Health.AddCheck(prefix+"Controller", od.backend.checkHealth)
 	s.Start(ctx, func(s *grpc.Server) {
 		switch od.csiVersion {
 		case csi03:
@@ -146,6 +146,41 @@ func (m *MockController) CheckMallocBDev(ctx context.Context, in *oim.CheckMallo
 // This can only be used to test the communication paths, but not
 // the actual operation.
 func TestMockOIM(t *testing.T) {
+	controller := testMockOIM(t, os.ExpandEnv("${TEST_WORK}/ca"), "host-0")
+	if assert.Len(t, controller.MapVolumes, 1) {
+		assert.Equal(t, "iqn.2018-09.io.oim:host:host-0", controller.MapVolumes[0].IscsiInitiator)
+	}
+}
+
+// Controller IDs which cannot be turned into an iSCSI initiator
+// name must still work for volumes which get attached via PCI.
+func TestMockOIMUnusualControllerID(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "oim-ca")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	controllerID := "Host_1"
+	ca := filepath.Join(tmp, "ca")
+	require.NoError(t, oimcommon.CreateCA(ca, "OIM CA", oimcommon.CertOptions{}))
+	for _, identity := range []oimcommon.Identity{
+		oimcommon.RegistryIdentity,
+		oimcommon.ControllerIdentity(controllerID),
+		{Role: oimcommon.RoleHost, ID: controllerID},
+	} {
+		require.NoError(t, oimcommon.IssueCert(ca, identity, filepath.Join(tmp, identity.String()), oimcommon.CertOptions{}))
+	}
+
+	controller := testMockOIM(t, tmp, controllerID)
+	if assert.Len(t, controller.MapVolumes, 1) {
+		assert.Equal(t, "", controller.MapVolumes[0].IscsiInitiator)
+		assert.Equal(t, "nqn.2018-09.io.oim:host:Host_1", controller.MapVolumes[0].HostNqn)
+	}
+}
+
+// testMockOIM stages a volume via the OIM registry and a mock
+// controller, using the CA and the keys for the controller ID in the
+// given directory. It returns the controller for further checks.
+func testMockOIM(t *testing.T, caDir, controllerID string) *MockController {
 	defer testlog.SetGlobal(t)()
 	ctx := context.Background()
 	adminCtx := oimregistry.RegistryClientContext(ctx, "user.admin")
@@ -155,10 +190,8 @@ func TestMockOIM(t *testing.T) {
 	require.NoError(t, err)
 	defer os.RemoveAll(tmp)
 
-	controllerID := "host-0"
-
 	registryAddress := "unix://" + tmp + "/oim-registry.sock"
-	tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+	tlsConfig, err := oimcommon.LoadTLSConfig(caDir+"/ca.crt", caDir+"/component.registry.key", "")
 	require.NoError(t, err)
 	registry, err := oimregistry.New(oimregistry.TLS(tlsConfig))
 	require.NoError(t, err)
@@ -170,8 +203,8 @@ func TestMockOIM(t *testing.T) {
 	controllerAddress := "unix://" + tmp + "/oim-controller.sock"
 	controller := &MockController{}
 	require.NoError(t, err)
-	controllerCreds, err := oimcommon.LoadTLS(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"),
-		os.ExpandEnv("${TEST_WORK}/ca/controller."+controllerID),
+	controllerCreds, err := oimcommon.LoadTLS(caDir+"/ca.crt",
+		caDir+"/controller."+controllerID,
 		"component.registry")
 	require.NoError(t, err)
 	controllerServer, controllerService := oimcontroller.Server(controllerAddress, controller, controllerCreds)
@@ -190,7 +223,7 @@ func TestMockOIM(t *testing.T) {
 	endpoint := "unix://" + tmp + "/oim-driver.sock"
 	driver, err := New(WithCSIEndpoint(endpoint),
 		WithOIMRegistryAddress(registryAddress),
-		WithRegistryCreds(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/host."+controllerID)),
+		WithRegistryCreds(caDir+"/ca.crt", caDir+"/host."+controllerID),
 		WithOIMControllerID(controllerID),
 	)
 	require.NoError(t, err)
@@ -202,6 +235,7 @@ func TestMockOIM(t *testing.T) {
 	opts := oimcommon.ChooseDialOpts(endpoint, grpc.WithBlock(), grpc.WithInsecure())
 	conn, err := grpc.Dial(endpoint, opts...)
 	require.NoError(t, err)
+	defer conn.Close()
 	csiClient := csi.NewNodeClient(conn)
 
 	// This will start waiting for a device that can never appear,
@@ -223,4 +257,5 @@ func TestMockOIM(t *testing.T) {
 		// What we can test reliably is that we get a DeadlineExceeded gRPC code.
 		assert.Equal(t, status.Convert(err).Code(), codes.DeadlineExceeded, fmt.Sprintf("expected DeadlineExceeded, got: %s", err))
 	}
+	return controller
 }
@@ -101,7 +101,7 @@ func (r *remoteSPDK) checkVolumeExists(ctx context.Context, volumeID string) err
 func (r *remoteSPDK) dialRegistry(ctx context.Context) (*grpc.ClientConn, error) {
 	// Intentionally loaded anew for each connection attempt.
 	// File content can change over time.
-	transportCreds, err := oimcommon.LoadTLS(r.registryCA, r.registryKey, "component.registry")
+	transportCreds, err := oimcommon.LoadTLS(r.registryCA, r.registryKey, oimcommon.RegistryIdentity.String())
 	if err != nil {
 		return nil, errors.Wrap(err, "load TLS certs")
 	}
@@ -113,7 +113,28 @@ func (r *remoteSPDK) dialRegistry(ctx context.Context) (*grpc.ClientConn, error)
 	return conn, nil
 }
 
-func (r *remoteSPDK) createDevice(ctx context.Context, volumeID string, csiRequest interface{}) (string, cleanup, error) {
+// checkHealth ensures that the registry is reachable and knows the
+// controller.
+func (r *remoteSPDK) checkHealth(ctx context.Context) error {
+	conn, err := r.dialRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	path := r.oimControllerID + "/" + oimcommon.RegistryAddress
+	reply, err := oim.NewRegistryClient(conn).GetValues(ctx, &oim.GetValuesRequest{
+		Path: path,
+	})
+	if err != nil {
+		return errors.Wrap(err, "get controller address from registry")
+	}
+	if len(reply.GetValues()) == 0 {
+		return errors.Errorf("controller %s not registered", r.oimControllerID)
+	}
+	return nil
+}
+
+func (r *remoteSPDK) createDevice(ctx context.Context, volumeID, stagingTargetPath string, csiRequest interface{}) (string, cleanup, error) {
 	// Connect to OIM controller through OIM registry.
 	conn, err := r.dialRegistry(ctx)
 	if err != nil {
@@ -145,6 +166,18 @@ func (r *remoteSPDK) createDevice(ctx context.Context, volumeID string, csiReque
 	}
 
 	// Make volume available and/or find out where it is.
+	hostNQN, err := oimcommon.HostNQN(r.oimControllerID)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "host NQN")
+	}
+	// IQNs are more restrictive than controller IDs. Without
+	// an initiator name, only controllers which export via iSCSI
+	// reject the request.
+	initiator, err := oimcommon.InitiatorIQN(r.oimControllerID)
+	if err != nil {
+		log.FromContext(ctx).Debugw("no iSCSI initiator name", "controllerid", r.oimControllerID, "error", err)
+		initiator = ""
+	}
 	ctx = metadata.AppendToOutgoingContext(ctx, "controllerid", r.oimControllerID)
 	request := &oim.MapVolumeRequest{
 		VolumeId: volumeID,
@@ -152,6 +185,11 @@ func (r *remoteSPDK) createDevice(ctx context.Context, volumeID string, csiReque
 		Params: &oim.MapVolumeRequest_Malloc{
 			Malloc: &oim.MallocParams{},
 		},
+		// Only used by controllers which export via NVMe-oF.
+		HostNqn: hostNQN,
+		// Only used by controllers which export via iSCSI.
+		IscsiInitiator: initiator,
+		IscsiChap:      iscsiCHAP(csiRequest),
 	}
 	if r.mapVolumeParams != nil {
 		// Replace default parameters with the actual
@@ -167,29 +205,30 @@ func (r *remoteSPDK) createDevice(ctx context.Context, volumeID string, csiReque
 		return "", nil, errors.Wrapf(err, "MapVolume for %s", volumeID)
 	}
 
-	// Find device node based on reply. If the PCI address
-	// is missing or incomplete, it must be set in the
-	// registry.
-	pciAddress := reply.GetPciAddress()
-	if pciAddress == nil {
-		pciAddress = &oim.PCIAddress{}
-	}
-	complete := oimcommon.CompletePCIAddress(*pciAddress, defPCIAddress)
-	if complete.Domain == 0xFFFF {
-		// We default the domain to zero because it
-		// rarely needed. Everything else must be
-		// specified.
-		complete.Domain = 0
+	// Remember how the volume gets attached before actually
+	// doing it, so that unstaging also cleans up after a
+	// partially failed attempt.
+	staged := stagedDevice{
+		NQN: reply.GetNvmeofDisk().GetNqn(),
+		IQN: reply.GetIscsiDisk().GetIqn(),
 	}
-	if complete.Bus == 0xFFFF || complete.Device == 0xFFFF || complete.Function == 0xFFFF {
-		return "", nil, errors.Errorf("need complete PCI address with bus:device.function: %s from controller, %s from registry at path %s => combined %s",
-			oimcommon.PrettyPCIAddress(pciAddress),
-			oimcommon.PrettyPCIAddress(&defPCIAddress),
-			oimcommon.PrettyPCIAddress(&complete),
-			path)
+	if err := writeStagedDevice(stagingTargetPath, staged); err != nil {
+		return "", nil, err
 	}
 
-	dev, major, minor, err := waitForDevice(ctx, "/sys/dev/block", &complete, reply.GetScsiDisk())
+	// Find device node based on reply, either by connecting
+	// to the NVMe-oF subsystem, by logging into the iSCSI target
+	// or via the PCI address.
+	var dev string
+	var major, minor int
+	switch {
+	case reply.GetNvmeofDisk() != nil:
+		dev, major, minor, err = attachNVMeoF(ctx, nvmeFabrics, sysNVMe, sysBlock, request.GetHostNqn(), reply.GetNvmeofDisk())
+	case reply.GetIscsiDisk() != nil:
+		dev, major, minor, err = attachISCSI(ctx, iscsiadm, sysISCSISession, iscsiNodes, request.GetIscsiInitiator(), reply.GetIscsiDisk(), request.GetIscsiChap())
+	default:
+		dev, major, minor, err = waitForPCIDevice(ctx, reply, defPCIAddress, path)
+	}
 	if err != nil {
 		return "", nil, errors.Wrap(err, "wait for device")
 	}
@@ -215,7 +254,33 @@ func (r *remoteSPDK) createDevice(ctx context.Context, volumeID string, csiReque
 	return devNode, cleanup, nil
 }
 
-func (r *remoteSPDK) deleteDevice(ctx context.Context, volumeID string) error {
+// waitForPCIDevice finds the device node for a volume which is
+// attached via PCI. If the PCI address is missing or incomplete in
+// the reply, it must be set in the registry at the given path.
+func waitForPCIDevice(ctx context.Context, reply *oim.MapVolumeReply, defPCIAddress oim.PCIAddress, path string) (string, int, int, error) {
+	pciAddress := reply.GetPciAddress()
+	if pciAddress == nil {
+		pciAddress = &oim.PCIAddress{}
+	}
+	complete := oimcommon.CompletePCIAddress(*pciAddress, defPCIAddress)
+	if complete.Domain == 0xFFFF {
+		// We default the domain to zero because it
+		// rarely needed. Everything else must be
+		// specified.
+		complete.Domain = 0
+	}
+	if complete.Bus == 0xFFFF || complete.Device == 0xFFFF || complete.Function == 0xFFFF {
+		return "", 0, 0, errors.Errorf("need complete PCI address with bus:device.function: %s from controller, %s from registry at path %s => combined %s",
+			oimcommon.PrettyPCIAddress(pciAddress),
+			oimcommon.PrettyPCIAddress(&defPCIAddress),
+			oimcommon.PrettyPCIAddress(&complete),
+			path)
+	}
+
+	return waitForDevice(ctx, "/sys/dev/block", &complete, reply.GetScsiDisk(), reply.GetBlkDisk())
+}
+
+func (r *remoteSPDK) deleteDevice(ctx context.Context, volumeID, stagingTargetPath string) error {
 	// Connect to OIM controller through OIM registry.
 	conn, err := r.dialRegistry(ctx)
 	if err != nil {
@@ -223,6 +288,23 @@ func (r *remoteSPDK) deleteDevice(ctx context.Context, volumeID string) error {
 	}
 	controllerClient := oim.NewControllerClient(conn)
 
+	// A volume which was exported via NVMe-oF or iSCSI must be
+	// disconnected first. Nothing happens for other volumes.
+	staged, err := readStagedDevice(stagingTargetPath)
+	if err != nil {
+		return err
+	}
+	if staged.NQN != "" {
+		if err := disconnectNVMe(ctx, sysNVMe, staged.NQN); err != nil {
+			return errors.Wrap(err, "disconnect NVMe-oF")
+		}
+	}
+	if staged.IQN != "" {
+		if err := logoutISCSI(ctx, iscsiadm, staged.IQN); err != nil {
+			return errors.Wrap(err, "log out of iSCSI target")
+		}
+	}
+
 	// Make volume available and/or find out where it is.
 	ctx = metadata.AppendToOutgoingContext(ctx, "controllerid", r.oimControllerID)
 	if _, err := controllerClient.UnmapVolume(ctx, &oim.UnmapVolumeRequest{
@@ -230,7 +312,7 @@ func (r *remoteSPDK) deleteDevice(ctx context.Context, volumeID string) error {
 	}); err != nil {
 		return errors.Wrapf(err, "UnmapVolume for %s", volumeID)
 	}
-	return nil
+	return removeStagedDevice(stagingTargetPath)
 }
 
 // makedev prepares the dev argument for Mknod.
@@ -246,11 +328,12 @@ const (
 	block = "/block/"
 )
 
-func waitForDevice(ctx context.Context, sys string, pciAddress *oim.PCIAddress, scsiDisk *oim.SCSIDisk) (string, int, int, error) {
+func waitForDevice(ctx context.Context, sys string, pciAddress *oim.PCIAddress, scsiDisk *oim.SCSIDisk, blkDisk *oim.BlkDisk) (string, int, int, error) {
 	log.FromContext(ctx).Infow("waiting for block device",
 		"sys", sys,
 		"PCI", pciAddress,
 		"scsi", scsiDisk,
+		"blk", blkDisk != nil,
 	)
 	watcher, err := fsnotify.NewWatcher()
 	if err == nil {
@@ -261,7 +344,7 @@ func waitForDevice(ctx context.Context, sys string, pciAddress *oim.PCIAddress,
 	}
 
 	for {
-		dev, major, minor, err := findDev(ctx, sys, pciAddress, scsiDisk)
+		dev, major, minor, err := findDev(ctx, sys, pciAddress, scsiDisk, blkDisk)
 		if err != nil {
 			// None of the operations should have failed. Give up.
 			return "", 0, 0, status.Error(codes.Internal, err.Error())
@@ -271,6 +354,10 @@ func waitForDevice(ctx context.Context, sys string, pciAddress *oim.PCIAddress,
 		}
 		select {
 		case <-ctx.Done():
+			if blkDisk != nil {
+				return "", 0, 0, status.Errorf(codes.DeadlineExceeded, "timed out waiting for virtio-blk device %s",
+					oimcommon.PrettyPCIAddress(pciAddress))
+			}
 			return "", 0, 0, status.Errorf(codes.DeadlineExceeded, "timed out waiting for device %s, SCSI disk '%+v'",
 				oimcommon.PrettyPCIAddress(pciAddress), scsiDisk)
 		case <-watcher.Events:
@@ -293,6 +380,7 @@ var (
 	majorMinor = regexp.MustCompile(`^(\d+):(\d+)$`)
 	pciRe      = regexp.MustCompile(`/pci[0-9a-fA-F]{1,4}:[0-9a-fA-F]{1,2}/([0-9a-fA-F]{1,4}):([0-9a-fA-F]{1,2}):([0-9a-fA-F]{1,2})\.([0-7])/`)
 	scsiRe     = regexp.MustCompile(`/target\d+:\d+:\d+/\d+:\d+:(\d+):(\d+)/block/`)
+	blkRe      = regexp.MustCompile(`virtio\d+/block/`)
 )
 
 func extractPCIAddress(str string) (*oim.PCIAddress, string) {
@@ -321,7 +409,7 @@ func extractSCSI(str string) *oim.SCSIDisk {
 	}
 }
 
-func findDev(ctx context.Context, sys string, pciAddress *oim.PCIAddress, scsiDisk *oim.SCSIDisk) (string, int, int, error) {
+func findDev(ctx context.Context, sys string, pciAddress *oim.PCIAddress, scsiDisk *oim.SCSIDisk, blkDisk *oim.BlkDisk) (string, int, int, error) {
 	files, err := ioutil.ReadDir(sys)
 	if err != nil {
 		return "", 0, 0, err
@@ -334,7 +422,9 @@ func findDev(ctx context.Context, sys string, pciAddress *oim.PCIAddress, scsiDi
 		}
 		// target is expected to have this format:
 		// ../../devices/pci0000:00/0000:00:15.0/virtio3/host0/target0:0:7/0:0:7:0/block/sda
-		// for PCI domain 0000, bus 00, device 15, function 9, SCSI target 7 and LUN 0.
+		// for PCI domain 0000, bus 00, device 15, function 9, SCSI target 7 and LUN 0,
+		// or this format for a virtio-blk device:
+		// ../../devices/pci0000:00/0000:00:16.0/virtio4/block/vda
 		log.FromContext(ctx).Debugw("symlink",
 			"from", fullpath,
 			"to", target,
@@ -345,10 +435,13 @@ func findDev(ctx context.Context, sys string, pciAddress *oim.PCIAddress, scsiDi
 		}
 		if scsiDisk != nil {
 			currentSCSI := extractSCSI(remainder)
-			if *currentSCSI != *scsiDisk {
+			if currentSCSI == nil || *currentSCSI != *scsiDisk {
 				continue
 			}
 		}
+		if blkDisk != nil && !blkRe.MatchString(remainder) {
+			continue
+		}
 		// Because Readdir sorted the entries, we are guaranteed to find
 		// the main block device before its partitions (i.e. 8:0 before 8:1).
 		sep := strings.LastIndex(target, block)
@@ -0,0 +1,72 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcsidriver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// stagedDevice records how a volume was attached while staging it,
+// so that unstaging only tears down that connection. The zero value
+// stands for a volume which needs no teardown, like one attached via
+// PCI.
+type stagedDevice struct {
+	// NQN is set for volumes connected via NVMe-oF.
+	NQN string `json:"nqn,omitempty"`
+	// IQN is set for volumes logged into via iSCSI.
+	IQN string `json:"iqn,omitempty"`
+}
+
+// stagedDeviceFile returns the file with the record for the staging
+// target path. It cannot be inside the target path because the volume
+// gets mounted there.
+func stagedDeviceFile(targetPath string) string {
+	return filepath.Clean(targetPath) + ".oim.json"
+}
+
+// writeStagedDevice stores the record, replacing an older one.
+func writeStagedDevice(targetPath string, device stagedDevice) error {
+	data, err := json.Marshal(device)
+	if err != nil {
+		return errors.Wrap(err, "encode staged device")
+	}
+	if err := ioutil.WriteFile(stagedDeviceFile(targetPath), data, 0600); err != nil {
+		return errors.Wrap(err, "write staged device")
+	}
+	return nil
+}
+
+// readStagedDevice returns the record for the staging target path. A
+// missing record is treated like one for a device without teardown.
+func readStagedDevice(targetPath string) (stagedDevice, error) {
+	var device stagedDevice
+	data, err := ioutil.ReadFile(stagedDeviceFile(targetPath))
+	if os.IsNotExist(err) {
+		return device, nil
+	}
+	if err != nil {
+		return device, errors.Wrap(err, "read staged device")
+	}
+	if err := json.Unmarshal(data, &device); err != nil {
+		return device, errors.Wrapf(err, "decode staged device %s", stagedDeviceFile(targetPath))
+	}
+	return device, nil
+}
+
+// removeStagedDevice deletes the record. Not having one is not an
+// error.
+func removeStagedDevice(targetPath string) error {
+	if err := os.Remove(stagedDeviceFile(targetPath)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove staged device")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimcsidriver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStagedDevice(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "staging")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	targetPath := filepath.Join(tmp, "globalmount") + "/"
+
+	// No record yet.
+	device, err := readStagedDevice(targetPath)
+	assert.NoError(t, err)
+	assert.Equal(t, stagedDevice{}, device)
+	assert.NoError(t, removeStagedDevice(targetPath))
+
+	// Stored next to the target path, not inside it.
+	nvme := stagedDevice{NQN: "nqn.2018-09.io.oim:host-0:vol-1"}
+	require.NoError(t, writeStagedDevice(targetPath, nvme))
+	_, err = os.Stat(filepath.Join(tmp, "globalmount.oim.json"))
+	assert.NoError(t, err)
+	device, err = readStagedDevice(targetPath)
+	assert.NoError(t, err)
+	assert.Equal(t, nvme, device)
+
+	// Replaced by a new record.
+	iscsi := stagedDevice{IQN: "iqn.2018-09.io.oim:host-0:vol-1"}
+	require.NoError(t, writeStagedDevice(targetPath, iscsi))
+	device, err = readStagedDevice(targetPath)
+	assert.NoError(t, err)
+	assert.Equal(t, iscsi, device)
+
+	// Removed.
+	assert.NoError(t, removeStagedDevice(targetPath))
+	device, err = readStagedDevice(targetPath)
+	assert.NoError(t, err)
+	assert.Equal(t, stagedDevice{}, device)
+
+	// Garbage.
+	require.NoError(t, ioutil.WriteFile(stagedDeviceFile(targetPath), []byte("{"), 0600))
+	_, err = readStagedDevice(targetPath)
+	assert.Error(t, err)
+}
@@ -0,0 +1,212 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimregistry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/vgough/grpc-proxy/proxy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/intel/oim/pkg/log"
+	"github.com/intel/oim/pkg/oim-common"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+// AuditRecord describes one registry modification or one call that
+// was proxied to a controller.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Peer is the identity of the caller as determined by
+	// oimcommon.CertIdentity, empty if unknown.
+	Peer string `json:"peer"`
+	// Method is the full gRPC method name.
+	Method string `json:"method"`
+	// Paths are the registry paths that the caller tried to
+	// modify.
+	Paths []string `json:"paths,omitempty"`
+	// ControllerID is set for proxied calls.
+	ControllerID string `json:"controllerID,omitempty"`
+	// VolumeID and Request are only set for known request types
+	// and only if the call was not rejected before reading the
+	// request.
+	VolumeID string `json:"volumeID,omitempty"`
+	// Request is the request message in JSON format, with all
+	// secrets redacted.
+	Request string `json:"request,omitempty"`
+	// Code is the gRPC status code of the result.
+	Code  string `json:"code"`
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink stores audit records.
+type AuditSink interface {
+	Audit(ctx context.Context, record *AuditRecord) error
+}
+
+// AuditLog is an AuditSink which writes one JSON object per line.
+type AuditLog struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// NewAuditLog creates an AuditSink which writes to the writer.
+func NewAuditLog(writer io.Writer) *AuditLog {
+	return &AuditLog{writer: writer}
+}
+
+// Audit writes one line.
+func (a *AuditLog) Audit(ctx context.Context, record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "encode audit record")
+	}
+	data = append(data, '\n')
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	_, err = a.writer.Write(data)
+	return errors.Wrap(err, "write audit record")
+}
+
+// AuditFile is an AuditLog which appends to a file and thus must be
+// closed when no longer needed.
+type AuditFile struct {
+	AuditLog
+	file *os.File
+}
+
+// NewAuditFile opens or creates the file.
+func NewAuditFile(path string) (*AuditFile, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "open audit log")
+	}
+	return &AuditFile{AuditLog: AuditLog{writer: file}, file: file}, nil
+}
+
+// Close closes the file.
+func (a *AuditFile) Close() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.file.Close()
+}
+
+// Audit configures where audit records are sent. Without a sink,
+// nothing gets recorded.
+func Audit(sink AuditSink) Option {
+	return func(r *registry) error {
+		r.audit = sink
+		return nil
+	}
+}
+
+// auditedRequests lists the proxied methods whose request gets
+// decoded for the audit record.
+var auditedRequests = map[string]func() proto.Message{
+	"/oim.v0.Controller/MapVolume":   func() proto.Message { return &oim.MapVolumeRequest{} },
+	"/oim.v0.Controller/UnmapVolume": func() proto.Message { return &oim.UnmapVolumeRequest{} },
+}
+
+// record fills in the common fields and passes the record to the
+// sink. Failures are logged, but do not affect the audited call.
+func (r *registry) record(ctx context.Context, record *AuditRecord, request proto.Message, err error) {
+	if r.audit == nil {
+		return
+	}
+	record.Time = time.Now()
+	record.Peer, _ = getPeer(ctx)
+	if request != nil {
+		record.Request = redactSecrets(request)
+		if volume, ok := request.(interface{ GetVolumeId() string }); ok {
+			record.VolumeID = volume.GetVolumeId()
+		}
+	}
+	record.Code = status.Code(err).String()
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if err := r.audit.Audit(ctx, record); err != nil {
+		log.FromContext(ctx).Errorw("audit", "method", record.Method, "error", err)
+	}
+}
+
+// auditMutation records a SetValue or SetValues call.
+func (r *registry) auditMutation(ctx context.Context, method string, request proto.Message, paths []string, err error) {
+	r.record(ctx, &AuditRecord{Method: method, Paths: paths}, request, err)
+}
+
+// auditProxy wraps the proxy handler such that each proxied call
+// gets recorded once it is complete.
+func (r *registry) auditProxy(handler grpc.StreamHandler) grpc.StreamHandler {
+	return func(srv interface{}, stream grpc.ServerStream) error {
+		if r.audit == nil {
+			return handler(srv, stream)
+		}
+		method, _ := grpc.MethodFromServerStream(stream)
+		audited := &auditedStream{ServerStream: stream, method: method}
+		err := handler(srv, audited)
+		ctx := stream.Context()
+		record := &AuditRecord{Method: method}
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md["controllerid"]) == 1 {
+			record.ControllerID = md["controllerid"][0]
+		}
+		r.record(ctx, record, audited.request, err)
+		return err
+	}
+}
+
+// auditedStream decodes the first request of known methods.
+type auditedStream struct {
+	grpc.ServerStream
+	method   string
+	received bool
+	request  proto.Message
+}
+
+func (a *auditedStream) RecvMsg(m interface{}) error {
+	if err := a.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if a.received {
+		return nil
+	}
+	a.received = true
+	newRequest, ok := auditedRequests[a.method]
+	if !ok {
+		return nil
+	}
+	// The proxy only has the raw message. Encoding its frame
+	// returns exactly those bytes.
+	data, err := proxy.Codec().Marshal(m)
+	if err != nil {
+		return nil
+	}
+	request := newRequest()
+	if err := proto.Unmarshal(data, request); err == nil {
+		a.request = request
+	}
+	return nil
+}
+
+// redactSecrets returns the message in JSON format, with all secret
+// fields replaced.
+func redactSecrets(msg proto.Message) string {
+	data, err := json.Marshal(oimcommon.RedactSecrets(msg))
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimregistry_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/intel/oim/pkg/oim-common"
+	"github.com/intel/oim/pkg/oim-registry"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recordingSink keeps all audit records in memory.
+type recordingSink struct {
+	mutex   sync.Mutex
+	records []oimregistry.AuditRecord
+}
+
+func (s *recordingSink) Audit(ctx context.Context, record *oimregistry.AuditRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.records = append(s.records, *record)
+	return nil
+}
+
+func (s *recordingSink) Records() []oimregistry.AuditRecord {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]oimregistry.AuditRecord{}, s.records...)
+}
+
+var _ = Describe("Audit", func() {
+	var (
+		ctx    = context.Background()
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "oim-registry-test")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("should record registry changes", func() {
+		filename := filepath.Join(tmpDir, "audit.log")
+		auditFile, err := oimregistry.NewAuditFile(filename)
+		Expect(err).NotTo(HaveOccurred())
+		defer auditFile.Close()
+		tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+		Expect(err).NotTo(HaveOccurred())
+		registry, err := oimregistry.New(oimregistry.TLS(tlsConfig), oimregistry.Audit(auditFile))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = registry.SetValue(oimregistry.RegistryClientContext(ctx, "user.admin"), &oim.SetValueRequest{
+			Value: &oim.Value{Path: "host-0/pci", Value: "0000:0003:20.1"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = registry.SetValue(oimregistry.RegistryClientContext(ctx, "controller.host-0"), &oim.SetValueRequest{
+			Value: &oim.Value{Path: "host-1/address", Value: "dns:///evil"},
+		})
+		Expect(err).To(HaveOccurred())
+		_, err = registry.SetValues(oimregistry.RegistryClientContext(ctx, "user.admin"), &oim.SetValuesRequest{
+			Changes: []*oim.SetValueRequest{
+				{Value: &oim.Value{Path: "host-0/pci"}},
+				{Value: &oim.Value{Path: "host-0/address", Value: "dns:///host-0"}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = registry.GetValues(oimregistry.RegistryClientContext(ctx, "user.admin"), &oim.GetValuesRequest{})
+		Expect(err).NotTo(HaveOccurred())
+
+		file, err := os.Open(filename)
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+		var records []oimregistry.AuditRecord
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var record oimregistry.AuditRecord
+			err := json.Unmarshal(scanner.Bytes(), &record)
+			Expect(err).NotTo(HaveOccurred(), scanner.Text())
+			Expect(record.Time).NotTo(BeZero())
+			records = append(records, record)
+		}
+		Expect(scanner.Err()).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(3))
+		Expect(records[0].Peer).To(Equal("user.admin"))
+		Expect(records[0].Method).To(Equal("/oim.v0.Registry/SetValue"))
+		Expect(records[0].Paths).To(Equal([]string{"host-0/pci"}))
+		Expect(records[0].Code).To(Equal("OK"))
+		Expect(records[1].Peer).To(Equal("controller.host-0"))
+		Expect(records[1].Paths).To(Equal([]string{"host-1/address"}))
+		Expect(records[1].Code).To(Equal("PermissionDenied"))
+		Expect(records[1].Error).To(ContainSubstring("not allowed"))
+		Expect(records[2].Method).To(Equal("/oim.v0.Registry/SetValues"))
+		Expect(records[2].Paths).To(Equal([]string{"host-0/pci", "host-0/address"}))
+		Expect(records[2].Code).To(Equal("OK"))
+	})
+})
@@ -0,0 +1,214 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimregistry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+
+	"github.com/intel/oim/pkg/log"
+)
+
+var (
+	connectionHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "oim",
+		Subsystem: "registry",
+		Name:      "proxy_connection_hits_total",
+		Help:      "Number of proxied calls which reused an existing connection to the controller.",
+	})
+	connectionMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "oim",
+		Subsystem: "registry",
+		Name:      "proxy_connection_misses_total",
+		Help:      "Number of proxied calls which had to establish a new connection to the controller.",
+	})
+	connectionsOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "oim",
+		Subsystem: "registry",
+		Name:      "proxy_connections",
+		Help:      "Number of cached connections to controllers.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(connectionHits, connectionMisses, connectionsOpen)
+}
+
+// connPool caches connections to controllers. Connections are shared
+// by concurrent calls. An unused connection gets closed after a
+// certain idle time, when the controller's address changes or when
+// room is needed for a different connection.
+type connPool struct {
+	maxConns    int
+	idleTimeout time.Duration
+
+	mutex sync.Mutex
+	conns map[string]*pooledConn // by controller ID
+	users map[*grpc.ClientConn]*pooledConn
+}
+
+type pooledConn struct {
+	controllerID string
+	address      string
+	conn         *grpc.ClientConn
+	users        int
+	lastUsed     time.Time
+	idleTimer    *time.Timer
+	// stale is set for connections which are still in use, but
+	// must not be handed out anymore.
+	stale bool
+}
+
+// dialFunc establishes a new connection. It must not depend on the
+// context of the current call because the connection may outlive it.
+type dialFunc func() (*grpc.ClientConn, error)
+
+func newConnPool(maxConns int, idleTimeout time.Duration) *connPool {
+	return &connPool{
+		maxConns:    maxConns,
+		idleTimeout: idleTimeout,
+		conns:       map[string]*pooledConn{},
+		users:       map[*grpc.ClientConn]*pooledConn{},
+	}
+}
+
+// get returns a connection for the controller at the given address,
+// either an existing one or a new one. Each connection must be
+// passed to put once the call is done.
+func (p *connPool) get(controllerID, address string, dial dialFunc) (*grpc.ClientConn, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if pc := p.conns[controllerID]; pc != nil {
+		if pc.address == address {
+			connectionHits.Inc()
+			p.use(pc)
+			return pc.conn, nil
+		}
+		// The controller has moved.
+		p.invalidateLocked(controllerID)
+	}
+
+	connectionMisses.Inc()
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	pc := &pooledConn{
+		controllerID: controllerID,
+		address:      address,
+		conn:         conn,
+	}
+	if p.makeRoom() {
+		p.conns[controllerID] = pc
+		connectionsOpen.Inc()
+	} else {
+		// Used once and then closed.
+		pc.stale = true
+	}
+	p.use(pc)
+	return conn, nil
+}
+
+// put marks the end of a call that used the connection.
+func (p *connPool) put(conn *grpc.ClientConn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	pc := p.users[conn]
+	if pc == nil {
+		return
+	}
+	pc.users--
+	if pc.users > 0 {
+		return
+	}
+	delete(p.users, conn)
+	pc.lastUsed = time.Now()
+	if pc.stale {
+		p.close(pc)
+		return
+	}
+	pc.idleTimer = time.AfterFunc(p.idleTimeout, func() {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		if pc.users == 0 && p.conns[pc.controllerID] == pc {
+			p.remove(pc)
+		}
+	})
+}
+
+// invalidate ensures that the current connection to the controller
+// is not used for further calls.
+func (p *connPool) invalidate(controllerID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.invalidateLocked(controllerID)
+}
+
+func (p *connPool) invalidateLocked(controllerID string) {
+	pc := p.conns[controllerID]
+	if pc == nil {
+		return
+	}
+	if pc.users == 0 {
+		p.remove(pc)
+	} else {
+		delete(p.conns, controllerID)
+		connectionsOpen.Dec()
+		pc.stale = true
+	}
+}
+
+func (p *connPool) use(pc *pooledConn) {
+	if pc.idleTimer != nil {
+		pc.idleTimer.Stop()
+		pc.idleTimer = nil
+	}
+	pc.users++
+	p.users[pc.conn] = pc
+}
+
+// makeRoom returns true if another connection may be added to the
+// pool, if necessary after closing the least recently used idle
+// connection.
+func (p *connPool) makeRoom() bool {
+	if len(p.conns) < p.maxConns {
+		return true
+	}
+	var oldest *pooledConn
+	for _, pc := range p.conns {
+		if pc.users == 0 && (oldest == nil || pc.lastUsed.Before(oldest.lastUsed)) {
+			oldest = pc
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	p.remove(oldest)
+	return true
+}
+
+// remove closes an idle connection which is in the pool.
+func (p *connPool) remove(pc *pooledConn) {
+	delete(p.conns, pc.controllerID)
+	connectionsOpen.Dec()
+	p.close(pc)
+}
+
+func (p *connPool) close(pc *pooledConn) {
+	if pc.idleTimer != nil {
+		pc.idleTimer.Stop()
+		pc.idleTimer = nil
+	}
+	if err := pc.conn.Close(); err != nil {
+		log.L().Warnw("closing connection", "controllerid", pc.controllerID, "error", err)
+	}
+}
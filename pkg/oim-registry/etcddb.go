@@ -0,0 +1,195 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimregistry
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/pkg/errors"
+
+	"github.com/intel/oim/pkg/log"
+)
+
+// etcdRegistryDB implements a RegistryDB on top of etcd. All registry
+// entries are stored as etcd keys with a common prefix, so several
+// OIM registry instances connected to the same etcd cluster share
+// the same view of the registry and the same etcd cluster can also
+// be used for other purposes.
+type etcdRegistryDB struct {
+	client *clientv3.Client
+	prefix string
+}
+
+// NewEtcdRegistryDB constructs a database which stores all entries
+// under the given prefix in etcd. The caller remains responsible for
+// closing the client after the database is no longer in use.
+func NewEtcdRegistryDB(client *clientv3.Client, prefix string) RegistryDB {
+	return &etcdRegistryDB{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (e *etcdRegistryDB) Store(ctx context.Context, controllerID, address string, ttl time.Duration, expected int64) (int64, error) {
+	return e.Txn(ctx, nil, []Change{{Key: controllerID, Value: address, TTL: ttl, Expected: expected}})
+}
+
+func (e *etcdRegistryDB) Txn(ctx context.Context, guards []Guard, changes []Change) (revision int64, finalErr error) {
+	var cmps []clientv3.Cmp
+	var ops []clientv3.Op
+	// Leases granted for this transaction must not outlive it when
+	// it does not get committed.
+	var granted []clientv3.LeaseID
+	defer func() {
+		if finalErr != nil {
+			e.revoke(ctx, granted)
+		}
+	}()
+	for _, guard := range guards {
+		// The mod revision of a non-existent key is zero,
+		// which matches our definition of "must not exist".
+		cmps = append(cmps, clientv3.Compare(clientv3.ModRevision(e.prefix+guard.Key), "=", guard.Revision))
+	}
+	for _, change := range changes {
+		key := e.prefix + change.Key
+		if change.Expected != AnyRevision {
+			cmps = append(cmps, clientv3.Compare(clientv3.ModRevision(key), "=", change.Expected))
+		}
+		if change.Value == "" {
+			ops = append(ops, clientv3.OpDelete(key))
+			continue
+		}
+		var opts []clientv3.OpOption
+		if change.TTL != 0 {
+			lease, fresh, err := e.lease(ctx, key, change.TTL)
+			if err != nil {
+				return 0, err
+			}
+			if fresh {
+				granted = append(granted, lease)
+			}
+			opts = append(opts, clientv3.WithLease(lease))
+		}
+		ops = append(ops, clientv3.OpPut(key, change.Value, opts...))
+	}
+	resp, err := e.client.Txn(ctx).If(cmps...).Then(ops...).Commit()
+	if err != nil {
+		return 0, errors.Wrap(err, "etcd transaction")
+	}
+	if !resp.Succeeded {
+		return 0, errors.Wrap(ErrConflict, "etcd transaction")
+	}
+	return resp.Header.Revision, nil
+}
+
+// lease returns a lease for storing the key with the given TTL. A
+// periodic refresh of a key reuses and renews the lease which the key
+// already has, as long as that lease was granted with the same TTL.
+// Otherwise a new lease is granted. Attaching the key to it detaches
+// the key from the previous one, which then expires without affecting
+// the key.
+func (e *etcdRegistryDB) lease(ctx context.Context, key string, ttl time.Duration) (id clientv3.LeaseID, fresh bool, err error) {
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	resp, err := e.client.Get(ctx, key, clientv3.WithKeysOnly())
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "etcd get %q", key)
+	}
+	if len(resp.Kvs) > 0 && resp.Kvs[0].Lease != 0 {
+		id := clientv3.LeaseID(resp.Kvs[0].Lease)
+		// If the lease has expired in the meantime or cannot be
+		// checked, we simply fall back to a new one.
+		if current, err := e.client.TimeToLive(ctx, id); err == nil && current.TTL > 0 && current.GrantedTTL == seconds {
+			if _, err := e.client.KeepAliveOnce(ctx, id); err == nil {
+				return id, false, nil
+			}
+		}
+	}
+	lease, err := e.client.Grant(ctx, seconds)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "etcd lease for %q", key)
+	}
+	return lease.ID, true, nil
+}
+
+// revoke releases leases which ended up not being used. Failures are
+// only logged because the leases expire eventually anyway.
+func (e *etcdRegistryDB) revoke(ctx context.Context, leases []clientv3.LeaseID) {
+	for _, id := range leases {
+		if _, err := e.client.Revoke(ctx, id); err != nil {
+			log.FromContext(ctx).Warnw("etcd revoke unused lease", "lease", id, "error", err)
+		}
+	}
+}
+
+func (e *etcdRegistryDB) Lookup(ctx context.Context, controllerID string) (address string, err error) {
+	key := e.prefix + controllerID
+	resp, err := e.client.Get(ctx, key)
+	if err != nil {
+		return "", errors.Wrapf(err, "etcd get %q", key)
+	}
+	if len(resp.Kvs) == 0 {
+		return "", nil
+	}
+	return string(resp.Kvs[0].Value), nil
+}
+
+func (e *etcdRegistryDB) Foreach(ctx context.Context, callback func(key string, entry Entry) bool) error {
+	resp, err := e.client.Get(ctx, e.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return errors.Wrapf(err, "etcd get prefix %q", e.prefix)
+	}
+	for _, kv := range resp.Kvs {
+		if !callback(strings.TrimPrefix(string(kv.Key), e.prefix), Entry{Value: string(kv.Value), Revision: kv.ModRevision, Temporary: kv.Lease != 0}) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (e *etcdRegistryDB) Watch(ctx context.Context) (map[string]Entry, int64, <-chan WatchEvent, error) {
+	resp, err := e.client.Get(ctx, e.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, 0, nil, errors.Wrapf(err, "etcd get prefix %q", e.prefix)
+	}
+	entries := make(map[string]Entry, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		entries[strings.TrimPrefix(string(kv.Key), e.prefix)] = Entry{Value: string(kv.Value), Revision: kv.ModRevision, Temporary: kv.Lease != 0}
+	}
+	revision := resp.Header.Revision
+
+	// Continue exactly where the snapshot ended, so no change
+	// gets lost.
+	watchChan := e.client.Watch(ctx, e.prefix, clientv3.WithPrefix(), clientv3.WithRev(revision+1))
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		for resp := range watchChan {
+			if err := resp.Err(); err != nil {
+				log.FromContext(ctx).Warnw("etcd watch", "prefix", e.prefix, "error", err)
+				return
+			}
+			for _, ev := range resp.Events {
+				event := WatchEvent{
+					Key:      strings.TrimPrefix(string(ev.Kv.Key), e.prefix),
+					Revision: ev.Kv.ModRevision,
+				}
+				if ev.Type == clientv3.EventTypePut {
+					event.Value = string(ev.Kv.Value)
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return entries, revision, events, nil
+}
@@ -0,0 +1,198 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimregistry
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/intel/oim/pkg/log"
+	"github.com/intel/oim/pkg/oim-common"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+// exportChunkSize is the maximum number of values per ExportReply,
+// to stay well below the gRPC message size limit.
+const exportChunkSize = 1000
+
+// importChunkSize is the maximum number of changes per transaction
+// in Import. etcd rejects transactions with more operations than
+// its --max-txn-ops (128 by default).
+const importChunkSize = 100
+
+// checkAdmin ensures that the caller may export and import.
+func (r *registry) checkAdmin(ctx context.Context) error {
+	peer, err := getPeer(ctx)
+	if err != nil {
+		return err
+	}
+	if !r.getPolicy().Allowed(peer, VerbAdmin, "") {
+		return status.Errorf(codes.PermissionDenied, "caller %q not allowed to export or import", peer)
+	}
+	return nil
+}
+
+func (r *registry) Export(in *oim.ExportRequest, stream oim.Registry_ExportServer) error {
+	ctx := stream.Context()
+	if err := r.checkAdmin(ctx); err != nil {
+		return err
+	}
+
+	// Watching is the only way to get all entries together
+	// with the revision of the snapshot. We stop immediately
+	// afterwards.
+	watchCtx, cancel := context.WithCancel(ctx)
+	entries, revision, _, err := r.db.Watch(watchCtx)
+	cancel()
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "export values: %s", err)
+	}
+	var values []*oim.Value
+	for key, entry := range entries {
+		values = append(values, &oim.Value{
+			Path:      key,
+			Value:     entry.Value,
+			Revision:  entry.Revision,
+			Temporary: entry.Temporary,
+		})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Path < values[j].Path
+	})
+
+	// Always send at least one reply, for the revision.
+	for {
+		reply := &oim.ExportReply{
+			Revision: revision,
+		}
+		n := len(values)
+		if n > exportChunkSize {
+			n = exportChunkSize
+		}
+		reply.Values, values = values[:n], values[n:]
+		if err := stream.Send(reply); err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return nil
+		}
+	}
+}
+
+func (r *registry) Import(stream oim.Registry_ImportServer) (err error) {
+	ctx := stream.Context()
+	var paths []string
+	defer func() {
+		r.auditMutation(ctx, "/oim.v0.Registry/Import", nil, paths, err)
+	}()
+
+	if err := r.checkAdmin(ctx); err != nil {
+		return err
+	}
+	db, ok := r.db.(TxnRegistryDB)
+	if !ok {
+		return status.Error(codes.Unimplemented, "registry database does not support transactions")
+	}
+
+	// Nothing gets stored before the client is done.
+	var changes []Change
+	var skipped []string
+	imported := map[string]bool{}
+	replace := false
+	for first := true; ; first = false {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if first {
+			replace = in.GetReplace()
+		}
+		for _, value := range in.GetValues() {
+			elements, err := oimcommon.SplitRegistryPath(value.GetPath())
+			if err != nil {
+				return status.Errorf(codes.InvalidArgument, "%q: %s", value.GetPath(), err)
+			}
+			if len(elements) == 0 {
+				return status.Error(codes.InvalidArgument, "empty path")
+			}
+			key := oimcommon.JoinRegistryPath(elements)
+			if value.GetValue() == "" {
+				return status.Errorf(codes.InvalidArgument, "%q: empty value", key)
+			}
+			if imported[key] {
+				return status.Errorf(codes.InvalidArgument, "%q imported more than once", key)
+			}
+			imported[key] = true
+			if value.GetTemporary() {
+				// Restoring it without the TTL would
+				// make it permanent. The controller
+				// registers again by itself.
+				skipped = append(skipped, key)
+				continue
+			}
+			paths = append(paths, key)
+			changes = append(changes, Change{
+				Key:      key,
+				Value:    value.GetValue(),
+				Expected: AnyRevision,
+			})
+		}
+	}
+
+	if replace {
+		// Removing entries only succeeds if they were not
+		// modified in the meantime.
+		err := r.db.Foreach(ctx, func(key string, entry Entry) bool {
+			if !imported[key] && !entry.Temporary {
+				paths = append(paths, key)
+				changes = append(changes, Change{
+					Key:      key,
+					Expected: entry.Revision,
+				})
+			}
+			return true
+		})
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "import values: %s", err)
+		}
+	}
+
+	// Each chunk is atomic, the import as a whole is not.
+	var revision int64
+	total := len(changes)
+	for first := true; first || len(changes) > 0; first = false {
+		n := len(changes)
+		if n > importChunkSize {
+			n = importChunkSize
+		}
+		var chunk []Change
+		chunk, changes = changes[:n], changes[n:]
+		revision, err = db.Txn(ctx, nil, chunk)
+		if err != nil {
+			return storeError("import", errors.Wrapf(err, "%d of %d changes stored", total-len(changes)-n, total))
+		}
+		for _, change := range chunk {
+			r.changed(change.Key)
+		}
+	}
+	if len(skipped) > 0 {
+		log.FromContext(ctx).Infow("import skipped temporary values", "paths", skipped)
+	}
+	return stream.SendAndClose(&oim.ImportReply{
+		Revision: revision,
+		Skipped:  skipped,
+	})
+}
@@ -0,0 +1,227 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimregistry_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/intel/oim/pkg/oim-common"
+	"github.com/intel/oim/pkg/oim-registry"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// exportStream implements oim.Registry_ExportServer.
+type exportStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	replies []*oim.ExportReply
+}
+
+func (e *exportStream) Context() context.Context {
+	return e.ctx
+}
+
+func (e *exportStream) Send(reply *oim.ExportReply) error {
+	e.replies = append(e.replies, reply)
+	return nil
+}
+
+// importStream implements oim.Registry_ImportServer.
+type importStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*oim.ImportRequest
+	reply    *oim.ImportReply
+}
+
+func (i *importStream) Context() context.Context {
+	return i.ctx
+}
+
+func (i *importStream) Recv() (*oim.ImportRequest, error) {
+	if len(i.requests) == 0 {
+		return nil, io.EOF
+	}
+	request := i.requests[0]
+	i.requests = i.requests[1:]
+	return request, nil
+}
+
+func (i *importStream) SendAndClose(reply *oim.ImportReply) error {
+	i.reply = reply
+	return nil
+}
+
+var _ = Describe("Export", func() {
+	var (
+		ctx      = context.Background()
+		adminCtx = oimregistry.RegistryClientContext(ctx, "user.admin")
+		registry oimregistry.RegistryServer
+	)
+
+	newRegistry := func() oimregistry.RegistryServer {
+		tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+		Expect(err).NotTo(HaveOccurred())
+		registry, err := oimregistry.New(oimregistry.TLS(tlsConfig))
+		Expect(err).NotTo(HaveOccurred())
+		return registry
+	}
+
+	set := func(registry oimregistry.RegistryServer, path, value string) {
+		_, err := registry.SetValue(adminCtx, &oim.SetValueRequest{Value: &oim.Value{Path: path, Value: value}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	register := func(registry oimregistry.RegistryServer, path, value string) {
+		_, err := registry.SetValue(adminCtx, &oim.SetValueRequest{Value: &oim.Value{Path: path, Value: value}, TtlSeconds: 60})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	values := func(registry oimregistry.RegistryServer) map[string]string {
+		reply, err := registry.GetValues(adminCtx, &oim.GetValuesRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		result := map[string]string{}
+		for _, value := range reply.GetValues() {
+			result[value.Path] = value.Value
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		registry = newRegistry()
+		set(registry, "host-0/address", "dns:///host-0")
+		set(registry, "host-0/pci", "0000:0003:20.1")
+		register(registry, "host-2/address", "dns:///host-2")
+	})
+
+	It("should return all values", func() {
+		stream := &exportStream{ctx: adminCtx}
+		err := registry.Export(&oim.ExportRequest{}, stream)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream.replies).To(Equal([]*oim.ExportReply{
+			{
+				Values: []*oim.Value{
+					{Path: "host-0/address", Value: "dns:///host-0", Revision: 1},
+					{Path: "host-0/pci", Value: "0000:0003:20.1", Revision: 2},
+					{Path: "host-2/address", Value: "dns:///host-2", Revision: 3, Temporary: true},
+				},
+				Revision: 3,
+			},
+		}))
+	})
+
+	It("should split large exports", func() {
+		for i := 0; i < 1500; i++ {
+			set(registry, fmt.Sprintf("host-%d/pci", i), "0000:0003:20.1")
+		}
+		stream := &exportStream{ctx: adminCtx}
+		err := registry.Export(&oim.ExportRequest{}, stream)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream.replies).To(HaveLen(2))
+		Expect(len(stream.replies[0].Values) + len(stream.replies[1].Values)).To(Equal(1502))
+		Expect(stream.replies[1].Revision).To(Equal(stream.replies[0].Revision))
+	})
+
+	It("should be restricted to admins", func() {
+		stream := &exportStream{ctx: oimregistry.RegistryClientContext(ctx, "host.host-0")}
+		err := registry.Export(&oim.ExportRequest{}, stream)
+		Expect(err).To(HaveOccurred())
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+
+		importer := &importStream{ctx: oimregistry.RegistryClientContext(ctx, "controller.host-0")}
+		err = registry.Import(importer)
+		Expect(err).To(HaveOccurred())
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+	})
+
+	Describe("import", func() {
+		var exported []*oim.ImportRequest
+
+		BeforeEach(func() {
+			stream := &exportStream{ctx: adminCtx}
+			err := registry.Export(&oim.ExportRequest{}, stream)
+			Expect(err).NotTo(HaveOccurred())
+			exported = nil
+			for _, reply := range stream.replies {
+				exported = append(exported, &oim.ImportRequest{Values: reply.Values})
+			}
+		})
+
+		It("should restore values", func() {
+			other := newRegistry()
+			set(other, "host-1/pci", "0000:0004:30.2")
+			importer := &importStream{ctx: adminCtx, requests: exported}
+			err := other.Import(importer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(importer.reply.Revision).To(Equal(int64(2)))
+			// Only addresses registered with a TTL are skipped.
+			Expect(importer.reply.Skipped).To(Equal([]string{"host-2/address"}))
+			Expect(values(other)).To(Equal(map[string]string{
+				"host-0/address": "dns:///host-0",
+				"host-0/pci":     "0000:0003:20.1",
+				"host-1/pci":     "0000:0004:30.2",
+			}))
+		})
+
+		It("should replace values", func() {
+			other := newRegistry()
+			register(other, "host-1/address", "dns:///host-1")
+			set(other, "host-1/pci", "0000:0004:30.2")
+			set(other, "host-3/address", "dns:///host-3")
+			exported[0].Replace = true
+			importer := &importStream{ctx: adminCtx, requests: exported}
+			err := other.Import(importer)
+			Expect(err).NotTo(HaveOccurred())
+			// Only temporary values survive.
+			Expect(values(other)).To(Equal(map[string]string{
+				"host-0/address": "dns:///host-0",
+				"host-0/pci":     "0000:0003:20.1",
+				"host-1/address": "dns:///host-1",
+			}))
+		})
+
+		It("should import many values", func() {
+			var many []*oim.Value
+			for i := 0; i < 1000; i++ {
+				many = append(many, &oim.Value{Path: fmt.Sprintf("host-%d/pci", i), Value: "0000:0003:20.1"})
+			}
+			other := newRegistry()
+			importer := &importStream{ctx: adminCtx, requests: []*oim.ImportRequest{{Values: many}}}
+			err := other.Import(importer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(importer.reply.Revision).To(Equal(int64(10)))
+			reply, err := other.GetValues(adminCtx, &oim.GetValuesRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.GetValues()).To(HaveLen(1000))
+		})
+
+		It("should reject invalid values", func() {
+			for _, invalid := range [][]*oim.Value{
+				{{Path: "host-1/pci"}},
+				{{Path: "/", Value: "foo"}},
+				{{Path: "../foo", Value: "foo"}},
+				{{Path: "host-1/pci", Value: "foo"}, {Path: "/host-1/pci/", Value: "bar"}},
+			} {
+				importer := &importStream{ctx: adminCtx, requests: []*oim.ImportRequest{{Values: invalid}}}
+				err := registry.Import(importer)
+				Expect(err).To(HaveOccurred(), "%v", invalid)
+				Expect(status.Code(err)).To(Equal(codes.InvalidArgument), "%v", invalid)
+			}
+			Expect(values(registry)).To(HaveLen(3))
+		})
+	})
+})
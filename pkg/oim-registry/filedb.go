@@ -0,0 +1,406 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimregistry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/intel/oim/pkg/log"
+)
+
+// FileRegistryDB is a RegistryDB which holds on to a file and
+// therefore must be closed when no longer needed.
+type FileRegistryDB interface {
+	RegistryDB
+	Close() error
+}
+
+// fileRegistryDB keeps all entries in memory and additionally
+// appends each modification to a log file. Each record is a single
+// line with a CRC32 checksum followed by a JSON object. A torn write
+// at the end of the file (for example, after a power loss) is
+// detected via the missing newline or wrong checksum and gets
+// truncated when loading the file. A failed append while running
+// gets truncated right away, and if that is not possible, the file
+// is not written to anymore.
+//
+// The file gets rewritten with just the current entries once it has
+// grown too large compared to the amount of live data. The new file
+// is written under a temporary name and then atomically renamed, so
+// there always is a complete copy of the data on disk.
+type fileRegistryDB struct {
+	memRegistryDB
+
+	path    string
+	file    *os.File
+	records int
+}
+
+// fileRecord is the content of one line in the log file. An empty
+// Value removes the entry. A record without Key only stores the
+// current revision, which is necessary after compaction.
+type fileRecord struct {
+	Key      string `json:"k,omitempty"`
+	Value    string `json:"v,omitempty"`
+	Revision int64  `json:"r,omitempty"`
+	// Expires is the time in Unix nanoseconds when an entry
+	// with a TTL needs to be removed.
+	Expires int64 `json:"e,omitempty"`
+	// Changes contains all records of a transaction, which
+	// are stored as a single line to make them atomic.
+	Changes []fileRecord `json:"c,omitempty"`
+}
+
+// compactMinRecords avoids rewriting small files too often.
+const compactMinRecords = 1000
+
+// NewFileRegistryDB opens or creates the database file. The file is
+// locked against concurrent use by other processes until Close is
+// called.
+func NewFileRegistryDB(path string) (FileRegistryDB, error) {
+	f := &fileRegistryDB{
+		memRegistryDB: memRegistryDB{db: make(map[string]Entry)},
+		path:          path,
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "open registry database")
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close() // nolint: gosec
+		return nil, errors.Wrapf(err, "lock registry database %s", path)
+	}
+	f.file = file
+
+	// Expired entries get removed by timers as soon as we
+	// release the mutex.
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.load(); err != nil {
+		f.close()
+		return nil, errors.Wrapf(err, "load registry database %s", path)
+	}
+	if err := f.maybeCompact(); err != nil {
+		f.close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// load replays the log file and leaves the file offset at the end of
+// the last valid record.
+func (f *fileRegistryDB) load() error {
+	reader := bufio.NewReader(f.file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.L().Warnw("truncating incomplete record", "file", f.path, "offset", offset)
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		record, err := decodeRecord(line)
+		if err != nil {
+			// Only the last record may have been
+			// corrupted by an interrupted write. Anything
+			// else indicates a real problem that we
+			// shouldn't paper over.
+			if _, err2 := reader.Peek(1); err2 != io.EOF {
+				return errors.Wrapf(err, "record at offset %d", offset)
+			}
+			log.L().Warnw("truncating corrupted record", "file", f.path, "offset", offset, "error", err)
+			break
+		}
+		f.apply(record)
+		f.records++
+		offset += int64(len(line))
+	}
+	if err := f.file.Truncate(offset); err != nil {
+		return err
+	}
+	_, err := f.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (f *fileRegistryDB) apply(record fileRecord) {
+	for _, change := range record.Changes {
+		f.apply(change)
+	}
+	if record.Key == "" {
+		if record.Revision > f.revision {
+			f.revision = record.Revision
+		}
+		return
+	}
+	f.update(record.Key, record.Value, record.Revision)
+	var at time.Time
+	if record.Expires != 0 {
+		at = time.Unix(0, record.Expires)
+	}
+	key := record.Key
+	f.expireAt(key, at, func() {
+		if err := f.store(context.Background(), key, "", time.Time{}); err != nil {
+			log.L().Warnw("remove expired registry entry", "key", key, "error", err)
+		}
+	})
+}
+
+func encodeRecord(record fileRecord) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(data), data)), nil
+}
+
+func decodeRecord(line []byte) (fileRecord, error) {
+	var record fileRecord
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	parts := bytes.SplitN(line, []byte(" "), 2)
+	if len(parts) != 2 {
+		return record, errors.New("missing checksum")
+	}
+	var checksum uint32
+	if _, err := fmt.Sscanf(string(parts[0]), "%08x", &checksum); err != nil {
+		return record, errors.Wrap(err, "parse checksum")
+	}
+	if checksum != crc32.ChecksumIEEE(parts[1]) {
+		return record, errors.New("checksum mismatch")
+	}
+	if err := json.Unmarshal(parts[1], &record); err != nil {
+		return record, errors.Wrap(err, "parse record")
+	}
+	return record, nil
+}
+
+func (f *fileRegistryDB) Store(ctx context.Context, controllerID, address string, ttl time.Duration, expected int64) (int64, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.check(controllerID, expected); err != nil {
+		return 0, err
+	}
+	var at time.Time
+	if ttl != 0 && address != "" {
+		at = time.Now().Add(ttl)
+	}
+	if err := f.store(ctx, controllerID, address, at); err != nil {
+		return 0, err
+	}
+	return f.revision, nil
+}
+
+func (f *fileRegistryDB) Txn(ctx context.Context, guards []Guard, changes []Change) (int64, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return 0, errors.New("registry database closed")
+	}
+	for _, guard := range guards {
+		if err := f.check(guard.Key, guard.Revision); err != nil {
+			return 0, err
+		}
+	}
+	for _, change := range changes {
+		if err := f.check(change.Key, change.Expected); err != nil {
+			return 0, err
+		}
+	}
+	var txn fileRecord
+	now := time.Now()
+	for _, change := range changes {
+		if !f.changes(change.Key, change.Value) {
+			continue
+		}
+		record := fileRecord{Key: change.Key, Value: change.Value, Revision: f.revision + 1}
+		if change.TTL != 0 && change.Value != "" {
+			record.Expires = now.Add(change.TTL).UnixNano()
+		}
+		txn.Changes = append(txn.Changes, record)
+	}
+	if len(txn.Changes) > 0 {
+		if err := f.commit(ctx, txn); err != nil {
+			return 0, err
+		}
+	}
+	return f.revision, nil
+}
+
+// store writes one change and then applies it. Must be called while
+// holding the mutex.
+func (f *fileRegistryDB) store(ctx context.Context, key, value string, at time.Time) error {
+	if f.file == nil {
+		return errors.New("registry database closed")
+	}
+	if !f.changes(key, value) {
+		return nil
+	}
+	record := fileRecord{Key: key, Value: value, Revision: f.revision + 1}
+	if !at.IsZero() {
+		record.Expires = at.UnixNano()
+	}
+	return f.commit(ctx, record)
+}
+
+// commit writes one record and then applies it. Must be called while
+// holding the mutex.
+func (f *fileRegistryDB) commit(ctx context.Context, record fileRecord) error {
+	data, err := encodeRecord(record)
+	if err != nil {
+		return err
+	}
+	// The in-memory state is only updated once the change is
+	// on disk, otherwise a failed write would be visible to
+	// clients until the next restart.
+	if err := f.write(data); err != nil {
+		return err
+	}
+	f.apply(record)
+	f.records++
+	// The change itself is safe at this point, so a failed
+	// compaction is not reported to the caller. It will be
+	// tried again after the next change.
+	if err := f.maybeCompact(); err != nil {
+		log.FromContext(ctx).Warnw("registry database", "error", err)
+	}
+	return nil
+}
+
+// write appends and syncs one record. A partially written record
+// gets removed again, because otherwise appending more records
+// would turn it into corruption in the middle of the file.
+func (f *fileRegistryDB) write(data []byte) error {
+	offset, err := f.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrap(err, "write registry database")
+	}
+	_, err = f.file.Write(data)
+	if err == nil {
+		err = f.file.Sync()
+	}
+	if err != nil {
+		err2 := f.file.Truncate(offset)
+		if err2 == nil {
+			_, err2 = f.file.Seek(offset, io.SeekStart)
+		}
+		if err2 != nil {
+			// Appending after the partial record is not
+			// safe. Stop using the file, loading it again
+			// will drop the partial record.
+			log.L().Errorw("registry database unusable after failed write", "file", f.path, "error", err2)
+			f.file.Close() // nolint: gosec
+			f.file = nil
+		}
+		return errors.Wrap(err, "write registry database")
+	}
+	return nil
+}
+
+// maybeCompact rewrites the file if it contains many obsolete
+// records. Must be called while holding the mutex.
+func (f *fileRegistryDB) maybeCompact() error {
+	if f.records < compactMinRecords || f.records < 2*len(f.db) {
+		return nil
+	}
+	if err := f.compact(); err != nil {
+		return errors.Wrap(err, "compact registry database")
+	}
+	return nil
+}
+
+func (f *fileRegistryDB) compact() error {
+	tmpPath := f.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	success := false
+	defer func() {
+		if !success {
+			tmp.Close()        // nolint: gosec
+			os.Remove(tmpPath) // nolint: gosec
+		}
+	}()
+	// Lock before the file becomes visible under its final name.
+	if err := syscall.Flock(int(tmp.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	records := []fileRecord{{Revision: f.revision}}
+	for key, entry := range f.db {
+		record := fileRecord{Key: key, Value: entry.Value, Revision: entry.Revision}
+		if e := f.expirations[key]; e != nil {
+			record.Expires = e.at.UnixNano()
+		}
+		records = append(records, record)
+	}
+	for _, record := range records {
+		data, err := encodeRecord(record)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return err
+	}
+	success = true
+	// Make the rename itself durable.
+	if dir, err := os.Open(filepath.Dir(f.path)); err == nil {
+		dir.Sync()  // nolint: gosec
+		dir.Close() // nolint: gosec
+	}
+	f.file.Close() // nolint: gosec
+	f.file = tmp
+	f.records = len(records)
+	return nil
+}
+
+func (f *fileRegistryDB) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.close()
+}
+
+// close must be called while holding the mutex.
+func (f *fileRegistryDB) close() error {
+	for key := range f.expirations {
+		f.expireAt(key, time.Time{}, nil)
+	}
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -7,46 +7,210 @@ SPDX-License-Identifier: Apache-2.0
 package oimregistry
 
 import (
+	"context"
 	"sync"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 // memRegistryDB implements an in-memory DB for Registry. Each call is
 // protected against concurrent access via locking.
 type memRegistryDB struct {
-	db    map[string]string
-	mutex sync.Mutex
+	db          map[string]Entry
+	revision    int64
+	watchers    map[chan WatchEvent]bool
+	expirations map[string]*expiration
+	mutex       sync.Mutex
+}
+
+// expiration tracks when an entry with a TTL needs to be removed.
+type expiration struct {
+	at    time.Time
+	timer *time.Timer
 }
 
+// watchBuffer is the number of events that may be pending for a
+// watcher before the watcher gets aborted.
+const watchBuffer = 100
+
 // NewMemRegistryDB constructs a new in-memory database.
 func NewMemRegistryDB() RegistryDB {
 	m := &memRegistryDB{}
-	m.db = make(map[string]string)
+	m.db = make(map[string]Entry)
 	return m
 }
 
-func (m *memRegistryDB) Store(controllerID, address string) {
+func (m *memRegistryDB) Store(ctx context.Context, controllerID, address string, ttl time.Duration, expected int64) (int64, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	if address == "" {
-		delete(m.db, controllerID)
+	if err := m.check(controllerID, expected); err != nil {
+		return 0, err
+	}
+	if m.changes(controllerID, address) {
+		m.update(controllerID, address, m.revision+1)
+	}
+	m.expireAfter(controllerID, address, ttl)
+	return m.revision, nil
+}
+
+func (m *memRegistryDB) Txn(ctx context.Context, guards []Guard, changes []Change) (int64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, guard := range guards {
+		if err := m.check(guard.Key, guard.Revision); err != nil {
+			return 0, err
+		}
+	}
+	for _, change := range changes {
+		if err := m.check(change.Key, change.Expected); err != nil {
+			return 0, err
+		}
+	}
+	revision := m.revision + 1
+	for _, change := range changes {
+		if m.changes(change.Key, change.Value) {
+			m.update(change.Key, change.Value, revision)
+		}
+		m.expireAfter(change.Key, change.Value, change.TTL)
+	}
+	return m.revision, nil
+}
+
+// expireAfter sets or clears the expiration for an entry that was
+// just stored. Must be called while holding the mutex.
+func (m *memRegistryDB) expireAfter(key, value string, ttl time.Duration) {
+	var at time.Time
+	if ttl != 0 && value != "" {
+		at = time.Now().Add(ttl)
+	}
+	m.expireAt(key, at, func() {
+		m.update(key, "", m.revision+1)
+	})
+}
+
+// check compares the revision of the entry against the expected
+// revision. Must be called while holding the mutex.
+func (m *memRegistryDB) check(key string, expected int64) error {
+	if expected == AnyRevision {
+		return nil
+	}
+	if revision := m.db[key].Revision; revision != expected {
+		return errors.Wrapf(ErrConflict, "%q: expected revision %d, have %d", key, expected, revision)
+	}
+	return nil
+}
+
+// expireAt arranges for the removal of the entry at the given time by
+// calling the remove function while holding the mutex. A zero time
+// cancels a pending removal. Must be called while holding the mutex.
+func (m *memRegistryDB) expireAt(key string, at time.Time, remove func()) {
+	if e := m.expirations[key]; e != nil {
+		e.timer.Stop()
+		delete(m.expirations, key)
+	}
+	if at.IsZero() {
+		return
+	}
+	if m.expirations == nil {
+		m.expirations = make(map[string]*expiration)
+	}
+	e := &expiration{at: at}
+	e.timer = time.AfterFunc(time.Until(at), func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		// Stopping the timer does not help when it already
+		// fired and we were waiting for the mutex, so check
+		// whether we are still the current expiration.
+		if m.expirations[key] != e {
+			return
+		}
+		delete(m.expirations, key)
+		remove()
+	})
+	m.expirations[key] = e
+}
+
+// entry returns the current entry for the key. Must be called while
+// holding the mutex.
+func (m *memRegistryDB) entry(key string) Entry {
+	entry := m.db[key]
+	entry.Temporary = m.expirations[key] != nil
+	return entry
+}
+
+// changes returns false if storing the value would not modify the
+// DB, i.e. when removing a non-existent entry.
+func (m *memRegistryDB) changes(key, value string) bool {
+	_, exists := m.db[key]
+	return value != "" || exists
+}
+
+// update modifies the DB and notifies watchers. Must be called while
+// holding the mutex.
+func (m *memRegistryDB) update(key, value string, revision int64) {
+	if value == "" {
+		delete(m.db, key)
 	} else {
-		m.db[controllerID] = address
+		m.db[key] = Entry{Value: value, Revision: revision}
+	}
+	if revision > m.revision {
+		m.revision = revision
+	}
+	event := WatchEvent{Key: key, Value: value, Revision: revision}
+	for watcher := range m.watchers {
+		select {
+		case watcher <- event:
+		default:
+			// Blocking here would block all
+			// writers, so instead the watcher
+			// gets dropped.
+			delete(m.watchers, watcher)
+			close(watcher)
+		}
 	}
 }
-func (m *memRegistryDB) Lookup(controllerID string) (address string) {
+func (m *memRegistryDB) Lookup(ctx context.Context, controllerID string) (address string, err error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	return m.db[controllerID]
+	return m.db[controllerID].Value, nil
 }
-func (m *memRegistryDB) Foreach(callback func(controllerID, address string) bool) {
+func (m *memRegistryDB) Foreach(ctx context.Context, callback func(key string, entry Entry) bool) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	for controllerID, address := range m.db {
-		if !callback(controllerID, address) {
-			return
+	for key := range m.db {
+		if !callback(key, m.entry(key)) {
+			return nil
 		}
 	}
+	return nil
+}
+
+func (m *memRegistryDB) Watch(ctx context.Context) (map[string]Entry, int64, <-chan WatchEvent, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entries := make(map[string]Entry, len(m.db))
+	for key := range m.db {
+		entries[key] = m.entry(key)
+	}
+	watcher := make(chan WatchEvent, watchBuffer)
+	if m.watchers == nil {
+		m.watchers = make(map[chan WatchEvent]bool)
+	}
+	m.watchers[watcher] = true
+	go func() {
+		<-ctx.Done()
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		if m.watchers[watcher] {
+			delete(m.watchers, watcher)
+			close(watcher)
+		}
+	}()
+	return entries, m.revision, watcher, nil
 }
@@ -0,0 +1,90 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimregistry
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/intel/oim/pkg/spec/oim/v0"
+)
+
+var proxiedCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "oim",
+	Subsystem: "registry",
+	Name:      "proxied_calls_total",
+	Help:      "Number of calls forwarded to controllers, by controller ID, method and gRPC status code. Calls which were not forwarded have an empty controller ID and method \"other\".",
+}, []string{"controllerid", "method", "code"})
+
+func init() {
+	prometheus.MustRegister(proxiedCalls)
+}
+
+// otherMethod is the method label for calls which were not forwarded
+// or which are not part of the Controller service.
+const otherMethod = "other"
+
+// controllerMethods contains the full names of all methods of the
+// Controller service. Only those are used as label values, because
+// clients may call arbitrary methods.
+var controllerMethods = func() map[string]bool {
+	methods := map[string]bool{}
+	t := reflect.TypeOf((*oim.ControllerServer)(nil)).Elem()
+	for i := 0; i < t.NumMethod(); i++ {
+		methods["/oim.v0.Controller/"+t.Method(i).Name] = true
+	}
+	return methods
+}()
+
+// proxiedCall is stored in the context of a proxied call. The
+// stream director sets the controller ID once it has accepted the
+// call and found the controller.
+type proxiedCall struct {
+	controllerID string
+}
+
+type proxiedCallKey struct{}
+
+// setProxiedController records that the call is forwarded to the
+// controller.
+func setProxiedController(ctx context.Context, controllerID string) {
+	if call, ok := ctx.Value(proxiedCallKey{}).(*proxiedCall); ok {
+		call.controllerID = controllerID
+	}
+}
+
+// countProxy wraps the proxy handler such that each proxied call
+// gets counted once it is complete. The labels only contain
+// values which are under the control of the registry, to limit the
+// number of time series.
+func countProxy(handler grpc.StreamHandler) grpc.StreamHandler {
+	return func(srv interface{}, stream grpc.ServerStream) error {
+		call := &proxiedCall{}
+		ctx := context.WithValue(stream.Context(), proxiedCallKey{}, call)
+		err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+		method, _ := grpc.MethodFromServerStream(stream)
+		if call.controllerID == "" || !controllerMethods[method] {
+			method = otherMethod
+		}
+		proxiedCalls.WithLabelValues(call.controllerID, method, status.Code(err).String()).Inc()
+		return err
+	}
+}
+
+// contextStream replaces the context of a stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (c *contextStream) Context() context.Context {
+	return c.ctx
+}
@@ -0,0 +1,310 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimregistry
+
+import (
+	"context"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/fsnotify/fsnotify.v1"
+	"gopkg.in/yaml.v2"
+
+	"github.com/intel/oim/pkg/log"
+)
+
+// Verbs that can be granted by a policy.
+const (
+	// VerbGet allows reading values (GetValues, WatchValues,
+	// revision guards in SetValues).
+	VerbGet = "get"
+	// VerbSet allows setting and removing values (SetValue,
+	// SetValues).
+	VerbSet = "set"
+	// VerbProxy allows calling a controller through the
+	// registry.
+	VerbProxy = "proxy"
+	// VerbAdmin allows exporting and importing the entire
+	// registry content. Paths and controllers of the rule are
+	// ignored for it.
+	VerbAdmin = "admin"
+)
+
+// Policy determines what clients are allowed to do, based on the
+// identity in their certificate (see oimcommon.CertIdentity).
+//
+// Subjects are matched against the identity in its <role>.<id> form,
+// which is the same as the traditional common name. A '*' in a subject
+// pattern matches any sequence of characters. What it matched can be
+// referenced as ${1}, ${2}, ... in the rules of the roles that are
+// granted to the subject.
+//
+// Path patterns are matched against registry paths element by element
+// using path.Match, with the addition that a "**" element matches
+// zero or more elements. Controller patterns are matched against the
+// controller ID with path.Match.
+type Policy struct {
+	Roles    map[string][]Rule `yaml:"roles"`
+	Subjects []Subject         `yaml:"subjects"`
+
+	subjects []*regexp.Regexp
+}
+
+// Rule grants verbs for certain paths (get, set) or controllers
+// (proxy).
+type Rule struct {
+	Verbs       []string `yaml:"verbs"`
+	Paths       []string `yaml:"paths"`
+	Controllers []string `yaml:"controllers"`
+}
+
+// Subject grants roles to all clients whose identity matches the
+// pattern.
+type Subject struct {
+	CN    string   `yaml:"cn"`
+	Roles []string `yaml:"roles"`
+}
+
+// DefaultPolicyYAML is used when no other policy is configured:
+// admins can read, write, export and import everything, a controller can set its own
+// address, a host can reach the controller with the same ID and
+// everyone can read.
+const DefaultPolicyYAML = `
+roles:
+  admin:
+  - verbs: [get, set, admin]
+    paths: ["**"]
+  controller:
+  - verbs: [set]
+    paths: ["${1}/address"]
+  host:
+  - verbs: [proxy]
+    controllers: ["${1}"]
+  reader:
+  - verbs: [get]
+    paths: ["**"]
+subjects:
+- cn: user.admin
+  roles: [admin]
+- cn: controller.*
+  roles: [controller]
+- cn: host.*
+  roles: [host]
+- cn: "*"
+  roles: [reader]
+`
+
+// DefaultPolicy returns the parsed DefaultPolicyYAML.
+func DefaultPolicy() *Policy {
+	policy, err := ParsePolicy([]byte(DefaultPolicyYAML))
+	if err != nil {
+		panic(err)
+	}
+	return policy
+}
+
+// ParsePolicy parses and validates a policy in YAML format.
+func ParsePolicy(data []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, errors.Wrap(err, "parse policy")
+	}
+	for name, rules := range policy.Roles {
+		for _, rule := range rules {
+			for _, verb := range rule.Verbs {
+				switch verb {
+				case VerbGet, VerbSet, VerbProxy, VerbAdmin:
+				default:
+					return nil, errors.Errorf("role %q: unknown verb %q", name, verb)
+				}
+			}
+			for _, pattern := range append(rule.Paths, rule.Controllers...) {
+				// Check the syntax with placeholders replaced.
+				if _, err := path.Match(expandPattern(pattern, nil), ""); err != nil {
+					return nil, errors.Wrapf(err, "role %q: pattern %q", name, pattern)
+				}
+			}
+		}
+	}
+	for _, subject := range policy.Subjects {
+		for _, role := range subject.Roles {
+			if _, ok := policy.Roles[role]; !ok {
+				return nil, errors.Errorf("subject %q: unknown role %q", subject.CN, role)
+			}
+		}
+		parts := strings.Split(subject.CN, "*")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		re, err := regexp.Compile("^" + strings.Join(parts, "(.*)") + "$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "subject %q", subject.CN)
+		}
+		policy.subjects = append(policy.subjects, re)
+	}
+	return policy, nil
+}
+
+// LoadPolicy reads and parses a policy file.
+func LoadPolicy(filename string) (*Policy, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "read policy")
+	}
+	policy, err := ParsePolicy(data)
+	return policy, errors.Wrap(err, filename)
+}
+
+// Allowed checks whether the client with the given identity may
+// apply the verb to the target, which is a registry path for get and
+// set, a controller ID for proxy and ignored for admin.
+func (p *Policy) Allowed(cn, verb, target string) bool {
+	for i, subject := range p.Subjects {
+		match := p.subjects[i].FindStringSubmatch(cn)
+		if match == nil {
+			continue
+		}
+		for _, role := range subject.Roles {
+			for _, rule := range p.Roles[role] {
+				if rule.allows(verb, target, match[1:]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (r Rule) allows(verb, target string, captures []string) bool {
+	found := false
+	for _, v := range r.Verbs {
+		if v == verb {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+	if verb == VerbAdmin {
+		return true
+	}
+	if verb == VerbProxy {
+		for _, pattern := range r.Controllers {
+			if ok, _ := path.Match(expandPattern(pattern, captures), target); ok {
+				return true
+			}
+		}
+		return false
+	}
+	for _, pattern := range r.Paths {
+		if matchPath(strings.Split(expandPattern(pattern, captures), "/"), strings.Split(target, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+var placeholder = regexp.MustCompile(`\$\{[0-9]+\}`)
+
+// expandPattern replaces ${n} with the escaped n-th capture, or with
+// the empty string if there is no such capture.
+func expandPattern(pattern string, captures []string) string {
+	return placeholder.ReplaceAllStringFunc(pattern, func(ref string) string {
+		n, _ := strconv.Atoi(ref[2 : len(ref)-1])
+		if n < 1 || n > len(captures) {
+			return ""
+		}
+		return escapePattern(captures[n-1])
+	})
+}
+
+// escapePattern ensures that path.Match treats all characters in the
+// string literally.
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func matchPath(pattern, elements []string) bool {
+	if len(pattern) == 0 {
+		return len(elements) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(elements); i++ {
+			if matchPath(pattern[1:], elements[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elements) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], elements[0]); !ok {
+		return false
+	}
+	return matchPath(pattern[1:], elements[1:])
+}
+
+// WatchPolicyFile loads the policy and then keeps reloading it
+// whenever something changes in the directory containing the file,
+// which also covers files that get replaced instead of modified
+// (editors, Kubernetes config maps). Each successfully loaded policy
+// is passed to the update callback, starting with the initial one.
+// Errors while reloading are logged and the previous policy remains
+// in use. Watching stops when the context is done.
+func WatchPolicyFile(ctx context.Context, filename string, update func(*Policy)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "watch policy")
+	}
+	if err := watcher.Add(filepath.Dir(filename)); err != nil {
+		watcher.Close() // nolint: gosec
+		return errors.Wrap(err, "watch policy")
+	}
+	policy, err := LoadPolicy(filename)
+	if err != nil {
+		watcher.Close() // nolint: gosec
+		return err
+	}
+	update(policy)
+
+	go func() {
+		defer watcher.Close()
+		logger := log.FromContext(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-watcher.Errors:
+				logger.Warnw("watch policy", "error", err)
+			case <-watcher.Events:
+				policy, err := LoadPolicy(filename)
+				if err != nil {
+					logger.Warnw("reload policy", "error", err)
+					continue
+				}
+				logger.Infow("reloaded policy", "file", filename)
+				update(policy)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oimregistry_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/intel/oim/pkg/oim-common"
+	"github.com/intel/oim/pkg/oim-registry"
+	"github.com/intel/oim/pkg/spec/oim/v0"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Policy", func() {
+	type check struct {
+		cn, verb, target string
+		allowed          bool
+	}
+	testPolicy := func(policy *oimregistry.Policy, checks []check) {
+		for _, c := range checks {
+			Expect(policy.Allowed(c.cn, c.verb, c.target)).To(Equal(c.allowed), "%s %s %s", c.cn, c.verb, c.target)
+		}
+	}
+
+	It("should have the traditional defaults", func() {
+		testPolicy(oimregistry.DefaultPolicy(), []check{
+			{"user.admin", "set", "host-0/pci", true},
+			{"user.admin", "get", "host-0/pci", true},
+			{"user.admin", "proxy", "host-0", false},
+			{"user.admin", "admin", "", true},
+			{"controller.host-0", "set", "host-0/address", true},
+			{"controller.host-0", "set", "host-0/pci", false},
+			{"controller.host-0", "set", "host-1/address", false},
+			{"controller.host-0", "set", "host-0/address/foo", false},
+			{"controller.host-0", "get", "host-1/address", true},
+			{"host.host-0", "proxy", "host-0", true},
+			{"host.host-0", "proxy", "host-1", false},
+			{"host.host-0", "set", "host-0/address", false},
+			{"host.*", "proxy", "host-1", false},
+			{"foobar", "get", "host-0/pci", true},
+			{"foobar", "set", "host-0/pci", false},
+			{"foobar", "admin", "", false},
+			{"controller.host-0", "admin", "", false},
+		})
+	})
+
+	It("should support custom rules", func() {
+		policy, err := oimregistry.ParsePolicy([]byte(`
+roles:
+  operator:
+  - verbs: [get]
+    paths: ["**"]
+  site-admin:
+  - verbs: [get, set]
+    paths: ["${1}/**"]
+  multi-host:
+  - verbs: [proxy]
+    controllers: ["${1}-*"]
+  controller:
+  - verbs: [set]
+    paths: ["*/${1}/address"]
+subjects:
+- cn: user.operator
+  roles: [operator]
+- cn: user.admin-*
+  roles: [site-admin]
+- cn: host.*
+  roles: [multi-host]
+- cn: controller.*
+  roles: [controller]
+`))
+		Expect(err).NotTo(HaveOccurred())
+		testPolicy(policy, []check{
+			{"user.operator", "get", "site-a/host-0/pci", true},
+			{"user.operator", "set", "site-a/host-0/pci", false},
+			{"user.admin-site-a", "set", "site-a/host-0/pci", true},
+			{"user.admin-site-a", "set", "site-a", true},
+			{"user.admin-site-a", "set", "site-b/host-0/pci", false},
+			{"user.admin-site-a", "get", "site-b/host-0/pci", false},
+			{"host.rack-1", "proxy", "rack-1-controller-0", true},
+			{"host.rack-1", "proxy", "rack-1-controller-1", true},
+			{"host.rack-1", "proxy", "rack-2-controller-0", false},
+			{"controller.c-0", "set", "site-a/c-0/address", true},
+			{"controller.c-0", "set", "site-a/c-1/address", false},
+			// Special characters in the common name are not patterns.
+			{"controller.*", "set", "site-a/c-1/address", false},
+			{"someone", "get", "site-a/host-0/pci", false},
+		})
+	})
+
+	It("should detect errors", func() {
+		for _, policy := range []string{
+			"roles:\n  admin:\n  - verbs: [delete]\n",
+			"subjects:\n- cn: user.admin\n  roles: [admin]\n",
+			"roles:\n  admin:\n  - verbs: [get]\n    paths: [\"[\"]\n",
+			"foo: bar\n",
+		} {
+			_, err := oimregistry.ParsePolicy([]byte(policy))
+			Expect(err).To(HaveOccurred(), policy)
+		}
+	})
+
+	Describe("in registry", func() {
+		var (
+			ctx      = context.Background()
+			registry oimregistry.RegistryServer
+		)
+
+		BeforeEach(func() {
+			tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+			Expect(err).NotTo(HaveOccurred())
+			registry, err = oimregistry.New(oimregistry.TLS(tlsConfig))
+			Expect(err).NotTo(HaveOccurred())
+			for _, value := range []*oim.Value{
+				{Path: "site-a/pci", Value: "0000:0003:20.1"},
+				{Path: "site-b/pci", Value: "0000:0004:30.2"},
+			} {
+				_, err := registry.SetValue(oimregistry.RegistryClientContext(ctx, "user.admin"), &oim.SetValueRequest{Value: value})
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should be applied", func() {
+			policy, err := oimregistry.ParsePolicy([]byte(`
+roles:
+  site-admin:
+  - verbs: [get, set]
+    paths: ["${1}/**"]
+subjects:
+- cn: user.admin-*
+  roles: [site-admin]
+`))
+			Expect(err).NotTo(HaveOccurred())
+			registry.SetPolicy(policy)
+			siteCtx := oimregistry.RegistryClientContext(ctx, "user.admin-site-a")
+
+			values, err := registry.GetValues(siteCtx, &oim.GetValuesRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values.Values).To(HaveLen(1))
+			Expect(values.Values[0].Path).To(Equal("site-a/pci"))
+
+			_, err = registry.SetValue(siteCtx, &oim.SetValueRequest{Value: &oim.Value{Path: "site-a/pci"}})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = registry.SetValue(siteCtx, &oim.SetValueRequest{Value: &oim.Value{Path: "site-b/pci"}})
+			Expect(err).To(HaveOccurred())
+			Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+
+			// The traditional admin is no longer special.
+			_, err = registry.SetValue(oimregistry.RegistryClientContext(ctx, "user.admin"), &oim.SetValueRequest{Value: &oim.Value{Path: "site-b/pci"}})
+			Expect(err).To(HaveOccurred())
+			Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+		})
+
+		It("should be reloaded", func() {
+			tmpDir, err := ioutil.TempDir("", "oim-registry-test")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(tmpDir)
+			filename := filepath.Join(tmpDir, "policy.yaml")
+			readOnly := `
+roles:
+  operator:
+  - verbs: [get]
+    paths: ["**"]
+subjects:
+- cn: user.*
+  roles: [operator]
+`
+			err = ioutil.WriteFile(filename, []byte(readOnly), 0600)
+			Expect(err).NotTo(HaveOccurred())
+
+			watchCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			err = oimregistry.WatchPolicyFile(watchCtx, filename, registry.SetPolicy)
+			Expect(err).NotTo(HaveOccurred())
+
+			set := func() error {
+				_, err := registry.SetValue(oimregistry.RegistryClientContext(ctx, "user.admin"), &oim.SetValueRequest{Value: &oim.Value{Path: "site-a/pci", Value: "0000:0005:20.1"}})
+				return err
+			}
+			Expect(set()).To(HaveOccurred())
+
+			// Invalid content is ignored.
+			err = ioutil.WriteFile(filename, []byte("foo: bar"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+			Consistently(set).Should(HaveOccurred())
+
+			err = ioutil.WriteFile(filename, []byte(oimregistry.DefaultPolicyYAML), 0600)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(set).ShouldNot(HaveOccurred())
+		})
+	})
+})
@@ -9,51 +9,141 @@ package oimregistry
 import (
 	"context"
 	"crypto/tls"
-	"errors"
-	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
 	"github.com/vgough/grpc-proxy/proxy"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
-	"github.com/intel/oim/pkg/log"
 	"github.com/intel/oim/pkg/oim-common"
 	"github.com/intel/oim/pkg/spec/oim/v0"
 )
 
 // RegistryDB stores the mapping from controller ID to gRPC address of
-// the controller.
+// the controller. Implementations which store the data remotely can
+// fail, therefore all operations take a context and return an error.
 type RegistryDB interface {
-	// Store a new mapping. Empty address removes the entry.
-	Store(controllerID, address string)
+	// Store a new mapping. Empty address removes the entry. A
+	// non-zero ttl causes the entry to be removed after that
+	// duration unless it gets stored again before that.
+	//
+	// Unless expected is AnyRevision, the entry is only
+	// modified if its current revision matches the expected
+	// one, with zero meaning that the entry must not exist.
+	// Otherwise the returned error wraps ErrConflict.
+	//
+	// Returns the DB revision after the operation.
+	Store(ctx context.Context, controllerID, address string, ttl time.Duration, expected int64) (revision int64, err error)
 
 	// Lookup returns the endpoint or the empty string if not found.
-	Lookup(controllerID string) (address string)
+	Lookup(ctx context.Context, controllerID string) (address string, err error)
 
 	// Foreach iterates over all DB entries until
 	// the callback function returns false.
-	Foreach(func(controllerID, address string) bool)
+	Foreach(ctx context.Context, callback func(key string, entry Entry) bool) error
+
+	// Watch returns all current DB entries together with the
+	// revision of that snapshot. All changes after that revision
+	// are sent via the channel until the context is canceled or
+	// watching fails, for example because the receiver did not
+	// keep up with the changes. The channel gets closed in both
+	// cases.
+	Watch(ctx context.Context) (entries map[string]Entry, revision int64, events <-chan WatchEvent, err error)
 }
 
+// Entry is the content of one RegistryDB entry.
+type Entry struct {
+	// Value is never empty.
+	Value string
+	// Revision is the DB revision of the last modification.
+	Revision int64
+	// Temporary is true for entries which were stored with a
+	// TTL.
+	Temporary bool
+}
+
+// WatchEvent describes one change of a RegistryDB entry.
+type WatchEvent struct {
+	// Key is the path of the modified entry.
+	Key string
+	// Value is the new value, empty if the entry was removed.
+	Value string
+	// Revision is the DB revision created by the change.
+	Revision int64
+}
+
+// TxnRegistryDB is implemented by databases which can apply several
+// changes atomically.
+type TxnRegistryDB interface {
+	RegistryDB
+
+	// Txn applies all changes if and only if all guards and
+	// the expected revisions of all changes match. Otherwise
+	// nothing gets modified and the returned error wraps
+	// ErrConflict. All changes get the same revision, which
+	// is returned.
+	Txn(ctx context.Context, guards []Guard, changes []Change) (revision int64, err error)
+}
+
+// Change describes one modification in a transaction. The fields
+// have the same meaning as the corresponding RegistryDB.Store
+// parameters.
+type Change struct {
+	Key      string
+	Value    string
+	TTL      time.Duration
+	Expected int64
+}
+
+// Guard checks the revision of an entry without modifying it. Zero
+// means that the entry must not exist.
+type Guard struct {
+	Key      string
+	Revision int64
+}
+
+// AnyRevision disables the revision check in RegistryDB.Store.
+const AnyRevision = -1
+
+// ErrConflict is returned by RegistryDB.Store when the entry does not
+// have the expected revision.
+var ErrConflict = errors.New("revision mismatch")
+
 // GetRegistryEntries returns all database entries as a map.
-func GetRegistryEntries(db RegistryDB) map[string]string {
+func GetRegistryEntries(db RegistryDB) (map[string]string, error) {
 	entries := make(map[string]string)
-	db.Foreach(func(key, value string) bool {
-		entries[key] = value
+	err := db.Foreach(context.Background(), func(key string, entry Entry) bool {
+		entries[key] = entry.Value
 		return true
 	})
-	return entries
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
 }
 
 // Registry implements oim.Registry.
 type registry struct {
 	db        RegistryDB
 	tlsConfig *tls.Config
+
+	policy      *Policy
+	policyMutex sync.RWMutex
+
+	maxConns    int
+	idleTimeout time.Duration
+	conns       *connPool
+
+	audit AuditSink
 }
 
 // RegistryServer is the public interface for managing a OIM registry server.
@@ -62,54 +152,171 @@ type RegistryServer interface {
 
 	// Server creates a server as required to run the registry service.
 	Server(endpoint string) (*oimcommon.NonBlockingGRPCServer, func(*grpc.Server))
+
+	// SetPolicy replaces the authorization policy. Can be called
+	// while the server is running.
+	SetPolicy(policy *Policy)
 }
 
+// getPeer returns the <role>.<id> identity of the caller that is
+// used for authorization.
 func getPeer(ctx context.Context) (string, error) {
-	peer, ok := peer.FromContext(ctx)
-	if !ok {
-		return "", status.Error(codes.FailedPrecondition, "cannot determine caller identity")
+	identity, err := oimcommon.PeerIdentity(ctx)
+	if err != nil {
+		return "", status.Error(codes.FailedPrecondition, err.Error())
 	}
-	tlsInfo, ok := peer.AuthInfo.(credentials.TLSInfo)
+	return identity.String(), nil
+}
+
+func (r *registry) SetValue(ctx context.Context, in *oim.SetValueRequest) (reply *oim.SetValueReply, err error) {
+	defer func() {
+		r.auditMutation(ctx, "/oim.v0.Registry/SetValue", in, []string{in.GetValue().GetPath()}, err)
+	}()
+
+	change, err := r.checkChange(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := r.db.Store(ctx, change.Key, change.Value, change.TTL, change.Expected)
+	if err != nil {
+		return nil, storeError(change.Key, err)
+	}
+	r.changed(change.Key)
+	return &oim.SetValueReply{
+		Revision: revision,
+	}, nil
+}
+
+func (r *registry) SetValues(ctx context.Context, in *oim.SetValuesRequest) (reply *oim.SetValuesReply, err error) {
+	defer func() {
+		var paths []string
+		for _, change := range in.GetChanges() {
+			paths = append(paths, change.GetValue().GetPath())
+		}
+		r.auditMutation(ctx, "/oim.v0.Registry/SetValues", in, paths, err)
+	}()
+
+	db, ok := r.db.(TxnRegistryDB)
 	if !ok {
-		return "", status.Error(codes.FailedPrecondition, "no TLS info")
+		return nil, status.Error(codes.Unimplemented, "registry database does not support transactions")
 	}
-	if len(tlsInfo.State.VerifiedChains) == 0 ||
-		len(tlsInfo.State.VerifiedChains[0]) == 0 {
-		return "", status.Error(codes.FailedPrecondition, "cannot determine peer, empty TLS verification chain")
+
+	// Each change must be allowed individually.
+	var changes []Change
+	modified := map[string]bool{}
+	for _, request := range in.GetChanges() {
+		change, err := r.checkChange(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		if modified[change.Key] {
+			return nil, status.Errorf(codes.InvalidArgument, "%q modified more than once", change.Key)
+		}
+		modified[change.Key] = true
+		changes = append(changes, change)
+	}
+	if len(changes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no changes")
+	}
+
+	// Guards only need read permission.
+	policy := r.getPolicy()
+	peer, err := getPeer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var guards []Guard
+	for _, guard := range in.GetGuards() {
+		elements, err := oimcommon.SplitRegistryPath(guard.Path)
+		if err != nil {
+			return nil, err
+		}
+		if len(elements) == 0 {
+			return nil, errors.New("empty path")
+		}
+		key := oimcommon.JoinRegistryPath(elements)
+		if !policy.Allowed(peer, VerbGet, key) {
+			return nil, status.Errorf(codes.PermissionDenied, "caller %q not allowed to get %q", peer, key)
+		}
+		if guard.Revision < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid revision %d", guard.Revision)
+		}
+		guards = append(guards, Guard{
+			Key:      key,
+			Revision: guard.Revision,
+		})
 	}
-	commonName := tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
-	return commonName, nil
+
+	revision, err := db.Txn(ctx, guards, changes)
+	if err != nil {
+		return nil, storeError("transaction", err)
+	}
+	for _, change := range changes {
+		r.changed(change.Key)
+	}
+	return &oim.SetValuesReply{
+		Revision: revision,
+	}, nil
 }
 
-func (r *registry) SetValue(ctx context.Context, in *oim.SetValueRequest) (*oim.SetValueReply, error) {
+// changed must be called after modifying a DB entry. Changes made by
+// other registry instances sharing the same DB are detected when
+// looking up the controller address in the proxy.
+func (r *registry) changed(key string) {
+	elements, _ := oimcommon.SplitRegistryPath(key)
+	if len(elements) == 2 && elements[1] == oimcommon.RegistryAddress {
+		r.conns.invalidate(elements[0])
+	}
+}
+
+// checkChange validates the request and ensures that the caller is
+// allowed to make the change.
+func (r *registry) checkChange(ctx context.Context, in *oim.SetValueRequest) (Change, error) {
 	value := in.GetValue()
 	if value == nil {
-		return nil, errors.New("missing value")
+		return Change{}, errors.New("missing value")
 	}
 
 	// sanitize path
 	elements, err := oimcommon.SplitRegistryPath(value.Path)
 	if err != nil {
-		return nil, err
+		return Change{}, err
 	}
 	if len(elements) == 0 {
-		return nil, errors.New("empty path")
+		return Change{}, errors.New("empty path")
 	}
 	key := oimcommon.JoinRegistryPath(elements)
 
-	// Permission check: admin can set anything, controller only '<controller ID>/address'.
 	peer, err := getPeer(ctx)
 	if err != nil {
-		return nil, err
+		return Change{}, err
+	}
+	if !r.getPolicy().Allowed(peer, VerbSet, key) {
+		return Change{}, status.Errorf(codes.PermissionDenied, "caller %q not allowed to set %q", peer, key)
+	}
+
+	change := Change{
+		Key:      key,
+		Value:    value.Value,
+		TTL:      time.Duration(in.GetTtlSeconds()) * time.Second,
+		Expected: AnyRevision,
 	}
-	allowed := peer == "user.admin" ||
-		peer == "controller."+elements[0] && len(elements) == 2 && elements[1] == oimcommon.RegistryAddress
-	if !allowed {
-		return nil, status.Errorf(codes.PermissionDenied, "caller %q not allowed to set %q", peer, key)
+	if in.GetExpectedRevision() != nil {
+		change.Expected = in.GetExpectedRevision().GetValue()
+		if change.Expected < 0 {
+			return Change{}, status.Errorf(codes.InvalidArgument, "invalid expected revision %d", change.Expected)
+		}
 	}
+	return change, nil
+}
 
-	r.db.Store(key, value.Value)
-	return &oim.SetValueReply{}, nil
+// storeError turns a RegistryDB error into the corresponding gRPC
+// error.
+func storeError(what string, err error) error {
+	if errors.Cause(err) == ErrConflict {
+		return status.Errorf(codes.FailedPrecondition, "store %q: %s", what, err)
+	}
+	return status.Errorf(codes.Unavailable, "store %q: %s", what, err)
 }
 
 func (r *registry) GetValues(ctx context.Context, in *oim.GetValuesRequest) (*oim.GetValuesReply, error) {
@@ -120,30 +327,118 @@ func (r *registry) GetValues(ctx context.Context, in *oim.GetValuesRequest) (*oi
 	}
 	prefix := oimcommon.JoinRegistryPath(elements)
 
-	// Permission check: everyone can read, but we want to at least know that
-	// we have identified a peer (i.e. TLS is active).
-	if _, err := getPeer(ctx); err != nil {
+	// Permission check: entries which the caller is not allowed
+	// to read are silently skipped.
+	peer, err := getPeer(ctx)
+	if err != nil {
 		return nil, err
 	}
+	policy := r.getPolicy()
 
 	out := oim.GetValuesReply{}
-	r.db.Foreach(func(key, value string) bool {
-		if prefix == "" ||
-			strings.HasPrefix(key, prefix) &&
-				(len(key) == len(prefix) ||
-					key[len(prefix)] == '/') {
+	err = r.db.Foreach(ctx, func(key string, entry Entry) bool {
+		if matchesPath(key, prefix) && policy.Allowed(peer, VerbGet, key) {
 			out.Values = append(out.Values,
 				&oim.Value{
-					Path:  key,
-					Value: value,
+					Path:     key,
+					Value:    entry.Value,
+					Revision: entry.Revision,
 				})
 		}
 		// More data please...
 		return true
 	})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "get values: %s", err)
+	}
 	return &out, nil
 }
 
+func (r *registry) WatchValues(in *oim.WatchValuesRequest, stream oim.Registry_WatchValuesServer) error {
+	// sanitize path
+	elements, err := oimcommon.SplitRegistryPath(in.GetPath())
+	if err != nil {
+		return err
+	}
+	prefix := oimcommon.JoinRegistryPath(elements)
+
+	// Permission check: same as for GetValues. The current policy
+	// is used for each event, so policy changes also affect
+	// watches that are already running.
+	ctx := stream.Context()
+	peer, err := getPeer(ctx)
+	if err != nil {
+		return err
+	}
+	readable := func(key string) bool {
+		return matchesPath(key, prefix) && r.getPolicy().Allowed(peer, VerbGet, key)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	entries, revision, events, err := r.db.Watch(ctx)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "watch values: %s", err)
+	}
+	snapshot := oim.WatchValuesReply{
+		Type:     oim.WatchValuesReply_SNAPSHOT,
+		Revision: revision,
+	}
+	for key, entry := range entries {
+		if readable(key) {
+			snapshot.Values = append(snapshot.Values,
+				&oim.Value{
+					Path:     key,
+					Value:    entry.Value,
+					Revision: entry.Revision,
+				})
+		}
+	}
+	sort.Slice(snapshot.Values, func(i, j int) bool {
+		return snapshot.Values[i].Path < snapshot.Values[j].Path
+	})
+	if err := stream.Send(&snapshot); err != nil {
+		return err
+	}
+
+	for event := range events {
+		if !readable(event.Key) {
+			continue
+		}
+		reply := oim.WatchValuesReply{
+			Type: oim.WatchValuesReply_PUT,
+			Values: []*oim.Value{
+				&oim.Value{
+					Path:     event.Key,
+					Value:    event.Value,
+					Revision: event.Revision,
+				},
+			},
+			Revision: event.Revision,
+		}
+		if event.Value == "" {
+			reply.Type = oim.WatchValuesReply_DELETE
+		}
+		if err := stream.Send(&reply); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// The client has to start again with a new snapshot.
+	return status.Error(codes.Unavailable, "watching values aborted")
+}
+
+// matchesPath returns true if the key is the same as the prefix or
+// beneath it. All keys match the empty prefix.
+func matchesPath(key, prefix string) bool {
+	return prefix == "" ||
+		strings.HasPrefix(key, prefix) &&
+			(len(key) == len(prefix) ||
+				key[len(prefix)] == '/')
+}
+
 // StreamDirectory transparently proxies gRPC method calls to the
 // corresponding controller, without keeping connections open.
 func (r *registry) StreamDirector() proxy.StreamDirector {
@@ -171,44 +466,70 @@ func (sd *streamDirector) Connect(ctx context.Context, method string) (context.C
 	}
 	controllerID := controllerIDs[0]
 
-	// Permission check: only the host service with the same
-	// controller ID can contact the controller.
 	peer, err := getPeer(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
-	prefix := "host."
-	if !strings.HasPrefix(peer, prefix) ||
-		peer[len(prefix):] != controllerID {
+	if !sd.r.getPolicy().Allowed(peer, VerbProxy, controllerID) {
 		return nil, nil, status.Errorf(codes.PermissionDenied, "caller %q not allowed to contact controller %q", peer, controllerID)
 	}
 
-	address := sd.r.db.Lookup(controllerID + "/" + oimcommon.RegistryAddress)
+	address, err := sd.r.db.Lookup(ctx, controllerID+"/"+oimcommon.RegistryAddress)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Unavailable, "%s: lookup address: %s", controllerID, err)
+	}
 	if address == "" {
 		return nil, nil, status.Errorf(codes.Unavailable, "%s: no address registered", controllerID)
 	}
 
-	// We check the controller's common name to ensure that we talk to the right service
+	// We check the controller's identity to ensure that we talk to the right service
 	// and not some man-in-the-middle attacker, or simply use the wrong address.
 	outgoingTLS := sd.r.tlsConfig.Clone()
-	outgoingTLS.ServerName = fmt.Sprintf("controller.%s", controllerID)
+	outgoingTLS.ServerName = oimcommon.ControllerIdentity(controllerID).String()
 	creds := credentials.NewTLS(outgoingTLS)
 	opts := oimcommon.ChooseDialOpts(address, grpc.WithCodec(proxy.Codec()), grpc.WithTransportCredentials(creds))
 
-	// Copy the inbound metadata explicitly.
-	outCtx := metadata.NewOutgoingContext(ctx, md.Copy())
-
-	// Make sure we use DialContext so the dialing can be cancelled/time out together with the context.
-	conn, err := grpc.DialContext(ctx, address, opts...)
+	// Copy the inbound metadata explicitly, except for the trace
+	// context of the caller. The client interceptor adds the
+	// span of the incoming call instead, so the controller's
+	// span becomes a child of the registry's span.
+	outMD := md.Copy()
+	stripTraceContext(ctx, outMD)
+	outCtx := metadata.NewOutgoingContext(ctx, outMD)
+
+	// The connection may get reused by other calls, so it must
+	// not be tied to the context of the current call. Dialing
+	// itself does not block, connection errors are reported
+	// when using the connection.
+	conn, err := sd.r.conns.get(controllerID, address, func() (*grpc.ClientConn, error) {
+		return grpc.Dial(address, opts...)
+	})
+	if err == nil {
+		setProxiedController(ctx, controllerID)
+	}
 	return outCtx, conn, err
 }
 
-func (sd *streamDirector) Release(ctx context.Context, conn *grpc.ClientConn) {
-	if err := conn.Close(); err != nil {
-		log.FromContext(ctx).Warnw("closing connection", "error", err)
+// stripTraceContext removes all keys from the metadata which the
+// tracer uses to propagate the span in the context.
+func stripTraceContext(ctx context.Context, md metadata.MD) {
+	sp := opentracing.SpanFromContext(ctx)
+	if sp == nil {
+		return
+	}
+	carrier := opentracing.TextMapCarrier{}
+	if err := sp.Tracer().Inject(sp.Context(), opentracing.HTTPHeaders, carrier); err != nil {
+		return
+	}
+	for key := range carrier {
+		delete(md, strings.ToLower(key))
 	}
 }
 
+func (sd *streamDirector) Release(ctx context.Context, conn *grpc.ClientConn) {
+	sd.r.conns.put(conn)
+}
+
 // Option is the parameter type taken by New.
 type Option func(r *registry) error
 
@@ -228,10 +549,33 @@ func TLS(tlsConfig *tls.Config) Option {
 	}
 }
 
+// Authorization sets the initial policy which determines what
+// clients are allowed to do. The default is DefaultPolicy.
+func Authorization(policy *Policy) Option {
+	return func(r *registry) error {
+		r.policy = policy
+		return nil
+	}
+}
+
+// ProxyConnections configures caching of connections to controllers
+// in the proxy. At most maxConns connections are kept open, each for
+// at most idleTimeout after its last use. Zero disables caching.
+func ProxyConnections(maxConns int, idleTimeout time.Duration) Option {
+	return func(r *registry) error {
+		r.maxConns = maxConns
+		r.idleTimeout = idleTimeout
+		return nil
+	}
+}
+
 // New creates a new instance of the OIM registry.
 func New(options ...Option) (RegistryServer, error) {
 	r := registry{
-		db: NewMemRegistryDB(),
+		db:          NewMemRegistryDB(),
+		policy:      DefaultPolicy(),
+		maxConns:    100,
+		idleTimeout: 5 * time.Minute,
 	}
 	for _, op := range options {
 		err := op(&r)
@@ -242,6 +586,7 @@ func New(options ...Option) (RegistryServer, error) {
 	if r.tlsConfig == nil {
 		return nil, errors.New("transport credentials missing")
 	}
+	r.conns = newConnPool(r.maxConns, r.idleTimeout)
 	return &r, nil
 }
 
@@ -253,9 +598,46 @@ func (r *registry) Server(endpoint string) (*oimcommon.NonBlockingGRPCServer, fu
 		Endpoint: endpoint,
 		ServerOptions: []grpc.ServerOption{
 			grpc.CustomCodec(proxy.Codec()),
-			grpc.UnknownServiceHandler(proxy.TransparentHandler(&streamDirector{r})),
+			grpc.UnknownServiceHandler(countProxy(r.auditProxy(proxy.TransparentHandler(&streamDirector{r})))),
 			grpc.Creds(credentials.NewTLS(r.tlsConfig)),
 		},
+		Health: oimcommon.NewHealthServer(),
 	}
+	server.Health.AddCheck("oim.v0.Registry", r.checkDB)
+	server.Health.Forward = r.forwardHealth
 	return server, service
 }
+
+// forwardHealth passes health checks with controllerid meta data on
+// to the controller, just like the proxy does for other calls. The
+// registry itself only answers health checks without it.
+func (r *registry) forwardHealth(ctx context.Context) (healthpb.HealthClient, context.Context, func(), error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if _, exists := md["controllerid"]; !exists {
+		return nil, nil, nil, nil
+	}
+	sd := &streamDirector{r}
+	outCtx, conn, err := sd.Connect(ctx, "/grpc.health.v1.Health/Check")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return healthpb.NewHealthClient(conn), outCtx, func() { sd.Release(ctx, conn) }, nil
+}
+
+// checkDB ensures that the registry DB can be read.
+func (r *registry) checkDB(ctx context.Context) error {
+	_, err := r.db.Lookup(ctx, "health/"+oimcommon.RegistryAddress)
+	return err
+}
+
+func (r *registry) SetPolicy(policy *Policy) {
+	r.policyMutex.Lock()
+	defer r.policyMutex.Unlock()
+	r.policy = policy
+}
+
+func (r *registry) getPolicy() *Policy {
+	r.policyMutex.RLock()
+	defer r.policyMutex.RUnlock()
+	return r.policy
+}
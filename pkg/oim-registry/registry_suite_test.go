@@ -9,14 +9,21 @@ package oimregistry_test
 import (
 	"testing"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+
 	"github.com/intel/oim/pkg/log/testlog"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
+// tracer records the spans of all components in memory.
+var tracer = mocktracer.New()
+
 func init() {
 	testlog.SetGlobal(GinkgoWriter)
+	opentracing.SetGlobalTracer(tracer)
 }
 
 func TestOIMRegistry(t *testing.T) {
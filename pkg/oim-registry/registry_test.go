@@ -8,17 +8,29 @@ package oimregistry_test
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/types"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/intel/oim/pkg/oim-common"
 	"github.com/intel/oim/pkg/oim-controller"
 	"github.com/intel/oim/pkg/oim-registry"
 	"github.com/intel/oim/pkg/spec/oim/v0"
+	testetcd "github.com/intel/oim/test/pkg/etcd"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -52,14 +64,80 @@ func (m *MockController) CheckMallocBDev(ctx context.Context, in *oim.CheckMallo
 	return &oim.CheckMallocBDevReply{}, nil
 }
 
+// proxyConnections returns the current values of the connection
+// cache hit and miss counters.
+func proxyConnections() (hits, misses float64) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	Expect(err).NotTo(HaveOccurred())
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch family.GetName() {
+			case "oim_registry_proxy_connection_hits_total":
+				hits = metric.GetCounter().GetValue()
+			case "oim_registry_proxy_connection_misses_total":
+				misses = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return
+}
+
+// proxiedCalls returns the current number of proxied calls with the
+// given labels.
+func proxiedCalls(controllerID, method, code string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	Expect(err).NotTo(HaveOccurred())
+	labels := map[string]string{
+		"controllerid": controllerID,
+		"method":       method,
+		"code":         code,
+	}
+	for _, family := range families {
+		if family.GetName() != "oim_registry_proxied_calls_total" {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+// watchStream implements oim.Registry_WatchValuesServer by forwarding
+// all replies to a channel.
+type watchStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	replies chan *oim.WatchValuesReply
+}
+
+func (w *watchStream) Context() context.Context {
+	return w.ctx
+}
+
+func (w *watchStream) Send(reply *oim.WatchValuesReply) error {
+	select {
+	case w.replies <- reply:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
 var _ = Describe("OIM Registry", func() {
 	ctx := context.Background()
 	adminCtx := oimregistry.RegistryClientContext(ctx, "user.admin")
 
 	Describe("storing mapping", func() {
-		It("should work", func() {
-			db := oimregistry.NewMemRegistryDB()
+		testStoring := func(db oimregistry.RegistryDB) {
 			var err error
+			var reply *oim.SetValueReply
 			tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
 			Expect(err).NotTo(HaveOccurred())
 			r, err := oimregistry.New(oimregistry.DB(db), oimregistry.TLS(tlsConfig))
@@ -67,46 +145,49 @@ var _ = Describe("OIM Registry", func() {
 			key1 := "foo/controller-id"
 			value1 := "dns:///1.1.1.1/"
 			expected := map[string]string{key1: value1}
-			_, err = r.SetValue(adminCtx, &oim.SetValueRequest{
+			reply, err = r.SetValue(adminCtx, &oim.SetValueRequest{
 				Value: &oim.Value{
 					Path:  key1,
 					Value: value1,
 				},
 			})
 			Expect(err).NotTo(HaveOccurred())
+			revision1 := reply.Revision
 			Expect(oimregistry.GetRegistryEntries(db)).To(Equal(expected))
 
 			key2 := "foo/pci"
 			value2 := "0000:0003:20.1"
 			expected[key2] = value2
-			_, err = r.SetValue(adminCtx, &oim.SetValueRequest{
+			reply, err = r.SetValue(adminCtx, &oim.SetValueRequest{
 				Value: &oim.Value{
 					Path:  key2,
 					Value: value2,
 				},
 			})
 			Expect(err).NotTo(HaveOccurred())
+			revision2 := reply.Revision
 			Expect(oimregistry.GetRegistryEntries(db)).To(Equal(expected))
 
 			key3 := "bar/pci"
 			value3 := "0000:0004:30.2"
 			expected[key3] = value3
-			_, err = r.SetValue(adminCtx, &oim.SetValueRequest{
+			reply, err = r.SetValue(adminCtx, &oim.SetValueRequest{
 				Value: &oim.Value{
 					Path:  key3,
 					Value: value3,
 				},
 			})
 			Expect(err).NotTo(HaveOccurred())
+			revision3 := reply.Revision
 			Expect(oimregistry.GetRegistryEntries(db)).To(Equal(expected))
 
 			var values *oim.GetValuesReply
 			values, err = r.GetValues(adminCtx, &oim.GetValuesRequest{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(values.Values).To(ConsistOf([]*oim.Value{
-				&oim.Value{Path: key1, Value: value1},
-				&oim.Value{Path: key2, Value: value2},
-				&oim.Value{Path: key3, Value: value3},
+				&oim.Value{Path: key1, Value: value1, Revision: revision1},
+				&oim.Value{Path: key2, Value: value2, Revision: revision2},
+				&oim.Value{Path: key3, Value: value3, Revision: revision3},
 			}))
 
 			values, err = r.GetValues(adminCtx, &oim.GetValuesRequest{
@@ -114,9 +195,9 @@ var _ = Describe("OIM Registry", func() {
 			})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(values.Values).To(ConsistOf([]*oim.Value{
-				&oim.Value{Path: key1, Value: value1},
-				&oim.Value{Path: key2, Value: value2},
-				&oim.Value{Path: key3, Value: value3},
+				&oim.Value{Path: key1, Value: value1, Revision: revision1},
+				&oim.Value{Path: key2, Value: value2, Revision: revision2},
+				&oim.Value{Path: key3, Value: value3, Revision: revision3},
 			}))
 
 			values, err = r.GetValues(adminCtx, &oim.GetValuesRequest{
@@ -124,7 +205,7 @@ var _ = Describe("OIM Registry", func() {
 			})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(values.Values).To(ConsistOf([]*oim.Value{
-				&oim.Value{Path: key1, Value: value1},
+				&oim.Value{Path: key1, Value: value1, Revision: revision1},
 			}))
 
 			values, err = r.GetValues(adminCtx, &oim.GetValuesRequest{
@@ -132,7 +213,7 @@ var _ = Describe("OIM Registry", func() {
 			})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(values.Values).To(ConsistOf([]*oim.Value{
-				&oim.Value{Path: key2, Value: value2},
+				&oim.Value{Path: key2, Value: value2, Revision: revision2},
 			}))
 
 			values, err = r.GetValues(adminCtx, &oim.GetValuesRequest{
@@ -140,8 +221,8 @@ var _ = Describe("OIM Registry", func() {
 			})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(values.Values).To(ConsistOf([]*oim.Value{
-				&oim.Value{Path: key1, Value: value1},
-				&oim.Value{Path: key2, Value: value2},
+				&oim.Value{Path: key1, Value: value1, Revision: revision1},
+				&oim.Value{Path: key2, Value: value2, Revision: revision2},
 			}))
 
 			values, err = r.GetValues(adminCtx, &oim.GetValuesRequest{
@@ -149,8 +230,8 @@ var _ = Describe("OIM Registry", func() {
 			})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(values.Values).To(ConsistOf([]*oim.Value{
-				&oim.Value{Path: key1, Value: value1},
-				&oim.Value{Path: key2, Value: value2},
+				&oim.Value{Path: key1, Value: value1, Revision: revision1},
+				&oim.Value{Path: key2, Value: value2, Revision: revision2},
 			}))
 
 			values, err = r.GetValues(adminCtx, &oim.GetValuesRequest{
@@ -158,9 +239,660 @@ var _ = Describe("OIM Registry", func() {
 			})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(values.Values).To(ConsistOf([]*oim.Value{
-				&oim.Value{Path: key1, Value: value1},
-				&oim.Value{Path: key2, Value: value2},
+				&oim.Value{Path: key1, Value: value1, Revision: revision1},
+				&oim.Value{Path: key2, Value: value2, Revision: revision2},
+			}))
+
+			_, err = r.SetValue(adminCtx, &oim.SetValueRequest{
+				Value: &oim.Value{
+					Path: key3,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			delete(expected, key3)
+			Expect(oimregistry.GetRegistryEntries(db)).To(Equal(expected))
+		}
+
+		testWatching := func(db oimregistry.RegistryDB) {
+			tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+			Expect(err).NotTo(HaveOccurred())
+			r, err := oimregistry.New(oimregistry.DB(db), oimregistry.TLS(tlsConfig))
+			Expect(err).NotTo(HaveOccurred())
+			revisions := map[string]int64{}
+			set := func(path, value string) {
+				reply, err := r.SetValue(adminCtx, &oim.SetValueRequest{
+					Value: &oim.Value{
+						Path:  path,
+						Value: value,
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				revisions[path] = reply.Revision
+			}
+
+			set("foo/address", "dns:///1.1.1.1/")
+			set("bar/address", "dns:///2.2.2.2/")
+
+			watchCtx, cancel := context.WithCancel(adminCtx)
+			defer cancel()
+			stream := &watchStream{
+				ctx:     watchCtx,
+				replies: make(chan *oim.WatchValuesReply, 10),
+			}
+			done := make(chan error, 1)
+			go func() {
+				done <- r.WatchValues(&oim.WatchValuesRequest{Path: "foo"}, stream)
+			}()
+
+			var reply *oim.WatchValuesReply
+			Eventually(stream.replies).Should(Receive(&reply))
+			Expect(reply.Type).To(Equal(oim.WatchValuesReply_SNAPSHOT))
+			Expect(reply.Values).To(Equal([]*oim.Value{
+				&oim.Value{Path: "foo/address", Value: "dns:///1.1.1.1/", Revision: revisions["foo/address"]},
+			}))
+			revision := reply.Revision
+
+			// Changes outside of the watched path must be ignored.
+			set("bar/pci", "0000:0004:30.2")
+			set("foo/pci", "0000:0003:20.1")
+			set("foo/address", "")
+
+			Eventually(stream.replies).Should(Receive(&reply))
+			Expect(reply.Type).To(Equal(oim.WatchValuesReply_PUT))
+			Expect(reply.Values).To(Equal([]*oim.Value{
+				&oim.Value{Path: "foo/pci", Value: "0000:0003:20.1", Revision: revisions["foo/pci"]},
 			}))
+			Expect(reply.Revision).To(Equal(revisions["foo/pci"]))
+			Expect(reply.Revision).To(BeNumerically(">", revision))
+			revision = reply.Revision
+
+			Eventually(stream.replies).Should(Receive(&reply))
+			Expect(reply.Type).To(Equal(oim.WatchValuesReply_DELETE))
+			Expect(reply.Values).To(Equal([]*oim.Value{
+				&oim.Value{Path: "foo/address", Revision: revisions["foo/address"]},
+			}))
+			Expect(reply.Revision).To(BeNumerically(">", revision))
+
+			Consistently(stream.replies).ShouldNot(Receive())
+			cancel()
+			Eventually(done).Should(Receive(HaveOccurred()))
+		}
+
+		testExpiring := func(db oimregistry.RegistryDB) {
+			tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+			Expect(err).NotTo(HaveOccurred())
+			r, err := oimregistry.New(oimregistry.DB(db), oimregistry.TLS(tlsConfig))
+			Expect(err).NotTo(HaveOccurred())
+			getDB := func() (map[string]string, error) {
+				return oimregistry.GetRegistryEntries(db)
+			}
+			key := "host-0/address"
+			value := &oim.Value{Path: key, Value: "dns:///1.1.1.1/"}
+
+			_, err = r.SetValue(adminCtx, &oim.SetValueRequest{Value: value, TtlSeconds: 2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(getDB()).To(HaveKey(key))
+			Eventually(getDB, 10*time.Second).ShouldNot(HaveKey(key))
+
+			// Setting again without TTL makes the value permanent.
+			_, err = r.SetValue(adminCtx, &oim.SetValueRequest{Value: value, TtlSeconds: 2})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = r.SetValue(adminCtx, &oim.SetValueRequest{Value: value})
+			Expect(err).NotTo(HaveOccurred())
+			Consistently(getDB, 5*time.Second).Should(HaveKey(key))
+		}
+
+		testConflicts := func(db oimregistry.RegistryDB) {
+			tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+			Expect(err).NotTo(HaveOccurred())
+			r, err := oimregistry.New(oimregistry.DB(db), oimregistry.TLS(tlsConfig))
+			Expect(err).NotTo(HaveOccurred())
+			set := func(value string, expected int64) (int64, error) {
+				reply, err := r.SetValue(adminCtx, &oim.SetValueRequest{
+					Value: &oim.Value{
+						Path:  "host-0/pci",
+						Value: value,
+					},
+					ExpectedRevision: &types.Int64Value{Value: expected},
+				})
+				if err != nil {
+					return 0, err
+				}
+				return reply.Revision, nil
+			}
+			isConflict := func(err error) {
+				Expect(err).To(HaveOccurred())
+				Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+			}
+
+			// Must not exist yet.
+			revision1, err := set("0000:0003:20.1", 0)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = set("0000:0003:20.2", 0)
+			isConflict(err)
+
+			// Must have been modified at revision1.
+			revision2, err := set("0000:0003:20.2", revision1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revision2).To(BeNumerically(">", revision1))
+			_, err = set("0000:0003:20.3", revision1)
+			isConflict(err)
+
+			values, err := r.GetValues(adminCtx, &oim.GetValuesRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values.Values).To(Equal([]*oim.Value{
+				&oim.Value{Path: "host-0/pci", Value: "0000:0003:20.2", Revision: revision2},
+			}))
+
+			// Removal also gets checked.
+			_, err = set("", revision1)
+			isConflict(err)
+			_, err = set("", revision2)
+			Expect(err).NotTo(HaveOccurred())
+			values, err = r.GetValues(adminCtx, &oim.GetValuesRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values.Values).To(BeEmpty())
+
+			_, err = set("0000:0003:20.1", -1)
+			Expect(err).To(HaveOccurred())
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		}
+
+		testTransactions := func(db oimregistry.RegistryDB) {
+			tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+			Expect(err).NotTo(HaveOccurred())
+			r, err := oimregistry.New(oimregistry.DB(db), oimregistry.TLS(tlsConfig))
+			Expect(err).NotTo(HaveOccurred())
+			change := func(path, value string) *oim.SetValueRequest {
+				return &oim.SetValueRequest{
+					Value: &oim.Value{
+						Path:  path,
+						Value: value,
+					},
+				}
+			}
+			getValues := func() []*oim.Value {
+				values, err := r.GetValues(adminCtx, &oim.GetValuesRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				return values.Values
+			}
+
+			reply, err := r.SetValues(adminCtx, &oim.SetValuesRequest{
+				Changes: []*oim.SetValueRequest{
+					change("host-0/address", "dns:///1.1.1.1/"),
+					change("host-0/pci", "0000:0003:20.1"),
+					change("host-0/site", "rack-1"),
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			revision := reply.Revision
+			Expect(getValues()).To(ConsistOf([]*oim.Value{
+				&oim.Value{Path: "host-0/address", Value: "dns:///1.1.1.1/", Revision: revision},
+				&oim.Value{Path: "host-0/pci", Value: "0000:0003:20.1", Revision: revision},
+				&oim.Value{Path: "host-0/site", Value: "rack-1", Revision: revision},
+			}))
+
+			// A failed guard prevents all changes.
+			_, err = r.SetValues(adminCtx, &oim.SetValuesRequest{
+				Changes: []*oim.SetValueRequest{
+					change("host-0/pci", "0000:0004:30.2"),
+				},
+				Guards: []*oim.RevisionGuard{
+					{Path: "host-0/site", Revision: revision},
+					{Path: "host-0/address", Revision: 0},
+				},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+
+			// Same for an expected revision of a change.
+			wrongRevision := change("host-0/site", "rack-2")
+			wrongRevision.ExpectedRevision = &types.Int64Value{Value: revision + 1000}
+			_, err = r.SetValues(adminCtx, &oim.SetValuesRequest{
+				Changes: []*oim.SetValueRequest{
+					change("host-0/pci", "0000:0004:30.2"),
+					wrongRevision,
+				},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+
+			// Permissions are checked for each change.
+			controllerCtx := oimregistry.RegistryClientContext(ctx, "controller.host-0")
+			_, err = r.SetValues(controllerCtx, &oim.SetValuesRequest{
+				Changes: []*oim.SetValueRequest{
+					change("host-0/address", "dns:///2.2.2.2/"),
+					change("host-0/pci", "0000:0004:30.2"),
+				},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+
+			// Each path only once.
+			_, err = r.SetValues(adminCtx, &oim.SetValuesRequest{
+				Changes: []*oim.SetValueRequest{
+					change("host-0/pci", "0000:0004:30.2"),
+					change("/host-0/pci/", ""),
+				},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+			Expect(getValues()).To(ConsistOf([]*oim.Value{
+				&oim.Value{Path: "host-0/address", Value: "dns:///1.1.1.1/", Revision: revision},
+				&oim.Value{Path: "host-0/pci", Value: "0000:0003:20.1", Revision: revision},
+				&oim.Value{Path: "host-0/site", Value: "rack-1", Revision: revision},
+			}))
+
+			// Successful guards, removal.
+			reply, err = r.SetValues(adminCtx, &oim.SetValuesRequest{
+				Changes: []*oim.SetValueRequest{
+					change("host-0/pci", ""),
+					change("host-0/site", ""),
+				},
+				Guards: []*oim.RevisionGuard{
+					{Path: "host-0/address", Revision: revision},
+					{Path: "host-1/address", Revision: 0},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.Revision).To(BeNumerically(">", revision))
+			Expect(getValues()).To(ConsistOf([]*oim.Value{
+				&oim.Value{Path: "host-0/address", Value: "dns:///1.1.1.1/", Revision: revision},
+			}))
+		}
+
+		It("should work", func() {
+			testStoring(oimregistry.NewMemRegistryDB())
+		})
+
+		It("should support transactions", func() {
+			testTransactions(oimregistry.NewMemRegistryDB())
+		})
+
+		It("should reject transactions without database support", func() {
+			tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+			Expect(err).NotTo(HaveOccurred())
+			// Hides the Txn method.
+			db := struct{ oimregistry.RegistryDB }{oimregistry.NewMemRegistryDB()}
+			r, err := oimregistry.New(oimregistry.DB(db), oimregistry.TLS(tlsConfig))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = r.SetValues(adminCtx, &oim.SetValuesRequest{
+				Changes: []*oim.SetValueRequest{
+					&oim.SetValueRequest{Value: &oim.Value{Path: "host-0/address", Value: "dns:///1.1.1.1/"}},
+				},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(status.Code(err)).To(Equal(codes.Unimplemented))
+		})
+
+		It("should detect conflicts", func() {
+			testConflicts(oimregistry.NewMemRegistryDB())
+		})
+
+		It("should expire", func() {
+			testExpiring(oimregistry.NewMemRegistryDB())
+		})
+
+		It("should watch", func() {
+			testWatching(oimregistry.NewMemRegistryDB())
+		})
+
+		It("should abort slow watchers", func() {
+			db := oimregistry.NewMemRegistryDB()
+			watchCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			_, _, events, err := db.Watch(watchCtx)
+			Expect(err).NotTo(HaveOccurred())
+			for i := 0; i < 1000; i++ {
+				_, err := db.Store(ctx, "foo/address", fmt.Sprintf("dns:///1.1.1.1:%d/", i), 0, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			received := 0
+			for range events {
+				received++
+			}
+			Expect(received).To(BeNumerically("<", 1000))
+		})
+
+		Context("with file", func() {
+			var (
+				tmpDir string
+				path   string
+			)
+
+			BeforeEach(func() {
+				var err error
+				tmpDir, err = ioutil.TempDir("", "oim-registry-test")
+				Expect(err).NotTo(HaveOccurred())
+				path = filepath.Join(tmpDir, "registry.db")
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(tmpDir)
+			})
+
+			open := func() oimregistry.FileRegistryDB {
+				db, err := oimregistry.NewFileRegistryDB(path)
+				Expect(err).NotTo(HaveOccurred())
+				return db
+			}
+
+			It("should work", func() {
+				db := open()
+				defer db.Close()
+				testStoring(db)
+			})
+
+			It("should watch", func() {
+				db := open()
+				defer db.Close()
+				testWatching(db)
+			})
+
+			It("should expire", func() {
+				db := open()
+				defer db.Close()
+				testExpiring(db)
+			})
+
+			It("should detect conflicts", func() {
+				db := open()
+				defer db.Close()
+				testConflicts(db)
+			})
+
+			It("should support transactions", func() {
+				db := open()
+				defer db.Close()
+				testTransactions(db)
+			})
+
+			It("should persist transactions", func() {
+				db := open()
+				revision, err := db.(oimregistry.TxnRegistryDB).Txn(ctx, nil, []oimregistry.Change{
+					{Key: "host-0/address", Value: "dns:///1.1.1.1/", Expected: oimregistry.AnyRevision},
+					{Key: "host-0/pci", Value: "0000:0003:20.1", Expected: oimregistry.AnyRevision},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				err = db.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				db = open()
+				defer db.Close()
+				entries, _, _, err := db.Watch(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(Equal(map[string]oimregistry.Entry{
+					"host-0/address": {Value: "dns:///1.1.1.1/", Revision: revision},
+					"host-0/pci":     {Value: "0000:0003:20.1", Revision: revision},
+				}))
+			})
+
+			It("should preserve revisions", func() {
+				db := open()
+				revision1, err := db.Store(ctx, "host-0/address", "dns:///1.1.1.1/", 0, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				// Enough changes to trigger compaction.
+				var revision2 int64
+				for i := 0; i < 5000; i++ {
+					revision2, err = db.Store(ctx, "host-0/pci", fmt.Sprintf("0000:0003:20.%d", i), 0, oimregistry.AnyRevision)
+					Expect(err).NotTo(HaveOccurred())
+				}
+				err = db.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				db = open()
+				defer db.Close()
+				entries, revision, _, err := db.Watch(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(revision).To(Equal(revision2))
+				Expect(entries).To(Equal(map[string]oimregistry.Entry{
+					"host-0/address": {Value: "dns:///1.1.1.1/", Revision: revision1},
+					"host-0/pci":     {Value: "0000:0003:20.4999", Revision: revision2},
+				}))
+			})
+
+			It("should expire after restart", func() {
+				db := open()
+				_, err := db.Store(ctx, "host-0/address", "dns:///1.1.1.1/", time.Hour, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = db.Store(ctx, "host-1/address", "dns:///2.2.2.2/", time.Second, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				err = db.Close()
+				Expect(err).NotTo(HaveOccurred())
+				time.Sleep(2 * time.Second)
+
+				db = open()
+				Eventually(func() (map[string]string, error) {
+					return oimregistry.GetRegistryEntries(db)
+				}).Should(Equal(map[string]string{
+					"host-0/address": "dns:///1.1.1.1/",
+				}))
+				err = db.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				// The removal must have been stored.
+				db = open()
+				defer db.Close()
+				Expect(oimregistry.GetRegistryEntries(db)).To(Equal(map[string]string{
+					"host-0/address": "dns:///1.1.1.1/",
+				}))
+			})
+
+			It("should continue revisions after restart", func() {
+				db := open()
+				for i := 0; i < 5000; i++ {
+					_, err := db.Store(ctx, "host-0/address", fmt.Sprintf("dns:///1.1.1.1:%d/", i), 0, oimregistry.AnyRevision)
+					Expect(err).NotTo(HaveOccurred())
+				}
+				_, revision, _, err := db.Watch(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(revision).To(Equal(int64(5000)))
+				err = db.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				db = open()
+				defer db.Close()
+				_, revision, _, err = db.Watch(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(revision).To(Equal(int64(5000)))
+			})
+
+			It("should persist entries", func() {
+				db := open()
+				_, err := db.Store(ctx, "host-0/address", "dns:///1.1.1.1/", 0, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = db.Store(ctx, "host-0/pci", "0000:0003:20.1", 0, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = db.Store(ctx, "host-1/address", "dns:///2.2.2.2/", 0, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = db.Store(ctx, "host-1/address", "", 0, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				err = db.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				db = open()
+				defer db.Close()
+				Expect(oimregistry.GetRegistryEntries(db)).To(Equal(map[string]string{
+					"host-0/address": "dns:///1.1.1.1/",
+					"host-0/pci":     "0000:0003:20.1",
+				}))
+			})
+
+			It("should be locked", func() {
+				db := open()
+				defer db.Close()
+				_, err := oimregistry.NewFileRegistryDB(path)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should recover from incomplete writes", func() {
+				db := open()
+				_, err := db.Store(ctx, "host-0/address", "dns:///1.1.1.1/", 0, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				err = db.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				// Simulate a write that was interrupted.
+				file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = file.WriteString(`12345678 {"k":"host-0/pci"`)
+				Expect(err).NotTo(HaveOccurred())
+				err = file.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				db = open()
+				Expect(oimregistry.GetRegistryEntries(db)).To(Equal(map[string]string{
+					"host-0/address": "dns:///1.1.1.1/",
+				}))
+				_, err = db.Store(ctx, "host-0/pci", "0000:0003:20.1", 0, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				err = db.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				db = open()
+				defer db.Close()
+				Expect(oimregistry.GetRegistryEntries(db)).To(Equal(map[string]string{
+					"host-0/address": "dns:///1.1.1.1/",
+					"host-0/pci":     "0000:0003:20.1",
+				}))
+			})
+
+			It("should detect corruption", func() {
+				db := open()
+				_, err := db.Store(ctx, "host-0/address", "dns:///1.1.1.1/", 0, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = db.Store(ctx, "host-0/pci", "0000:0003:20.1", 0, oimregistry.AnyRevision)
+				Expect(err).NotTo(HaveOccurred())
+				err = db.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				data, err := ioutil.ReadFile(path)
+				Expect(err).NotTo(HaveOccurred())
+				data[20]++
+				err = ioutil.WriteFile(path, data, 0600)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = oimregistry.NewFileRegistryDB(path)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should compact", func() {
+				db := open()
+				for i := 0; i < 5000; i++ {
+					_, err := db.Store(ctx, "host-0/address", fmt.Sprintf("dns:///1.1.1.1:%d/", i), 0, oimregistry.AnyRevision)
+					Expect(err).NotTo(HaveOccurred())
+				}
+				info, err := os.Stat(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Size()).To(BeNumerically("<", 100*1000))
+				err = db.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				db = open()
+				defer db.Close()
+				Expect(oimregistry.GetRegistryEntries(db)).To(Equal(map[string]string{
+					"host-0/address": "dns:///1.1.1.1:4999/",
+				}))
+			})
+		})
+
+		Context("with etcd", func() {
+			var prefix string
+
+			BeforeEach(func() {
+				err := testetcd.Init()
+				Expect(err).NotTo(HaveOccurred())
+				// Unique prefix, in case that the etcd instance is shared.
+				prefix = fmt.Sprintf("/oim-registry-test-%d/", time.Now().UnixNano())
+			})
+
+			AfterEach(func() {
+				if testetcd.Client != nil {
+					_, err := testetcd.Client.Delete(ctx, prefix, clientv3.WithPrefix())
+					Expect(err).NotTo(HaveOccurred())
+				}
+				err := testetcd.Finalize()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should work", func() {
+				testStoring(oimregistry.NewEtcdRegistryDB(testetcd.Client, prefix))
+			})
+
+			It("should watch", func() {
+				testWatching(oimregistry.NewEtcdRegistryDB(testetcd.Client, prefix))
+			})
+
+			It("should expire", func() {
+				testExpiring(oimregistry.NewEtcdRegistryDB(testetcd.Client, prefix))
+			})
+
+			It("should detect conflicts", func() {
+				testConflicts(oimregistry.NewEtcdRegistryDB(testetcd.Client, prefix))
+			})
+
+			It("should not leak leases", func() {
+				db := oimregistry.NewEtcdRegistryDB(testetcd.Client, prefix)
+				leases := func() int {
+					resp, err := testetcd.Client.Leases(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					return len(resp.Leases)
+				}
+				before := leases()
+
+				// Refreshing reuses the lease.
+				var revision int64
+				for i := 0; i < 5; i++ {
+					var err error
+					revision, err = db.Store(ctx, "host-0/address", "dns:///1.1.1.1/", time.Minute, oimregistry.AnyRevision)
+					Expect(err).NotTo(HaveOccurred())
+				}
+				Expect(leases()).To(Equal(before + 1))
+
+				// A different TTL needs a new lease, which
+				// gets revoked again when the change fails.
+				_, err := db.Store(ctx, "host-0/address", "dns:///1.1.1.1/", 2*time.Minute, revision-1)
+				Expect(errors.Cause(err)).To(Equal(oimregistry.ErrConflict))
+				Expect(leases()).To(Equal(before + 1))
+			})
+
+			It("should support transactions", func() {
+				testTransactions(oimregistry.NewEtcdRegistryDB(testetcd.Client, prefix))
+			})
+
+			It("should share entries between registries", func() {
+				tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
+				Expect(err).NotTo(HaveOccurred())
+
+				// Each registry gets its own client, just as
+				// separate registry processes would.
+				var registries []oimregistry.RegistryServer
+				for i := 0; i < 2; i++ {
+					client, err := clientv3.New(clientv3.Config{
+						Endpoints:   testetcd.Endpoints,
+						DialTimeout: 10 * time.Second,
+					})
+					Expect(err).NotTo(HaveOccurred())
+					defer client.Close()
+					r, err := oimregistry.New(oimregistry.DB(oimregistry.NewEtcdRegistryDB(client, prefix)), oimregistry.TLS(tlsConfig))
+					Expect(err).NotTo(HaveOccurred())
+					registries = append(registries, r)
+				}
+
+				value := &oim.Value{Path: "host-0/address", Value: "dns:///1.1.1.1/"}
+				reply, err := registries[0].SetValue(adminCtx, &oim.SetValueRequest{Value: value})
+				Expect(err).NotTo(HaveOccurred())
+				value.Revision = reply.Revision
+				values, err := registries[1].GetValues(adminCtx, &oim.GetValuesRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(values.Values).To(ConsistOf([]*oim.Value{value}))
+
+				_, err = registries[1].SetValue(adminCtx, &oim.SetValueRequest{Value: &oim.Value{Path: value.Path}})
+				Expect(err).NotTo(HaveOccurred())
+				values, err = registries[0].GetValues(adminCtx, &oim.GetValuesRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(values.Values).To(BeEmpty())
+			})
 		})
 	})
 
@@ -173,6 +905,7 @@ var _ = Describe("OIM Registry", func() {
 			registryAddress  string
 			controllerClient oim.ControllerClient
 			clientConn       *grpc.ClientConn
+			auditSink        *recordingSink
 		)
 
 		BeforeEach(func() {
@@ -184,7 +917,8 @@ var _ = Describe("OIM Registry", func() {
 			// Spin up registry.
 			tlsConfig, err := oimcommon.LoadTLSConfig(os.ExpandEnv("${TEST_WORK}/ca/ca.crt"), os.ExpandEnv("${TEST_WORK}/ca/component.registry.key"), "")
 			Expect(err).NotTo(HaveOccurred())
-			registry, err = oimregistry.New(oimregistry.TLS(tlsConfig))
+			auditSink = &recordingSink{}
+			registry, err = oimregistry.New(oimregistry.TLS(tlsConfig), oimregistry.Audit(auditSink))
 			Expect(err).NotTo(HaveOccurred())
 			registryAddress = "unix://" + filepath.Join(tmpDir, "registry.sock")
 			server, service := registry.Server(registryAddress)
@@ -236,6 +970,20 @@ var _ = Describe("OIM Registry", func() {
 			Expect(err.Error()).To(ContainSubstring(`code = PermissionDenied desc = caller "host.host-0" not allowed to contact controller "host-1"`))
 		})
 
+		It("should be healthy", func() {
+			health := healthpb.NewHealthClient(clientConn)
+			for _, service := range []string{"", "oim.v0.Registry"} {
+				reply, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply.Status).To(Equal(healthpb.HealthCheckResponse_SERVING), service)
+			}
+
+			// Not answered on behalf of a controller.
+			callCtx := metadata.AppendToOutgoingContext(ctx, "controllerid", controllerID)
+			_, err := health.Check(callCtx, &healthpb.HealthCheckRequest{})
+			Expect(status.Code(err)).To(Equal(codes.Unavailable))
+		})
+
 		It("should reject normal user SetVar", func() {
 			registryClient := oim.NewRegistryClient(clientConn)
 			_, err := registryClient.SetValue(ctx, &oim.SetValueRequest{
@@ -367,6 +1115,19 @@ var _ = Describe("OIM Registry", func() {
 				})
 			}
 
+			It("should forward health checks", func() {
+				setupController(ca, key)
+				health := healthpb.NewHealthClient(clientConn)
+				callCtx := metadata.AppendToOutgoingContext(ctx, "controllerid", controllerID)
+				reply, err := health.Check(callCtx, &healthpb.HealthCheckRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply.Status).To(Equal(healthpb.HealthCheckResponse_SERVING))
+
+				// The controller does not know about the registry service.
+				_, err = health.Check(callCtx, &healthpb.HealthCheckRequest{Service: "oim.v0.Registry"})
+				Expect(status.Code(err)).To(Equal(codes.NotFound))
+			})
+
 			It("controller should detect wrong registry", func() {
 				// Setup controller with normal creds.
 				setupController(ca, key)
@@ -387,6 +1148,144 @@ var _ = Describe("OIM Registry", func() {
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring(`all SubConns are in TransientFailure, latest connection error: connection error: desc = "transport: authentication handshake failed: remote error: tls: bad certificate"`))
 			})
+
+			It("should audit calls", func() {
+				setupController(ca, key)
+				callCtx := metadata.AppendToOutgoingContext(ctx, "controllerid", controllerID)
+				_, err := controllerClient.MapVolume(callCtx, &oim.MapVolumeRequest{
+					VolumeId: "my-volume",
+					Params: &oim.MapVolumeRequest_Ceph{
+						Ceph: &oim.CephParams{
+							UserId: "admin",
+							Secret: "top-secret",
+						},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				ctx := metadata.AppendToOutgoingContext(ctx, "controllerid", "host-1")
+				_, err = controllerClient.UnmapVolume(ctx, &oim.UnmapVolumeRequest{VolumeId: "other-volume"})
+				Expect(err).To(HaveOccurred())
+
+				// Proxied calls are recorded after the reply was sent.
+				Eventually(func() int { return len(auditSink.Records()) }).Should(Equal(3))
+				records := auditSink.Records()
+				Expect(records[0].Method).To(Equal("/oim.v0.Registry/SetValue"))
+				Expect(records[1].Peer).To(Equal("host.host-0"))
+				Expect(records[1].Method).To(Equal("/oim.v0.Controller/MapVolume"))
+				Expect(records[1].ControllerID).To(Equal(controllerID))
+				Expect(records[1].VolumeID).To(Equal("my-volume"))
+				Expect(records[1].Code).To(Equal("OK"))
+				Expect(records[1].Request).To(ContainSubstring("admin"))
+				Expect(records[1].Request).To(ContainSubstring("***stripped***"))
+				Expect(records[1].Request).NotTo(ContainSubstring("top-secret"))
+				Expect(records[2].Method).To(Equal("/oim.v0.Controller/UnmapVolume"))
+				Expect(records[2].ControllerID).To(Equal("host-1"))
+				// Rejected before the request was read.
+				Expect(records[2].VolumeID).To(BeEmpty())
+				Expect(records[2].Code).To(Equal("PermissionDenied"))
+
+				// The controller itself must have received the secret.
+				Expect(controller.MapVolumes[0].GetCeph().GetSecret()).To(Equal("top-secret"))
+			})
+
+			It("should reuse connections", func() {
+				setupController(ca, key)
+				callCtx := metadata.AppendToOutgoingContext(ctx, "controllerid", controllerID)
+				hits, misses := proxyConnections()
+				for i := 0; i < 3; i++ {
+					_, err := controllerClient.MapVolume(callCtx, &oim.MapVolumeRequest{VolumeId: "my-volume"})
+					Expect(err).NotTo(HaveOccurred())
+				}
+				newHits, newMisses := proxyConnections()
+				Expect(newMisses-misses).To(Equal(1.0), "misses")
+				Expect(newHits-hits).To(Equal(2.0), "hits")
+				Expect(controller.MapVolumes).To(HaveLen(3))
+			})
+
+			It("should count proxied calls", func() {
+				setupController(ca, key)
+				mapVolume := "/oim.v0.Controller/MapVolume"
+				ok := proxiedCalls(controllerID, mapVolume, "OK")
+				denied := proxiedCalls("", "other", "PermissionDenied")
+				callCtx := metadata.AppendToOutgoingContext(ctx, "controllerid", controllerID)
+				_, err := controllerClient.MapVolume(callCtx, &oim.MapVolumeRequest{VolumeId: "my-volume"})
+				Expect(err).NotTo(HaveOccurred())
+				callCtx = metadata.AppendToOutgoingContext(ctx, "controllerid", "host-1")
+				_, err = controllerClient.MapVolume(callCtx, &oim.MapVolumeRequest{VolumeId: "my-volume"})
+				Expect(err).To(HaveOccurred())
+
+				// Proxied calls are counted after the reply was sent.
+				Eventually(func() float64 { return proxiedCalls(controllerID, mapVolume, "OK") }).Should(Equal(ok + 1))
+				// Rejected calls do not get their own labels.
+				Eventually(func() float64 { return proxiedCalls("", "other", "PermissionDenied") }).Should(Equal(denied + 1))
+			})
+
+			It("should trace proxied calls", func() {
+				setupController(ca, key)
+				parent := tracer.StartSpan("test")
+				callCtx := metadata.AppendToOutgoingContext(ctx, "controllerid", controllerID)
+				callCtx = opentracing.ContextWithSpan(callCtx, parent)
+				_, err := controllerClient.MapVolume(callCtx, &oim.MapVolumeRequest{VolumeId: "my-volume"})
+				Expect(err).NotTo(HaveOccurred())
+				parent.Finish()
+
+				// Client -> registry -> controller results in
+				// one client and one server span per hop, each
+				// a child of the previous one.
+				traceID := parent.Context().(mocktracer.MockSpanContext).TraceID
+				chain := func() []string {
+					children := map[int]*mocktracer.MockSpan{}
+					for _, span := range tracer.FinishedSpans() {
+						if span.SpanContext.TraceID == traceID {
+							children[span.ParentID] = span
+						}
+					}
+					var names []string
+					for span := children[parent.Context().(mocktracer.MockSpanContext).SpanID]; span != nil; span = children[span.SpanContext.SpanID] {
+						names = append(names, fmt.Sprintf("%s %s", span.Tag("span.kind"), span.OperationName))
+					}
+					return names
+				}
+				Eventually(chain).Should(Equal([]string{
+					"client /oim.v0.Controller/MapVolume",
+					"server /oim.v0.Controller/MapVolume",
+					"client /oim.v0.Controller/MapVolume",
+					"server /oim.v0.Controller/MapVolume",
+				}))
+			})
+
+			It("should reconnect after address change", func() {
+				setupController(ca, key)
+				callCtx := metadata.AppendToOutgoingContext(ctx, "controllerid", controllerID)
+				_, err := controllerClient.MapVolume(callCtx, &oim.MapVolumeRequest{VolumeId: "my-volume"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(controller.MapVolumes).To(HaveLen(1))
+
+				// Move the controller to a different address.
+				controllerCreds, err := oimcommon.LoadTLS(ca, key, "component.registry")
+				Expect(err).NotTo(HaveOccurred())
+				movedController := &MockController{}
+				movedAddress := "unix://" + filepath.Join(tmpDir, "controller-2.sock")
+				server, service := oimcontroller.Server(movedAddress, movedController, controllerCreds)
+				err = server.Start(ctx, service)
+				Expect(err).NotTo(HaveOccurred())
+				defer func() {
+					server.ForceStop(ctx)
+					server.Wait(ctx)
+				}()
+				_, err = registry.SetValue(adminCtx, &oim.SetValueRequest{
+					Value: &oim.Value{
+						Path:  controllerID + "/" + oimcommon.RegistryAddress,
+						Value: movedAddress,
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = controllerClient.MapVolume(callCtx, &oim.MapVolumeRequest{VolumeId: "my-volume"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(controller.MapVolumes).To(HaveLen(1))
+				Expect(movedController.MapVolumes).To(HaveLen(1))
+			})
 		})
 	})
 })
@@ -18,7 +18,8 @@ import (
 
 // RegistryClientContext creates a new context with credentials as if
 // the client had connected via TLS with the client name as
-// CommonName.
+// CommonName. Because there are no SANs, that name is also the
+// identity of the client.
 func RegistryClientContext(ctx context.Context, client string) context.Context {
 	return peer.NewContext(ctx, &peer.Peer{
 		AuthInfo: credentials.TLSInfo{
@@ -51,6 +51,11 @@ import (
 	"regexp"
 	"strconv"
 	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
 
 	"github.com/intel/oim/pkg/log"
 )
@@ -222,9 +227,16 @@ func (c *clientCodec) Close() error {
 	return c.c.Close()
 }
 
-// Client encapsulates the connection to a SPDK JSON server.
+// Client encapsulates the connection to a SPDK JSON server. When
+// SPDK closes the connection, for example because it was restarted,
+// the client connects again for the next call.
 type Client struct {
-	client *rpc.Client
+	path string
+
+	mutex       sync.Mutex
+	client      *rpc.Client
+	closed      bool
+	reconnected func()
 }
 
 type logConn struct {
@@ -252,21 +264,86 @@ func (lc *logConn) Write(b []byte) (int, error) {
 
 // New constructs a new SPDK JSON client.
 func New(path string) (*Client, error) {
+	client, err := dial(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{path: path, client: client}, nil
+}
+
+func dial(path string) (*rpc.Client, error) {
 	conn, err := net.Dial("unix", path)
 	if err != nil {
 		return nil, err
 	}
 	conn = &logConn{conn, log.L().With("at", "spdk-rpc")}
-	client := rpc.NewClientWithCodec(newClientCodec(conn))
-	return &Client{client: client}, nil
+	return rpc.NewClientWithCodec(newClientCodec(conn)), nil
+}
+
+// OnReconnect sets a function which gets called in a new goroutine
+// each time that the client has connected again. SPDK does not
+// persist its configuration, so after a restart it has lost
+// everything that was set up before.
+func (c *Client) OnReconnect(reconnected func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reconnected = reconnected
+}
+
+// reconnect replaces the connection, unless that was already done
+// by some other call or the client was closed.
+func (c *Client) reconnect(old *rpc.Client) (*rpc.Client, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return nil, rpc.ErrShutdown
+	}
+	if c.client != old {
+		return c.client, nil
+	}
+	client, err := dial(c.path)
+	if err != nil {
+		return nil, err
+	}
+	log.L().Infow("reconnected to SPDK", "path", c.path)
+	c.client = client
+	if c.reconnected != nil {
+		go c.reconnected()
+	}
+	return client, nil
 }
 
 // Close the connection to the server.
 func (c *Client) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.closed = true
 	return c.client.Close()
 }
 
 // Invoke a certain method, get the reply and return the error (if any).
-func (c *Client) Invoke(_ context.Context, method string, args, reply interface{}) error {
-	return c.client.Call(method, args, reply)
+// The call is traced as a child of the span in the context, if there is one.
+func (c *Client) Invoke(ctx context.Context, method string, args, reply interface{}) error {
+	start := time.Now()
+	sp, _ := opentracing.StartSpanFromContext(ctx, "spdk "+method, ext.SpanKindRPCClient)
+	defer sp.Finish()
+	ext.Component.Set(sp, "spdk")
+	c.mutex.Lock()
+	client := c.client
+	c.mutex.Unlock()
+	err := client.Call(method, args, reply)
+	if err == rpc.ErrShutdown {
+		// The connection was already lost before this call,
+		// so it has not been sent and can be sent again.
+		client, err = c.reconnect(client)
+		if err == nil {
+			err = client.Call(method, args, reply)
+		}
+	}
+	if err != nil {
+		ext.Error.Set(sp, true)
+		sp.LogFields(otlog.Error(err))
+	}
+	observeRPC(method, start, err)
+	return err
 }
@@ -0,0 +1,35 @@
+/*
+Copyright (C) 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package spdk
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "oim",
+	Subsystem: "spdk",
+	Name:      "rpc_duration_seconds",
+	Help:      "Duration of SPDK JSON RPC calls, by method and whether they failed.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "failed"})
+
+func init() {
+	prometheus.MustRegister(rpcDuration)
+}
+
+// observeRPC records the duration of a call that started at the
+// given time.
+func observeRPC(method string, start time.Time, err error) {
+	failed := "false"
+	if err != nil {
+		failed = "true"
+	}
+	rpcDuration.WithLabelValues(method, failed).Observe(time.Since(start).Seconds())
+}
@@ -145,16 +145,30 @@ func ConstructVHostSCSIController(ctx context.Context, client *Client, args Cons
 	return client.Invoke(ctx, "construct_vhost_scsi_controller", args, nil)
 }
 
-// nolint: golint
+// AnySCSITarget lets AddVHostSCSILUN pick the first unused SCSI
+// target of the controller.
+const AnySCSITarget = -1
+
+// MaxSCSITargets is the number of SCSI targets per vhost-scsi
+// controller (SPDK_VHOST_SCSI_CTRLR_MAX_DEVS).
+const MaxSCSITargets = 8
+
+// AddVHostSCSILUNArgs adds a new SCSI target with the BDev as LUN 0.
+// SPDK does not support more than one LUN per target.
 type AddVHostSCSILUNArgs struct {
 	Controller    string `json:"ctrlr"`
-	SCSITargetNum uint32 `json:"scsi_target_num"`
+	SCSITargetNum int32  `json:"scsi_target_num"`
 	BDevName      string `json:"bdev_name"`
 }
 
+// AddVHostSCSILUNResponse is the number of the new SCSI target.
+type AddVHostSCSILUNResponse uint32
+
 // nolint: golint
-func AddVHostSCSILUN(ctx context.Context, client *Client, args AddVHostSCSILUNArgs) error {
-	return client.Invoke(ctx, "add_vhost_scsi_lun", args, nil)
+func AddVHostSCSILUN(ctx context.Context, client *Client, args AddVHostSCSILUNArgs) (AddVHostSCSILUNResponse, error) {
+	var response AddVHostSCSILUNResponse
+	err := client.Invoke(ctx, "add_vhost_scsi_lun", args, &response)
+	return response, err
 }
 
 // nolint: golint
@@ -168,6 +182,19 @@ func RemoveVHostSCSITarget(ctx context.Context, client *Client, args RemoveVHost
 	return client.Invoke(ctx, "remove_vhost_scsi_target", args, nil)
 }
 
+// nolint: golint
+type ConstructVHostBLKControllerArgs struct {
+	CPUMask    string `json:"cpumask,omitempty"`
+	Controller string `json:"ctrlr"`
+	DevName    string `json:"dev_name"`
+	ReadOnly   bool   `json:"readonly,omitempty"`
+}
+
+// nolint: golint
+func ConstructVHostBLKController(ctx context.Context, client *Client, args ConstructVHostBLKControllerArgs) error {
+	return client.Invoke(ctx, "construct_vhost_blk_controller", args, nil)
+}
+
 // nolint: golint
 type RemoveVHostControllerArgs struct {
 	Controller string `json:"ctrlr"`
@@ -186,7 +213,7 @@ type Controller struct {
 	Controller string `json:"ctrlr"`
 	CPUMask    string `json:"cpumask"`
 	// BackendSpecific holds the parsed JSON response for known
-	// backends (like SCSIControllerSpecific), otherwise
+	// backends (like SCSIControllerSpecific or BLKControllerSpecific), otherwise
 	// the JSON data converted to basic types (map, list, etc.)
 	BackendSpecific BackendSpecificType `json:"backend_specific"`
 }
@@ -211,6 +238,36 @@ type SCSIControllerLUN struct {
 	BDevName string
 }
 
+// nolint: golint
+type BLKControllerSpecific struct {
+	BDevName string
+	ReadOnly bool
+}
+
+// getBLKBackendSpecific interprets the Controller.BackendSpecific value for
+// map entries with key "block". See spdk_vhost_blk_dump_info_json().
+func getBLKBackendSpecific(in interface{}) BLKControllerSpecific {
+	result := BLKControllerSpecific{}
+	hash, ok := in.(map[string]interface{})
+	if !ok {
+		return result
+	}
+	for key, value := range hash {
+		switch key {
+		case "bdev":
+			// null when the BDev was removed.
+			if name, ok := value.(string); ok {
+				result.BDevName = name
+			}
+		case "readonly":
+			if readonly, ok := value.(bool); ok {
+				result.ReadOnly = readonly
+			}
+		}
+	}
+	return result
+}
+
 // getSCSIBackendSpecific interprets the Controller.BackendSpecific value for
 // map entries with key "scsi". See https://github.com/spdk/spdk/issues/329#issuecomment-396266197
 // and spdk_vhost_scsi_dump_info_json().
@@ -278,9 +335,342 @@ func GetVHostControllers(ctx context.Context, client *Client) (GetVHostControlle
 				switch backend {
 				case "scsi":
 					controller.BackendSpecific[backend] = getSCSIBackendSpecific(specific)
+				case "block":
+					controller.BackendSpecific[backend] = getBLKBackendSpecific(specific)
 				}
 			}
 		}
 	}
 	return response, err
 }
+
+// nolint: golint
+type NVMfCreateTransportArgs struct {
+	TRType string `json:"trtype"`
+}
+
+// nolint: golint
+func NVMfCreateTransport(ctx context.Context, client *Client, args NVMfCreateTransportArgs) error {
+	return client.Invoke(ctx, "nvmf_create_transport", args, nil)
+}
+
+// nolint: golint
+type NVMfTransport struct {
+	TRType string `json:"trtype"`
+}
+
+// nolint: golint
+type GetNVMfTransportsResponse []NVMfTransport
+
+// nolint: golint
+func GetNVMfTransports(ctx context.Context, client *Client) (GetNVMfTransportsResponse, error) {
+	var response GetNVMfTransportsResponse
+	err := client.Invoke(ctx, "get_nvmf_transports", nil, &response)
+	return response, err
+}
+
+// nolint: golint
+type NVMfSubsystemCreateArgs struct {
+	NQN           string `json:"nqn"`
+	SerialNumber  string `json:"serial_number,omitempty"`
+	AllowAnyHost  bool   `json:"allow_any_host,omitempty"`
+	MaxNamespaces uint32 `json:"max_namespaces,omitempty"`
+}
+
+// nolint: golint
+func NVMfSubsystemCreate(ctx context.Context, client *Client, args NVMfSubsystemCreateArgs) error {
+	return client.Invoke(ctx, "nvmf_subsystem_create", args, nil)
+}
+
+// nolint: golint
+type DeleteNVMfSubsystemArgs struct {
+	NQN string `json:"nqn"`
+}
+
+// nolint: golint
+func DeleteNVMfSubsystem(ctx context.Context, client *Client, args DeleteNVMfSubsystemArgs) error {
+	return client.Invoke(ctx, "delete_nvmf_subsystem", args, nil)
+}
+
+// nolint: golint
+type NVMfListenAddress struct {
+	TRType  string `json:"trtype"`
+	AdrFam  string `json:"adrfam,omitempty"`
+	TRAddr  string `json:"traddr"`
+	TRSvcID string `json:"trsvcid,omitempty"`
+}
+
+// nolint: golint
+type NVMfSubsystemAddListenerArgs struct {
+	NQN           string            `json:"nqn"`
+	ListenAddress NVMfListenAddress `json:"listen_address"`
+}
+
+// nolint: golint
+func NVMfSubsystemAddListener(ctx context.Context, client *Client, args NVMfSubsystemAddListenerArgs) error {
+	return client.Invoke(ctx, "nvmf_subsystem_add_listener", args, nil)
+}
+
+// nolint: golint
+type NVMfNamespace struct {
+	NSID     uint32 `json:"nsid,omitempty"`
+	BDevName string `json:"bdev_name"`
+}
+
+// nolint: golint
+type NVMfSubsystemAddNSArgs struct {
+	NQN       string        `json:"nqn"`
+	Namespace NVMfNamespace `json:"namespace"`
+}
+
+// nolint: golint
+type NVMfSubsystemAddNSResponse uint32
+
+// nolint: golint
+func NVMfSubsystemAddNS(ctx context.Context, client *Client, args NVMfSubsystemAddNSArgs) (NVMfSubsystemAddNSResponse, error) {
+	var response NVMfSubsystemAddNSResponse
+	err := client.Invoke(ctx, "nvmf_subsystem_add_ns", args, &response)
+	return response, err
+}
+
+// nolint: golint
+type NVMfSubsystemRemoveNSArgs struct {
+	NQN  string `json:"nqn"`
+	NSID uint32 `json:"nsid"`
+}
+
+// nolint: golint
+func NVMfSubsystemRemoveNS(ctx context.Context, client *Client, args NVMfSubsystemRemoveNSArgs) error {
+	return client.Invoke(ctx, "nvmf_subsystem_remove_ns", args, nil)
+}
+
+// nolint: golint
+type NVMfSubsystemAddHostArgs struct {
+	NQN  string `json:"nqn"`
+	Host string `json:"host"`
+}
+
+// nolint: golint
+func NVMfSubsystemAddHost(ctx context.Context, client *Client, args NVMfSubsystemAddHostArgs) error {
+	return client.Invoke(ctx, "nvmf_subsystem_add_host", args, nil)
+}
+
+// nolint: golint
+type NVMfSubsystemAllowAnyHostArgs struct {
+	NQN          string `json:"nqn"`
+	AllowAnyHost bool   `json:"allow_any_host"`
+}
+
+// nolint: golint
+func NVMfSubsystemAllowAnyHost(ctx context.Context, client *Client, args NVMfSubsystemAllowAnyHostArgs) error {
+	return client.Invoke(ctx, "nvmf_subsystem_allow_any_host", args, nil)
+}
+
+// nolint: golint
+type NVMfHost struct {
+	NQN string `json:"nqn"`
+}
+
+// nolint: golint
+type NVMfSubsystem struct {
+	NQN             string              `json:"nqn"`
+	Subtype         string              `json:"subtype"`
+	ListenAddresses []NVMfListenAddress `json:"listen_addresses"`
+	AllowAnyHost    bool                `json:"allow_any_host"`
+	Hosts           []NVMfHost          `json:"hosts,omitempty"`
+	SerialNumber    string              `json:"serial_number,omitempty"`
+	Namespaces      []NVMfNamespace     `json:"namespaces,omitempty"`
+}
+
+// nolint: golint
+type GetNVMfSubsystemsResponse []NVMfSubsystem
+
+// nolint: golint
+func GetNVMfSubsystems(ctx context.Context, client *Client) (GetNVMfSubsystemsResponse, error) {
+	var response GetNVMfSubsystemsResponse
+	err := client.Invoke(ctx, "get_nvmf_subsystems", nil, &response)
+	return response, err
+}
+
+// nolint: golint
+type ISCSIPortal struct {
+	Host    string `json:"host"`
+	Port    string `json:"port"`
+	CPUMask string `json:"cpumask,omitempty"`
+}
+
+// nolint: golint
+type AddPortalGroupArgs struct {
+	Tag     int32         `json:"tag"`
+	Portals []ISCSIPortal `json:"portals"`
+}
+
+// nolint: golint
+func AddPortalGroup(ctx context.Context, client *Client, args AddPortalGroupArgs) error {
+	return client.Invoke(ctx, "add_portal_group", args, nil)
+}
+
+// nolint: golint
+type DeletePortalGroupArgs struct {
+	Tag int32 `json:"tag"`
+}
+
+// nolint: golint
+func DeletePortalGroup(ctx context.Context, client *Client, args DeletePortalGroupArgs) error {
+	return client.Invoke(ctx, "delete_portal_group", args, nil)
+}
+
+// nolint: golint
+type PortalGroup struct {
+	Tag     int32         `json:"tag"`
+	Portals []ISCSIPortal `json:"portals"`
+}
+
+// nolint: golint
+type GetPortalGroupsResponse []PortalGroup
+
+// nolint: golint
+func GetPortalGroups(ctx context.Context, client *Client) (GetPortalGroupsResponse, error) {
+	var response GetPortalGroupsResponse
+	err := client.Invoke(ctx, "get_portal_groups", nil, &response)
+	return response, err
+}
+
+// nolint: golint
+type AddInitiatorGroupArgs struct {
+	Tag        int32    `json:"tag"`
+	Initiators []string `json:"initiators"`
+	Netmasks   []string `json:"netmasks"`
+}
+
+// nolint: golint
+func AddInitiatorGroup(ctx context.Context, client *Client, args AddInitiatorGroupArgs) error {
+	return client.Invoke(ctx, "add_initiator_group", args, nil)
+}
+
+// nolint: golint
+type DeleteInitiatorGroupArgs struct {
+	Tag int32 `json:"tag"`
+}
+
+// nolint: golint
+func DeleteInitiatorGroup(ctx context.Context, client *Client, args DeleteInitiatorGroupArgs) error {
+	return client.Invoke(ctx, "delete_initiator_group", args, nil)
+}
+
+// nolint: golint
+type InitiatorGroup struct {
+	Tag        int32    `json:"tag"`
+	Initiators []string `json:"initiators"`
+	Netmasks   []string `json:"netmasks"`
+}
+
+// nolint: golint
+type GetInitiatorGroupsResponse []InitiatorGroup
+
+// nolint: golint
+func GetInitiatorGroups(ctx context.Context, client *Client) (GetInitiatorGroupsResponse, error) {
+	var response GetInitiatorGroupsResponse
+	err := client.Invoke(ctx, "get_initiator_groups", nil, &response)
+	return response, err
+}
+
+// nolint: golint
+type CHAPSecret struct {
+	User    string `json:"user"`
+	Secret  string `json:"secret"`
+	MUser   string `json:"muser,omitempty"`
+	MSecret string `json:"msecret,omitempty"`
+}
+
+// nolint: golint
+type AddISCSIAuthGroupArgs struct {
+	Tag     int32        `json:"tag"`
+	Secrets []CHAPSecret `json:"secrets,omitempty"`
+}
+
+// nolint: golint
+func AddISCSIAuthGroup(ctx context.Context, client *Client, args AddISCSIAuthGroupArgs) error {
+	return client.Invoke(ctx, "add_iscsi_auth_group", args, nil)
+}
+
+// nolint: golint
+type DeleteISCSIAuthGroupArgs struct {
+	Tag int32 `json:"tag"`
+}
+
+// nolint: golint
+func DeleteISCSIAuthGroup(ctx context.Context, client *Client, args DeleteISCSIAuthGroupArgs) error {
+	return client.Invoke(ctx, "delete_iscsi_auth_group", args, nil)
+}
+
+// nolint: golint
+type ISCSIAuthGroup struct {
+	Tag     int32        `json:"tag"`
+	Secrets []CHAPSecret `json:"secrets"`
+}
+
+// nolint: golint
+type GetISCSIAuthGroupsResponse []ISCSIAuthGroup
+
+// nolint: golint
+func GetISCSIAuthGroups(ctx context.Context, client *Client) (GetISCSIAuthGroupsResponse, error) {
+	var response GetISCSIAuthGroupsResponse
+	err := client.Invoke(ctx, "get_iscsi_auth_groups", nil, &response)
+	return response, err
+}
+
+// nolint: golint
+type TargetNodeLUN struct {
+	BDevName string `json:"bdev_name"`
+	LUNID    int32  `json:"lun_id"`
+}
+
+// nolint: golint
+type PGIGMap struct {
+	PGTag int32 `json:"pg_tag"`
+	IGTag int32 `json:"ig_tag"`
+}
+
+// TargetNode is used both for constructing a target node and for
+// describing existing ones. The name can be a complete IQN. SPDK adds
+// its node base (iqn.2016-06.io.spdk by default) to other names.
+type TargetNode struct {
+	Name        string          `json:"name"`
+	AliasName   string          `json:"alias_name"`
+	LUNs        []TargetNodeLUN `json:"luns"`
+	PGIGMaps    []PGIGMap       `json:"pg_ig_maps"`
+	QueueDepth  int32           `json:"queue_depth"`
+	DisableCHAP bool            `json:"disable_chap,omitempty"`
+	RequireCHAP bool            `json:"require_chap,omitempty"`
+	MutualCHAP  bool            `json:"mutual_chap,omitempty"`
+	CHAPGroup   int32           `json:"chap_group,omitempty"`
+}
+
+// nolint: golint
+type ConstructTargetNodeArgs TargetNode
+
+// nolint: golint
+func ConstructTargetNode(ctx context.Context, client *Client, args ConstructTargetNodeArgs) error {
+	return client.Invoke(ctx, "construct_target_node", args, nil)
+}
+
+// nolint: golint
+type DeleteTargetNodeArgs struct {
+	Name string `json:"name"`
+}
+
+// nolint: golint
+func DeleteTargetNode(ctx context.Context, client *Client, args DeleteTargetNodeArgs) error {
+	return client.Invoke(ctx, "delete_target_node", args, nil)
+}
+
+// nolint: golint
+type GetTargetNodesResponse []TargetNode
+
+// nolint: golint
+func GetTargetNodes(ctx context.Context, client *Client) (GetTargetNodesResponse, error) {
+	var response GetTargetNodesResponse
+	err := client.Invoke(ctx, "get_target_nodes", nil, &response)
+	return response, err
+}
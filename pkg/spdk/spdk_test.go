@@ -8,8 +8,13 @@ package spdk_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -57,6 +62,83 @@ func TestError(t *testing.T) {
 	require.True(t, spdk.IsJSONError(err, spdk.ERROR_INVALID_PARAMS), "IsJSONError(%+v, ERROR_INVALID_PARAMS)", err)
 }
 
+// fakeSPDK answers one get_bdevs call per connection with an empty
+// list and then closes the connection, like a SPDK which restarts
+// after each call.
+func fakeSPDK(t *testing.T, path string) (connections func() int, stop func()) {
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	var mutex sync.Mutex
+	accepted := 0
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mutex.Lock()
+			accepted++
+			mutex.Unlock()
+			var request struct {
+				ID uint64 `json:"id"`
+			}
+			if err := json.NewDecoder(conn).Decode(&request); err == nil {
+				fmt.Fprintf(conn, `{"jsonrpc": "2.0", "id": %d, "result": []}`, request.ID)
+			}
+			conn.Close()
+		}
+	}()
+	connections = func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return accepted
+	}
+	return connections, func() { listener.Close() }
+}
+
+func TestReconnect(t *testing.T) {
+	defer testlog.SetGlobal(t)()
+	ctx := context.Background()
+	tmp, err := ioutil.TempDir("", "spdk")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "spdk.sock")
+	connections, stop := fakeSPDK(t, path)
+	defer stop()
+
+	client, err := spdk.New(path)
+	require.NoError(t, err)
+	defer client.Close()
+	reconnected := make(chan bool, 10)
+	client.OnReconnect(func() { reconnected <- true })
+
+	_, err = spdk.GetBDevs(ctx, client, spdk.GetBDevsArgs{})
+	require.NoError(t, err, "first call")
+
+	// A call may fail while the client has not noticed yet that
+	// the connection is gone, but not once it has.
+	for i := 0; ; i++ {
+		_, err = spdk.GetBDevs(ctx, client, spdk.GetBDevsArgs{})
+		if err == nil {
+			break
+		}
+		require.True(t, i < 10, "call after reconnect: %v", err)
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, 2, connections())
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reconnect callback not invoked")
+	}
+
+	// No reconnect after closing.
+	client.Close()
+	_, err = spdk.GetBDevs(ctx, client, spdk.GetBDevsArgs{})
+	assert.Error(t, err, "closed")
+	assert.Equal(t, 2, connections())
+}
+
 func TestMallocBDev(t *testing.T) {
 	defer testlog.SetGlobal(t)()
 	ctx := context.Background()
@@ -238,8 +320,9 @@ func TestSCSI(t *testing.T) {
 		Controller: controller,
 		BDevName:   string(created),
 	}
-	err = spdk.AddVHostSCSILUN(ctx, client, addLUN)
+	target, err := spdk.AddVHostSCSILUN(ctx, client, addLUN)
 	require.NoError(t, err, "AddVHostSCSILUN %v", addLUN)
+	assert.Equal(t, spdk.AddVHostSCSILUNResponse(0), target, "target")
 	expected[0].BackendSpecific["scsi"] = spdk.SCSIControllerSpecific{
 		spdk.SCSIControllerTarget{
 			TargetName: "Target 0",
@@ -257,8 +340,9 @@ func TestSCSI(t *testing.T) {
 		SCSITargetNum: 1,
 		BDevName:      string(created2),
 	}
-	err = spdk.AddVHostSCSILUN(ctx, client, addLUN2)
+	target, err = spdk.AddVHostSCSILUN(ctx, client, addLUN2)
 	require.NoError(t, err, "AddVHostSCSILUN %v", addLUN2)
+	assert.Equal(t, spdk.AddVHostSCSILUNResponse(1), target, "target")
 	expected[0].BackendSpecific["scsi"] = spdk.SCSIControllerSpecific{
 		spdk.SCSIControllerTarget{
 			TargetName: "Target 0",
@@ -319,6 +403,19 @@ func TestSCSI(t *testing.T) {
 	}
 	checkControllers(t, expected)
 
+	// SPDK picks the first unused target.
+	addAny := spdk.AddVHostSCSILUNArgs{
+		Controller:    controller,
+		SCSITargetNum: spdk.AnySCSITarget,
+		BDevName:      string(created),
+	}
+	target, err = spdk.AddVHostSCSILUN(ctx, client, addAny)
+	require.NoError(t, err, "AddVHostSCSILUN %v", addAny)
+	assert.Equal(t, spdk.AddVHostSCSILUNResponse(0), target, "target")
+	err = spdk.RemoveVHostSCSITarget(ctx, client, removeArgs)
+	require.NoError(t, err, "RemoveVHostSCSITarget %v", removeArgs)
+	checkControllers(t, expected)
+
 	// Cannot remove non-empty controller.
 	err = spdk.RemoveVHostController(ctx, client, spdk.RemoveVHostControllerArgs{Controller: controller})
 	require.Error(t, err, "Remove VHost controller %s", controller)
@@ -329,3 +426,202 @@ func TestSCSI(t *testing.T) {
 	expected = expected[0:1]
 	checkControllers(t, expected)
 }
+
+func TestBLK(t *testing.T) {
+	defer testlog.SetGlobal(t)()
+	ctx := context.Background()
+	defer testspdk.Finalize()
+	client := connect(t)
+	defer client.Close()
+
+	checkControllers := func(t *testing.T, expected spdk.GetVHostControllersResponse) {
+		controllers, err := spdk.GetVHostControllers(ctx, client)
+		require.NoError(t, err, "GetVHostControllers")
+		assert.Equal(t, expected, controllers)
+	}
+
+	bdevArgs := spdk.ConstructMallocBDevArgs{ConstructBDevArgs: spdk.ConstructBDevArgs{NumBlocks: 2048, BlockSize: 512}}
+	created, err := spdk.ConstructMallocBDev(ctx, client, bdevArgs)
+	require.NoError(t, err, "Construct Malloc BDev with %v", bdevArgs)
+	defer spdk.DeleteBDev(ctx, client, spdk.DeleteBDevArgs{Name: string(created)})
+
+	controller := "my-blk-vhost"
+	constructArgs := spdk.ConstructVHostBLKControllerArgs{
+		Controller: controller,
+		DevName:    string(created),
+	}
+	err = spdk.ConstructVHostBLKController(ctx, client, constructArgs)
+	require.NoError(t, err, "Construct VHostBLK controller with %v", constructArgs)
+	defer spdk.RemoveVHostController(ctx, client, spdk.RemoveVHostControllerArgs{Controller: controller})
+
+	checkControllers(t, spdk.GetVHostControllersResponse{
+		spdk.Controller{
+			Controller: controller,
+			CPUMask:    "0x1",
+			BackendSpecific: spdk.BackendSpecificType{
+				"block": spdk.BLKControllerSpecific{
+					BDevName: string(created),
+				},
+			},
+		},
+	})
+
+	// The same BDev cannot be used twice.
+	constructArgs2 := spdk.ConstructVHostBLKControllerArgs{
+		Controller: controller + "2",
+		DevName:    string(created),
+	}
+	err = spdk.ConstructVHostBLKController(ctx, client, constructArgs2)
+	require.Error(t, err, "Construct VHostBLK controller with %v", constructArgs2)
+
+	err = spdk.RemoveVHostController(ctx, client, spdk.RemoveVHostControllerArgs{Controller: controller})
+	require.NoError(t, err, "Remove VHost controller %s", controller)
+	checkControllers(t, spdk.GetVHostControllersResponse{})
+}
+
+func TestNVMf(t *testing.T) {
+	defer testlog.SetGlobal(t)()
+	ctx := context.Background()
+	defer testspdk.Finalize()
+	client := connect(t)
+	defer client.Close()
+
+	// Only available in SPDK binaries with the NVMe-oF target.
+	transports, err := spdk.GetNVMfTransports(ctx, client)
+	if spdk.IsJSONError(err, spdk.ERROR_METHOD_NOT_FOUND) {
+		t.Skip("No NVMe-oF target in SPDK.")
+	}
+	require.NoError(t, err, "GetNVMfTransports")
+	haveTCP := false
+	for _, transport := range transports {
+		if transport.TRType == "TCP" {
+			haveTCP = true
+		}
+	}
+	if !haveTCP {
+		err = spdk.NVMfCreateTransport(ctx, client, spdk.NVMfCreateTransportArgs{TRType: "TCP"})
+		require.NoError(t, err, "NVMfCreateTransport")
+	}
+
+	bdevArgs := spdk.ConstructMallocBDevArgs{ConstructBDevArgs: spdk.ConstructBDevArgs{NumBlocks: 2048, BlockSize: 512}}
+	created, err := spdk.ConstructMallocBDev(ctx, client, bdevArgs)
+	require.NoError(t, err, "Construct Malloc BDev with %v", bdevArgs)
+	defer spdk.DeleteBDev(ctx, client, spdk.DeleteBDevArgs{Name: string(created)})
+
+	nqn := "nqn.2018-09.io.oim:spdk-test"
+	createArgs := spdk.NVMfSubsystemCreateArgs{
+		NQN:          nqn,
+		SerialNumber: "OIM0001",
+		AllowAnyHost: true,
+	}
+	err = spdk.NVMfSubsystemCreate(ctx, client, createArgs)
+	require.NoError(t, err, "NVMfSubsystemCreate %v", createArgs)
+	defer spdk.DeleteNVMfSubsystem(ctx, client, spdk.DeleteNVMfSubsystemArgs{NQN: nqn})
+
+	err = spdk.NVMfSubsystemAllowAnyHost(ctx, client, spdk.NVMfSubsystemAllowAnyHostArgs{NQN: nqn, AllowAnyHost: false})
+	require.NoError(t, err, "NVMfSubsystemAllowAnyHost")
+	hostNQN := "nqn.2018-09.io.oim:host:spdk-test"
+	err = spdk.NVMfSubsystemAddHost(ctx, client, spdk.NVMfSubsystemAddHostArgs{NQN: nqn, Host: hostNQN})
+	require.NoError(t, err, "NVMfSubsystemAddHost")
+
+	listener := spdk.NVMfListenAddress{
+		TRType:  "TCP",
+		AdrFam:  "IPv4",
+		TRAddr:  "127.0.0.1",
+		TRSvcID: "4420",
+	}
+	err = spdk.NVMfSubsystemAddListener(ctx, client, spdk.NVMfSubsystemAddListenerArgs{NQN: nqn, ListenAddress: listener})
+	require.NoError(t, err, "NVMfSubsystemAddListener")
+
+	nsid, err := spdk.NVMfSubsystemAddNS(ctx, client, spdk.NVMfSubsystemAddNSArgs{
+		NQN:       nqn,
+		Namespace: spdk.NVMfNamespace{BDevName: string(created)},
+	})
+	require.NoError(t, err, "NVMfSubsystemAddNS")
+	assert.Equal(t, spdk.NVMfSubsystemAddNSResponse(1), nsid, "first namespace ID")
+
+	subsystems, err := spdk.GetNVMfSubsystems(ctx, client)
+	require.NoError(t, err, "GetNVMfSubsystems")
+	var found *spdk.NVMfSubsystem
+	for i := range subsystems {
+		if subsystems[i].NQN == nqn {
+			found = &subsystems[i]
+		}
+	}
+	if assert.NotNil(t, found, "subsystem %s in %v", nqn, subsystems) {
+		assert.Equal(t, []spdk.NVMfListenAddress{listener}, found.ListenAddresses)
+		assert.Equal(t, []spdk.NVMfNamespace{{NSID: 1, BDevName: string(created)}}, found.Namespaces)
+		assert.False(t, found.AllowAnyHost, "allow any host")
+		assert.Equal(t, []spdk.NVMfHost{{NQN: hostNQN}}, found.Hosts)
+	}
+
+	err = spdk.NVMfSubsystemRemoveNS(ctx, client, spdk.NVMfSubsystemRemoveNSArgs{NQN: nqn, NSID: uint32(nsid)})
+	require.NoError(t, err, "NVMfSubsystemRemoveNS")
+	err = spdk.DeleteNVMfSubsystem(ctx, client, spdk.DeleteNVMfSubsystemArgs{NQN: nqn})
+	require.NoError(t, err, "DeleteNVMfSubsystem")
+}
+
+func TestISCSI(t *testing.T) {
+	defer testlog.SetGlobal(t)()
+	ctx := context.Background()
+	defer testspdk.Finalize()
+	client := connect(t)
+	defer client.Close()
+
+	// Only available in SPDK binaries with the iSCSI target.
+	_, err := spdk.GetPortalGroups(ctx, client)
+	if spdk.IsJSONError(err, spdk.ERROR_METHOD_NOT_FOUND) {
+		t.Skip("No iSCSI target in SPDK.")
+	}
+	require.NoError(t, err, "GetPortalGroups")
+
+	bdevArgs := spdk.ConstructMallocBDevArgs{ConstructBDevArgs: spdk.ConstructBDevArgs{NumBlocks: 2048, BlockSize: 512}}
+	created, err := spdk.ConstructMallocBDev(ctx, client, bdevArgs)
+	require.NoError(t, err, "Construct Malloc BDev with %v", bdevArgs)
+	defer spdk.DeleteBDev(ctx, client, spdk.DeleteBDevArgs{Name: string(created)})
+
+	portal := spdk.ISCSIPortal{Host: "127.0.0.1", Port: "3260"}
+	err = spdk.AddPortalGroup(ctx, client, spdk.AddPortalGroupArgs{Tag: 101, Portals: []spdk.ISCSIPortal{portal}})
+	require.NoError(t, err, "AddPortalGroup")
+	defer spdk.DeletePortalGroup(ctx, client, spdk.DeletePortalGroupArgs{Tag: 101})
+	portalGroups, err := spdk.GetPortalGroups(ctx, client)
+	require.NoError(t, err, "GetPortalGroups")
+	assert.Contains(t, portalGroups, spdk.PortalGroup{Tag: 101, Portals: []spdk.ISCSIPortal{portal}})
+
+	initiatorGroup := spdk.InitiatorGroup{Tag: 102, Initiators: []string{"ANY"}, Netmasks: []string{"ANY"}}
+	err = spdk.AddInitiatorGroup(ctx, client, spdk.AddInitiatorGroupArgs(initiatorGroup))
+	require.NoError(t, err, "AddInitiatorGroup")
+	defer spdk.DeleteInitiatorGroup(ctx, client, spdk.DeleteInitiatorGroupArgs{Tag: 102})
+	initiatorGroups, err := spdk.GetInitiatorGroups(ctx, client)
+	require.NoError(t, err, "GetInitiatorGroups")
+	assert.Contains(t, initiatorGroups, initiatorGroup)
+
+	authGroup := spdk.ISCSIAuthGroup{Tag: 103, Secrets: []spdk.CHAPSecret{{User: "user", Secret: "0123456789ab"}}}
+	err = spdk.AddISCSIAuthGroup(ctx, client, spdk.AddISCSIAuthGroupArgs(authGroup))
+	require.NoError(t, err, "AddISCSIAuthGroup")
+	defer spdk.DeleteISCSIAuthGroup(ctx, client, spdk.DeleteISCSIAuthGroupArgs{Tag: 103})
+	authGroups, err := spdk.GetISCSIAuthGroups(ctx, client)
+	require.NoError(t, err, "GetISCSIAuthGroups")
+	assert.Contains(t, authGroups, authGroup)
+
+	iqn := "iqn.2018-09.io.oim:spdk-test"
+	target := spdk.TargetNode{
+		Name:        iqn,
+		AliasName:   "spdk-test",
+		LUNs:        []spdk.TargetNodeLUN{{BDevName: string(created), LUNID: 0}},
+		PGIGMaps:    []spdk.PGIGMap{{PGTag: 101, IGTag: 102}},
+		QueueDepth:  64,
+		RequireCHAP: true,
+		CHAPGroup:   103,
+	}
+	err = spdk.ConstructTargetNode(ctx, client, spdk.ConstructTargetNodeArgs(target))
+	require.NoError(t, err, "ConstructTargetNode %v", target)
+	defer spdk.DeleteTargetNode(ctx, client, spdk.DeleteTargetNodeArgs{Name: iqn})
+
+	targets, err := spdk.GetTargetNodes(ctx, client)
+	require.NoError(t, err, "GetTargetNodes")
+	assert.Contains(t, targets, target)
+
+	err = spdk.DeleteTargetNode(ctx, client, spdk.DeleteTargetNodeArgs{Name: iqn})
+	require.NoError(t, err, "DeleteTargetNode")
+}
@@ -11,14 +11,27 @@
 		SetValueRequest
 		Value
 		SetValueReply
+		SetValuesRequest
+		RevisionGuard
+		SetValuesReply
 		GetValuesRequest
 		GetValuesReply
+		WatchValuesRequest
+		WatchValuesReply
+		ExportRequest
+		ExportReply
+		ImportRequest
+		ImportReply
 		MapVolumeRequest
 		MallocParams
 		CephParams
+		ISCSICHAP
 		MapVolumeReply
 		PCIAddress
 		SCSIDisk
+		BlkDisk
+		NVMeoFDisk
+		ISCSIDisk
 		UnmapVolumeRequest
 		UnmapVolumeReply
 		ProvisionMallocBDevRequest
@@ -31,7 +44,7 @@ package oim
 import proto "github.com/gogo/protobuf/proto"
 import fmt "fmt"
 import math "math"
-import _ "github.com/gogo/protobuf/types"
+import google_protobuf "github.com/gogo/protobuf/types"
 
 import "context"
 import grpc "google.golang.org/grpc"
@@ -49,8 +62,48 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 
+type WatchValuesReply_Type int32
+
+const (
+	// All current values.
+	WatchValuesReply_SNAPSHOT WatchValuesReply_Type = 0
+	// A value was set or overwritten.
+	WatchValuesReply_PUT WatchValuesReply_Type = 1
+	// A value was removed.
+	WatchValuesReply_DELETE WatchValuesReply_Type = 2
+)
+
+var WatchValuesReply_Type_name = map[int32]string{
+	0: "SNAPSHOT",
+	1: "PUT",
+	2: "DELETE",
+}
+var WatchValuesReply_Type_value = map[string]int32{
+	"SNAPSHOT": 0,
+	"PUT":      1,
+	"DELETE":   2,
+}
+
+func (x WatchValuesReply_Type) String() string {
+	return proto.EnumName(WatchValuesReply_Type_name, int32(x))
+}
+func (WatchValuesReply_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptorOim, []int{9, 0} }
+
 type SetValueRequest struct {
 	Value *Value `protobuf:"bytes,1,opt,name=value" json:"value,omitempty"`
+	// If non-zero, the value gets removed automatically
+	// after this many seconds unless it gets set again
+	// before that. Setting it again without a TTL makes
+	// the value permanent. Controllers use this to ensure
+	// that their address vanishes when they stop
+	// refreshing it.
+	TtlSeconds uint32 `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	// If set, the value is only modified if its current
+	// revision matches the expected one. Zero means that
+	// the value must not exist yet. The registry replies
+	// with a gRPC "FailedPrecondition" error when that
+	// is not the case.
+	ExpectedRevision *google_protobuf.Int64Value `protobuf:"bytes,3,opt,name=expected_revision,json=expectedRevision" json:"expected_revision,omitempty"`
 }
 
 func (m *SetValueRequest) Reset()                    { *m = SetValueRequest{} }
@@ -65,6 +118,20 @@ func (m *SetValueRequest) GetValue() *Value {
 	return nil
 }
 
+func (m *SetValueRequest) GetTtlSeconds() uint32 {
+	if m != nil {
+		return m.TtlSeconds
+	}
+	return 0
+}
+
+func (m *SetValueRequest) GetExpectedRevision() *google_protobuf.Int64Value {
+	if m != nil {
+		return m.ExpectedRevision
+	}
+	return nil
+}
+
 // A single registry DB entry.
 type Value struct {
 	// A value is referenced by a set of path elements,
@@ -74,6 +141,14 @@ type Value struct {
 	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
 	// The value itself is also a string.
 	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// The registry DB revision at which the value was
+	// last modified. Set by the registry in replies,
+	// ignored in requests.
+	Revision int64 `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"`
+	// True if the value was stored with a TTL and thus
+	// gets removed unless refreshed. Only set by Export
+	// and only used by Import.
+	Temporary bool `protobuf:"varint,4,opt,name=temporary,proto3" json:"temporary,omitempty"`
 }
 
 func (m *Value) Reset()                    { *m = Value{} }
@@ -95,7 +170,23 @@ func (m *Value) GetValue() string {
 	return ""
 }
 
+func (m *Value) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+func (m *Value) GetTemporary() bool {
+	if m != nil {
+		return m.Temporary
+	}
+	return false
+}
+
 type SetValueReply struct {
+	// The registry DB revision after the change.
+	Revision int64 `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
 }
 
 func (m *SetValueReply) Reset()                    { *m = SetValueReply{} }
@@ -103,6 +194,86 @@ func (m *SetValueReply) String() string            { return proto.CompactTextStr
 func (*SetValueReply) ProtoMessage()               {}
 func (*SetValueReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{2} }
 
+func (m *SetValueReply) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+type SetValuesRequest struct {
+	// Each change is checked and applied like a single
+	// SetValue call, including the permission checks.
+	// A path may only be modified once.
+	Changes []*SetValueRequest `protobuf:"bytes,1,rep,name=changes" json:"changes,omitempty"`
+	// Additional revision checks for values which are
+	// not necessarily modified.
+	Guards []*RevisionGuard `protobuf:"bytes,2,rep,name=guards" json:"guards,omitempty"`
+}
+
+func (m *SetValuesRequest) Reset()                    { *m = SetValuesRequest{} }
+func (m *SetValuesRequest) String() string            { return proto.CompactTextString(m) }
+func (*SetValuesRequest) ProtoMessage()               {}
+func (*SetValuesRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{3} }
+
+func (m *SetValuesRequest) GetChanges() []*SetValueRequest {
+	if m != nil {
+		return m.Changes
+	}
+	return nil
+}
+
+func (m *SetValuesRequest) GetGuards() []*RevisionGuard {
+	if m != nil {
+		return m.Guards
+	}
+	return nil
+}
+
+type RevisionGuard struct {
+	// The complete path of a value.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// The value must have been last modified at this
+	// revision. Zero means that it must not exist.
+	Revision int64 `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+}
+
+func (m *RevisionGuard) Reset()                    { *m = RevisionGuard{} }
+func (m *RevisionGuard) String() string            { return proto.CompactTextString(m) }
+func (*RevisionGuard) ProtoMessage()               {}
+func (*RevisionGuard) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{4} }
+
+func (m *RevisionGuard) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *RevisionGuard) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+type SetValuesReply struct {
+	// The registry DB revision after the change.
+	Revision int64 `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+}
+
+func (m *SetValuesReply) Reset()                    { *m = SetValuesReply{} }
+func (m *SetValuesReply) String() string            { return proto.CompactTextString(m) }
+func (*SetValuesReply) ProtoMessage()               {}
+func (*SetValuesReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{5} }
+
+func (m *SetValuesReply) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
 type GetValuesRequest struct {
 	// Return all values beneath or at the given path,
 	// all values when empty.
@@ -112,7 +283,7 @@ type GetValuesRequest struct {
 func (m *GetValuesRequest) Reset()                    { *m = GetValuesRequest{} }
 func (m *GetValuesRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetValuesRequest) ProtoMessage()               {}
-func (*GetValuesRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{3} }
+func (*GetValuesRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{6} }
 
 func (m *GetValuesRequest) GetPath() string {
 	if m != nil {
@@ -129,7 +300,7 @@ type GetValuesReply struct {
 func (m *GetValuesReply) Reset()                    { *m = GetValuesReply{} }
 func (m *GetValuesReply) String() string            { return proto.CompactTextString(m) }
 func (*GetValuesReply) ProtoMessage()               {}
-func (*GetValuesReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{4} }
+func (*GetValuesReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{7} }
 
 func (m *GetValuesReply) GetValues() []*Value {
 	if m != nil {
@@ -138,6 +309,158 @@ func (m *GetValuesReply) GetValues() []*Value {
 	return nil
 }
 
+type WatchValuesRequest struct {
+	// Watch all values beneath or at the given path,
+	// all values when empty.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+}
+
+func (m *WatchValuesRequest) Reset()                    { *m = WatchValuesRequest{} }
+func (m *WatchValuesRequest) String() string            { return proto.CompactTextString(m) }
+func (*WatchValuesRequest) ProtoMessage()               {}
+func (*WatchValuesRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{8} }
+
+func (m *WatchValuesRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type WatchValuesReply struct {
+	Type WatchValuesReply_Type `protobuf:"varint,1,opt,name=type,proto3,enum=oim.v0.WatchValuesReply_Type" json:"type,omitempty"`
+	// The current values for SNAPSHOT, exactly one value
+	// for PUT and DELETE. The value string is empty for
+	// DELETE.
+	Values []*Value `protobuf:"bytes,2,rep,name=values" json:"values,omitempty"`
+	// The registry DB revision at which the snapshot was
+	// taken or the change happened. Revisions increase
+	// with each change of the registry DB.
+	Revision int64 `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"`
+}
+
+func (m *WatchValuesReply) Reset()                    { *m = WatchValuesReply{} }
+func (m *WatchValuesReply) String() string            { return proto.CompactTextString(m) }
+func (*WatchValuesReply) ProtoMessage()               {}
+func (*WatchValuesReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{9} }
+
+func (m *WatchValuesReply) GetType() WatchValuesReply_Type {
+	if m != nil {
+		return m.Type
+	}
+	return WatchValuesReply_SNAPSHOT
+}
+
+func (m *WatchValuesReply) GetValues() []*Value {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+func (m *WatchValuesReply) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+type ExportRequest struct {
+}
+
+func (m *ExportRequest) Reset()                    { *m = ExportRequest{} }
+func (m *ExportRequest) String() string            { return proto.CompactTextString(m) }
+func (*ExportRequest) ProtoMessage()               {}
+func (*ExportRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{10} }
+
+type ExportReply struct {
+	// Some of the current values, including their
+	// revision.
+	Values []*Value `protobuf:"bytes,1,rep,name=values" json:"values,omitempty"`
+	// The registry DB revision at which the snapshot was
+	// taken. The same in all replies.
+	Revision int64 `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+}
+
+func (m *ExportReply) Reset()                    { *m = ExportReply{} }
+func (m *ExportReply) String() string            { return proto.CompactTextString(m) }
+func (*ExportReply) ProtoMessage()               {}
+func (*ExportReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{11} }
+
+func (m *ExportReply) GetValues() []*Value {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+func (m *ExportReply) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+type ImportRequest struct {
+	// If set in the first request, all values which are
+	// not part of the import are removed, except for
+	// temporary ones.
+	Replace bool `protobuf:"varint,1,opt,name=replace,proto3" json:"replace,omitempty"`
+	// Values to set or overwrite. The revision is ignored,
+	// the registry assigns new revisions. A path may only
+	// be imported once and the value must not be empty.
+	// Temporary values are skipped, because importing
+	// them without their TTL would make them permanent.
+	// The controllers which stored them with a TTL
+	// register again by themselves.
+	Values []*Value `protobuf:"bytes,2,rep,name=values" json:"values,omitempty"`
+}
+
+func (m *ImportRequest) Reset()                    { *m = ImportRequest{} }
+func (m *ImportRequest) String() string            { return proto.CompactTextString(m) }
+func (*ImportRequest) ProtoMessage()               {}
+func (*ImportRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{12} }
+
+func (m *ImportRequest) GetReplace() bool {
+	if m != nil {
+		return m.Replace
+	}
+	return false
+}
+
+func (m *ImportRequest) GetValues() []*Value {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type ImportReply struct {
+	// The registry DB revision after the import.
+	Revision int64 `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	// Paths of temporary values which were not imported.
+	Skipped []string `protobuf:"bytes,2,rep,name=skipped" json:"skipped,omitempty"`
+}
+
+func (m *ImportReply) Reset()                    { *m = ImportReply{} }
+func (m *ImportReply) String() string            { return proto.CompactTextString(m) }
+func (*ImportReply) ProtoMessage()               {}
+func (*ImportReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{13} }
+
+func (m *ImportReply) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+func (m *ImportReply) GetSkipped() []string {
+	if m != nil {
+		return m.Skipped
+	}
+	return nil
+}
+
 type MapVolumeRequest struct {
 	// An identifier for the volume that must be unique
 	// among all volumes mapped by the OIM controller.
@@ -150,12 +473,28 @@ type MapVolumeRequest struct {
 	//	*MapVolumeRequest_Malloc
 	//	*MapVolumeRequest_Ceph
 	Params isMapVolumeRequest_Params `protobuf_oneof:"params"`
+	// Optional CHAP credentials. When the controller exports
+	// the volume via iSCSI, initiators must log in with them.
+	// Ignored otherwise.
+	IscsiChap *ISCSICHAP `protobuf:"bytes,4,opt,name=iscsi_chap,json=iscsiChap" json:"iscsi_chap,omitempty"`
+	// NVMe qualified name (NQN) of the host. When the
+	// controller exports the volume via NVMe-oF, only this
+	// host may connect to the subsystem, unless the
+	// controller was configured to allow any host.
+	// Ignored otherwise.
+	HostNqn string `protobuf:"bytes,5,opt,name=host_nqn,json=hostNqn,proto3" json:"host_nqn,omitempty"`
+	// iSCSI qualified name (IQN) of the initiator. When the
+	// controller exports the volume via iSCSI, only this
+	// initiator may log into the target, unless the
+	// controller was configured to allow any initiator.
+	// Ignored otherwise.
+	IscsiInitiator string `protobuf:"bytes,6,opt,name=iscsi_initiator,json=iscsiInitiator,proto3" json:"iscsi_initiator,omitempty"`
 }
 
 func (m *MapVolumeRequest) Reset()                    { *m = MapVolumeRequest{} }
 func (m *MapVolumeRequest) String() string            { return proto.CompactTextString(m) }
 func (*MapVolumeRequest) ProtoMessage()               {}
-func (*MapVolumeRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{5} }
+func (*MapVolumeRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{14} }
 
 type isMapVolumeRequest_Params interface {
 	isMapVolumeRequest_Params()
@@ -201,6 +540,27 @@ func (m *MapVolumeRequest) GetCeph() *CephParams {
 	return nil
 }
 
+func (m *MapVolumeRequest) GetIscsiChap() *ISCSICHAP {
+	if m != nil {
+		return m.IscsiChap
+	}
+	return nil
+}
+
+func (m *MapVolumeRequest) GetHostNqn() string {
+	if m != nil {
+		return m.HostNqn
+	}
+	return ""
+}
+
+func (m *MapVolumeRequest) GetIscsiInitiator() string {
+	if m != nil {
+		return m.IscsiInitiator
+	}
+	return ""
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*MapVolumeRequest) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _MapVolumeRequest_OneofMarshaler, _MapVolumeRequest_OneofUnmarshaler, _MapVolumeRequest_OneofSizer, []interface{}{
@@ -285,7 +645,7 @@ type MallocParams struct {
 func (m *MallocParams) Reset()                    { *m = MallocParams{} }
 func (m *MallocParams) String() string            { return proto.CompactTextString(m) }
 func (*MallocParams) ProtoMessage()               {}
-func (*MallocParams) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{6} }
+func (*MallocParams) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{15} }
 
 // Defines a Ceph block device.
 type CephParams struct {
@@ -305,7 +665,7 @@ type CephParams struct {
 func (m *CephParams) Reset()                    { *m = CephParams{} }
 func (m *CephParams) String() string            { return proto.CompactTextString(m) }
 func (*CephParams) ProtoMessage()               {}
-func (*CephParams) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{7} }
+func (*CephParams) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{16} }
 
 func (m *CephParams) GetUserId() string {
 	if m != nil {
@@ -342,22 +702,62 @@ func (m *CephParams) GetImage() string {
 	return ""
 }
 
+// One-way CHAP credentials for an iSCSI target.
+type ISCSICHAP struct {
+	// The CHAP user name.
+	User string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	// The CHAP secret.
+	Secret string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+}
+
+func (m *ISCSICHAP) Reset()                    { *m = ISCSICHAP{} }
+func (m *ISCSICHAP) String() string            { return proto.CompactTextString(m) }
+func (*ISCSICHAP) ProtoMessage()               {}
+func (*ISCSICHAP) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{17} }
+
+func (m *ISCSICHAP) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *ISCSICHAP) GetSecret() string {
+	if m != nil {
+		return m.Secret
+	}
+	return ""
+}
+
 // The reply must tell the caller enough about the mapped volume
 // to find it in /sys/dev/block.
 type MapVolumeReply struct {
 	// The PCI address (domain/bus/device/function, extended BDF).
 	// A controller which does not know its own PCI address can
-	// return a an address with all fields set to 0xFFFF.
+	// return a an address with all fields set to 0xFFFF. Not set
+	// for volumes exported via NVMe-oF or iSCSI.
 	PciAddress *PCIAddress `protobuf:"bytes,1,opt,name=pci_address,json=pciAddress" json:"pci_address,omitempty"`
 	// The SCSI target and LUN. Only present for disks attached
 	// via a SCSI controller.
 	ScsiDisk *SCSIDisk `protobuf:"bytes,2,opt,name=scsi_disk,json=scsiDisk" json:"scsi_disk,omitempty"`
+	// Present instead of scsi_disk for disks attached via
+	// virtio-blk. The PCI address then identifies the disk
+	// itself.
+	BlkDisk *BlkDisk `protobuf:"bytes,3,opt,name=blk_disk,json=blkDisk" json:"blk_disk,omitempty"`
+	// Present instead of a PCI address for volumes which are
+	// exported via NVMe over Fabrics. The host must connect to
+	// the subsystem itself.
+	NvmeofDisk *NVMeoFDisk `protobuf:"bytes,4,opt,name=nvmeof_disk,json=nvmeofDisk" json:"nvmeof_disk,omitempty"`
+	// Present instead of a PCI address for volumes which are
+	// exported via iSCSI. The host must log into the target
+	// itself.
+	IscsiDisk *ISCSIDisk `protobuf:"bytes,5,opt,name=iscsi_disk,json=iscsiDisk" json:"iscsi_disk,omitempty"`
 }
 
 func (m *MapVolumeReply) Reset()                    { *m = MapVolumeReply{} }
 func (m *MapVolumeReply) String() string            { return proto.CompactTextString(m) }
 func (*MapVolumeReply) ProtoMessage()               {}
-func (*MapVolumeReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{8} }
+func (*MapVolumeReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{18} }
 
 func (m *MapVolumeReply) GetPciAddress() *PCIAddress {
 	if m != nil {
@@ -373,6 +773,27 @@ func (m *MapVolumeReply) GetScsiDisk() *SCSIDisk {
 	return nil
 }
 
+func (m *MapVolumeReply) GetBlkDisk() *BlkDisk {
+	if m != nil {
+		return m.BlkDisk
+	}
+	return nil
+}
+
+func (m *MapVolumeReply) GetNvmeofDisk() *NVMeoFDisk {
+	if m != nil {
+		return m.NvmeofDisk
+	}
+	return nil
+}
+
+func (m *MapVolumeReply) GetIscsiDisk() *ISCSIDisk {
+	if m != nil {
+		return m.IscsiDisk
+	}
+	return nil
+}
+
 // Each field can be marked as unknown or unset with 0xFFFF.
 // This leads to nicer code than the other workarounds for missing
 // optional scalars (.google.protobuf.UInt32Value or oneof).
@@ -390,7 +811,7 @@ type PCIAddress struct {
 func (m *PCIAddress) Reset()                    { *m = PCIAddress{} }
 func (m *PCIAddress) String() string            { return proto.CompactTextString(m) }
 func (*PCIAddress) ProtoMessage()               {}
-func (*PCIAddress) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{9} }
+func (*PCIAddress) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{19} }
 
 func (m *PCIAddress) GetDomain() uint32 {
 	if m != nil {
@@ -421,14 +842,17 @@ func (m *PCIAddress) GetFunction() uint32 {
 }
 
 type SCSIDisk struct {
+	// The SCSI target that was allocated for the volume.
 	Target uint32 `protobuf:"varint,1,opt,name=target,proto3" json:"target,omitempty"`
-	Lun    uint32 `protobuf:"varint,2,opt,name=lun,proto3" json:"lun,omitempty"`
+	// Always 0, because SPDK's vhost-scsi supports only
+	// one LUN per target.
+	Lun uint32 `protobuf:"varint,2,opt,name=lun,proto3" json:"lun,omitempty"`
 }
 
 func (m *SCSIDisk) Reset()                    { *m = SCSIDisk{} }
 func (m *SCSIDisk) String() string            { return proto.CompactTextString(m) }
 func (*SCSIDisk) ProtoMessage()               {}
-func (*SCSIDisk) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{10} }
+func (*SCSIDisk) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{20} }
 
 func (m *SCSIDisk) GetTarget() uint32 {
 	if m != nil {
@@ -444,6 +868,108 @@ func (m *SCSIDisk) GetLun() uint32 {
 	return 0
 }
 
+// A virtio-blk device has exactly one disk, so nothing besides
+// the PCI address is needed to find it.
+type BlkDisk struct {
+}
+
+func (m *BlkDisk) Reset()                    { *m = BlkDisk{} }
+func (m *BlkDisk) String() string            { return proto.CompactTextString(m) }
+func (*BlkDisk) ProtoMessage()               {}
+func (*BlkDisk) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{21} }
+
+// Identifies a namespace in a NVMe-oF subsystem and where to
+// reach it.
+type NVMeoFDisk struct {
+	// The NVMe qualified name of the subsystem.
+	Nqn string `protobuf:"bytes,1,opt,name=nqn,proto3" json:"nqn,omitempty"`
+	// The transport type, currently always "tcp".
+	Transport string `protobuf:"bytes,2,opt,name=transport,proto3" json:"transport,omitempty"`
+	// The transport address (IP address or host name) of the
+	// subsystem.
+	Address string `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	// The transport service ID (TCP port) of the subsystem.
+	Port string `protobuf:"bytes,4,opt,name=port,proto3" json:"port,omitempty"`
+	// The namespace ID of the volume in the subsystem.
+	NamespaceId uint32 `protobuf:"varint,5,opt,name=namespace_id,json=namespaceId,proto3" json:"namespace_id,omitempty"`
+}
+
+func (m *NVMeoFDisk) Reset()                    { *m = NVMeoFDisk{} }
+func (m *NVMeoFDisk) String() string            { return proto.CompactTextString(m) }
+func (*NVMeoFDisk) ProtoMessage()               {}
+func (*NVMeoFDisk) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{22} }
+
+func (m *NVMeoFDisk) GetNqn() string {
+	if m != nil {
+		return m.Nqn
+	}
+	return ""
+}
+
+func (m *NVMeoFDisk) GetTransport() string {
+	if m != nil {
+		return m.Transport
+	}
+	return ""
+}
+
+func (m *NVMeoFDisk) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *NVMeoFDisk) GetPort() string {
+	if m != nil {
+		return m.Port
+	}
+	return ""
+}
+
+func (m *NVMeoFDisk) GetNamespaceId() uint32 {
+	if m != nil {
+		return m.NamespaceId
+	}
+	return 0
+}
+
+// Identifies a LUN of an iSCSI target and where to reach it.
+type ISCSIDisk struct {
+	// The iSCSI qualified name of the target.
+	Iqn string `protobuf:"bytes,1,opt,name=iqn,proto3" json:"iqn,omitempty"`
+	// The portal (<ip>:<port>) on which the target listens.
+	Portal string `protobuf:"bytes,2,opt,name=portal,proto3" json:"portal,omitempty"`
+	// The logical unit number of the volume in the target.
+	Lun uint32 `protobuf:"varint,3,opt,name=lun,proto3" json:"lun,omitempty"`
+}
+
+func (m *ISCSIDisk) Reset()                    { *m = ISCSIDisk{} }
+func (m *ISCSIDisk) String() string            { return proto.CompactTextString(m) }
+func (*ISCSIDisk) ProtoMessage()               {}
+func (*ISCSIDisk) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{23} }
+
+func (m *ISCSIDisk) GetIqn() string {
+	if m != nil {
+		return m.Iqn
+	}
+	return ""
+}
+
+func (m *ISCSIDisk) GetPortal() string {
+	if m != nil {
+		return m.Portal
+	}
+	return ""
+}
+
+func (m *ISCSIDisk) GetLun() uint32 {
+	if m != nil {
+		return m.Lun
+	}
+	return 0
+}
+
 type UnmapVolumeRequest struct {
 	// The volume ID that was used when mapping the volume.
 	VolumeId string `protobuf:"bytes,1,opt,name=volume_id,json=volumeId,proto3" json:"volume_id,omitempty"`
@@ -452,7 +978,7 @@ type UnmapVolumeRequest struct {
 func (m *UnmapVolumeRequest) Reset()                    { *m = UnmapVolumeRequest{} }
 func (m *UnmapVolumeRequest) String() string            { return proto.CompactTextString(m) }
 func (*UnmapVolumeRequest) ProtoMessage()               {}
-func (*UnmapVolumeRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{11} }
+func (*UnmapVolumeRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{24} }
 
 func (m *UnmapVolumeRequest) GetVolumeId() string {
 	if m != nil {
@@ -467,7 +993,7 @@ type UnmapVolumeReply struct {
 func (m *UnmapVolumeReply) Reset()                    { *m = UnmapVolumeReply{} }
 func (m *UnmapVolumeReply) String() string            { return proto.CompactTextString(m) }
 func (*UnmapVolumeReply) ProtoMessage()               {}
-func (*UnmapVolumeReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{12} }
+func (*UnmapVolumeReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{25} }
 
 type ProvisionMallocBDevRequest struct {
 	// The desired name of the new BDev.
@@ -479,7 +1005,7 @@ type ProvisionMallocBDevRequest struct {
 func (m *ProvisionMallocBDevRequest) Reset()                    { *m = ProvisionMallocBDevRequest{} }
 func (m *ProvisionMallocBDevRequest) String() string            { return proto.CompactTextString(m) }
 func (*ProvisionMallocBDevRequest) ProtoMessage()               {}
-func (*ProvisionMallocBDevRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{13} }
+func (*ProvisionMallocBDevRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{26} }
 
 func (m *ProvisionMallocBDevRequest) GetBdevName() string {
 	if m != nil {
@@ -501,7 +1027,7 @@ type ProvisionMallocBDevReply struct {
 func (m *ProvisionMallocBDevReply) Reset()                    { *m = ProvisionMallocBDevReply{} }
 func (m *ProvisionMallocBDevReply) String() string            { return proto.CompactTextString(m) }
 func (*ProvisionMallocBDevReply) ProtoMessage()               {}
-func (*ProvisionMallocBDevReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{14} }
+func (*ProvisionMallocBDevReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{27} }
 
 type CheckMallocBDevRequest struct {
 	// The name of an existing BDev.
@@ -511,7 +1037,7 @@ type CheckMallocBDevRequest struct {
 func (m *CheckMallocBDevRequest) Reset()                    { *m = CheckMallocBDevRequest{} }
 func (m *CheckMallocBDevRequest) String() string            { return proto.CompactTextString(m) }
 func (*CheckMallocBDevRequest) ProtoMessage()               {}
-func (*CheckMallocBDevRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{15} }
+func (*CheckMallocBDevRequest) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{28} }
 
 func (m *CheckMallocBDevRequest) GetBdevName() string {
 	if m != nil {
@@ -526,26 +1052,40 @@ type CheckMallocBDevReply struct {
 func (m *CheckMallocBDevReply) Reset()                    { *m = CheckMallocBDevReply{} }
 func (m *CheckMallocBDevReply) String() string            { return proto.CompactTextString(m) }
 func (*CheckMallocBDevReply) ProtoMessage()               {}
-func (*CheckMallocBDevReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{16} }
+func (*CheckMallocBDevReply) Descriptor() ([]byte, []int) { return fileDescriptorOim, []int{29} }
 
 func init() {
 	proto.RegisterType((*SetValueRequest)(nil), "oim.v0.SetValueRequest")
 	proto.RegisterType((*Value)(nil), "oim.v0.Value")
 	proto.RegisterType((*SetValueReply)(nil), "oim.v0.SetValueReply")
+	proto.RegisterType((*SetValuesRequest)(nil), "oim.v0.SetValuesRequest")
+	proto.RegisterType((*RevisionGuard)(nil), "oim.v0.RevisionGuard")
+	proto.RegisterType((*SetValuesReply)(nil), "oim.v0.SetValuesReply")
 	proto.RegisterType((*GetValuesRequest)(nil), "oim.v0.GetValuesRequest")
 	proto.RegisterType((*GetValuesReply)(nil), "oim.v0.GetValuesReply")
+	proto.RegisterType((*WatchValuesRequest)(nil), "oim.v0.WatchValuesRequest")
+	proto.RegisterType((*WatchValuesReply)(nil), "oim.v0.WatchValuesReply")
+	proto.RegisterType((*ExportRequest)(nil), "oim.v0.ExportRequest")
+	proto.RegisterType((*ExportReply)(nil), "oim.v0.ExportReply")
+	proto.RegisterType((*ImportRequest)(nil), "oim.v0.ImportRequest")
+	proto.RegisterType((*ImportReply)(nil), "oim.v0.ImportReply")
 	proto.RegisterType((*MapVolumeRequest)(nil), "oim.v0.MapVolumeRequest")
 	proto.RegisterType((*MallocParams)(nil), "oim.v0.MallocParams")
 	proto.RegisterType((*CephParams)(nil), "oim.v0.CephParams")
+	proto.RegisterType((*ISCSICHAP)(nil), "oim.v0.ISCSICHAP")
 	proto.RegisterType((*MapVolumeReply)(nil), "oim.v0.MapVolumeReply")
 	proto.RegisterType((*PCIAddress)(nil), "oim.v0.PCIAddress")
 	proto.RegisterType((*SCSIDisk)(nil), "oim.v0.SCSIDisk")
+	proto.RegisterType((*BlkDisk)(nil), "oim.v0.BlkDisk")
+	proto.RegisterType((*NVMeoFDisk)(nil), "oim.v0.NVMeoFDisk")
+	proto.RegisterType((*ISCSIDisk)(nil), "oim.v0.ISCSIDisk")
 	proto.RegisterType((*UnmapVolumeRequest)(nil), "oim.v0.UnmapVolumeRequest")
 	proto.RegisterType((*UnmapVolumeReply)(nil), "oim.v0.UnmapVolumeReply")
 	proto.RegisterType((*ProvisionMallocBDevRequest)(nil), "oim.v0.ProvisionMallocBDevRequest")
 	proto.RegisterType((*ProvisionMallocBDevReply)(nil), "oim.v0.ProvisionMallocBDevReply")
 	proto.RegisterType((*CheckMallocBDevRequest)(nil), "oim.v0.CheckMallocBDevRequest")
 	proto.RegisterType((*CheckMallocBDevReply)(nil), "oim.v0.CheckMallocBDevReply")
+	proto.RegisterEnum("oim.v0.WatchValuesReply_Type", WatchValuesReply_Type_name, WatchValuesReply_Type_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -561,8 +1101,28 @@ const _ = grpc.SupportPackageIsVersion4
 type RegistryClient interface {
 	// Set or overwrite a registry DB entry.
 	SetValue(ctx context.Context, in *SetValueRequest, opts ...grpc.CallOption) (*SetValueReply, error)
+	// Set, overwrite or remove several registry DB entries
+	// atomically: either all changes are applied or, if
+	// some check fails, none of them.
+	SetValues(ctx context.Context, in *SetValuesRequest, opts ...grpc.CallOption) (*SetValuesReply, error)
 	// Retrieves registry DB entries.
 	GetValues(ctx context.Context, in *GetValuesRequest, opts ...grpc.CallOption) (*GetValuesReply, error)
+	// Streams all registry DB entries and then all changes
+	// of those entries. The first reply is a SNAPSHOT with
+	// the current entries, followed by one PUT or DELETE
+	// reply per change. The stream continues until the
+	// client cancels it.
+	WatchValues(ctx context.Context, in *WatchValuesRequest, opts ...grpc.CallOption) (Registry_WatchValuesClient, error)
+	// Streams a consistent snapshot of all registry DB
+	// entries, in one or more replies. Only allowed for
+	// clients which were granted the "admin" verb.
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (Registry_ExportClient, error)
+	// Stores all values sent by the client once the
+	// client closes the stream. The values are stored
+	// in several transactions, so an error may leave
+	// some of them stored. Only allowed for clients
+	// which were granted the "admin" verb.
+	Import(ctx context.Context, opts ...grpc.CallOption) (Registry_ImportClient, error)
 }
 
 type registryClient struct {
@@ -582,6 +1142,15 @@ func (c *registryClient) SetValue(ctx context.Context, in *SetValueRequest, opts
 	return out, nil
 }
 
+func (c *registryClient) SetValues(ctx context.Context, in *SetValuesRequest, opts ...grpc.CallOption) (*SetValuesReply, error) {
+	out := new(SetValuesReply)
+	err := grpc.Invoke(ctx, "/oim.v0.Registry/SetValues", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *registryClient) GetValues(ctx context.Context, in *GetValuesRequest, opts ...grpc.CallOption) (*GetValuesReply, error) {
 	out := new(GetValuesReply)
 	err := grpc.Invoke(ctx, "/oim.v0.Registry/GetValues", in, out, c.cc, opts...)
@@ -591,13 +1160,131 @@ func (c *registryClient) GetValues(ctx context.Context, in *GetValuesRequest, op
 	return out, nil
 }
 
-// Server API for Registry service
+func (c *registryClient) WatchValues(ctx context.Context, in *WatchValuesRequest, opts ...grpc.CallOption) (Registry_WatchValuesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Registry_serviceDesc.Streams[0], c.cc, "/oim.v0.Registry/WatchValues", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registryWatchValuesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
 
-type RegistryServer interface {
-	// Set or overwrite a registry DB entry.
-	SetValue(context.Context, *SetValueRequest) (*SetValueReply, error)
-	// Retrieves registry DB entries.
-	GetValues(context.Context, *GetValuesRequest) (*GetValuesReply, error)
+type Registry_WatchValuesClient interface {
+	Recv() (*WatchValuesReply, error)
+	grpc.ClientStream
+}
+
+type registryWatchValuesClient struct {
+	grpc.ClientStream
+}
+
+func (x *registryWatchValuesClient) Recv() (*WatchValuesReply, error) {
+	m := new(WatchValuesReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *registryClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (Registry_ExportClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Registry_serviceDesc.Streams[1], c.cc, "/oim.v0.Registry/Export", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registryExportClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Registry_ExportClient interface {
+	Recv() (*ExportReply, error)
+	grpc.ClientStream
+}
+
+type registryExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *registryExportClient) Recv() (*ExportReply, error) {
+	m := new(ExportReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *registryClient) Import(ctx context.Context, opts ...grpc.CallOption) (Registry_ImportClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Registry_serviceDesc.Streams[2], c.cc, "/oim.v0.Registry/Import", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &registryImportClient{stream}
+	return x, nil
+}
+
+type Registry_ImportClient interface {
+	Send(*ImportRequest) error
+	CloseAndRecv() (*ImportReply, error)
+	grpc.ClientStream
+}
+
+type registryImportClient struct {
+	grpc.ClientStream
+}
+
+func (x *registryImportClient) Send(m *ImportRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *registryImportClient) CloseAndRecv() (*ImportReply, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for Registry service
+
+type RegistryServer interface {
+	// Set or overwrite a registry DB entry.
+	SetValue(context.Context, *SetValueRequest) (*SetValueReply, error)
+	// Set, overwrite or remove several registry DB entries
+	// atomically: either all changes are applied or, if
+	// some check fails, none of them.
+	SetValues(context.Context, *SetValuesRequest) (*SetValuesReply, error)
+	// Retrieves registry DB entries.
+	GetValues(context.Context, *GetValuesRequest) (*GetValuesReply, error)
+	// Streams all registry DB entries and then all changes
+	// of those entries. The first reply is a SNAPSHOT with
+	// the current entries, followed by one PUT or DELETE
+	// reply per change. The stream continues until the
+	// client cancels it.
+	WatchValues(*WatchValuesRequest, Registry_WatchValuesServer) error
+	// Streams a consistent snapshot of all registry DB
+	// entries, in one or more replies. Only allowed for
+	// clients which were granted the "admin" verb.
+	Export(*ExportRequest, Registry_ExportServer) error
+	// Stores all values sent by the client once the
+	// client closes the stream. The values are stored
+	// in several transactions, so an error may leave
+	// some of them stored. Only allowed for clients
+	// which were granted the "admin" verb.
+	Import(Registry_ImportServer) error
 }
 
 func RegisterRegistryServer(s *grpc.Server, srv RegistryServer) {
@@ -622,6 +1309,24 @@ func _Registry_SetValue_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Registry_SetValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetValuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServer).SetValues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/oim.v0.Registry/SetValues",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServer).SetValues(ctx, req.(*SetValuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Registry_GetValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetValuesRequest)
 	if err := dec(in); err != nil {
@@ -640,6 +1345,74 @@ func _Registry_GetValues_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Registry_WatchValues_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchValuesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RegistryServer).WatchValues(m, &registryWatchValuesServer{stream})
+}
+
+type Registry_WatchValuesServer interface {
+	Send(*WatchValuesReply) error
+	grpc.ServerStream
+}
+
+type registryWatchValuesServer struct {
+	grpc.ServerStream
+}
+
+func (x *registryWatchValuesServer) Send(m *WatchValuesReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Registry_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RegistryServer).Export(m, &registryExportServer{stream})
+}
+
+type Registry_ExportServer interface {
+	Send(*ExportReply) error
+	grpc.ServerStream
+}
+
+type registryExportServer struct {
+	grpc.ServerStream
+}
+
+func (x *registryExportServer) Send(m *ExportReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Registry_Import_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RegistryServer).Import(&registryImportServer{stream})
+}
+
+type Registry_ImportServer interface {
+	SendAndClose(*ImportReply) error
+	Recv() (*ImportRequest, error)
+	grpc.ServerStream
+}
+
+type registryImportServer struct {
+	grpc.ServerStream
+}
+
+func (x *registryImportServer) SendAndClose(m *ImportReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *registryImportServer) Recv() (*ImportRequest, error) {
+	m := new(ImportRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _Registry_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "oim.v0.Registry",
 	HandlerType: (*RegistryServer)(nil),
@@ -648,12 +1421,32 @@ var _Registry_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SetValue",
 			Handler:    _Registry_SetValue_Handler,
 		},
+		{
+			MethodName: "SetValues",
+			Handler:    _Registry_SetValues_Handler,
+		},
 		{
 			MethodName: "GetValues",
 			Handler:    _Registry_GetValues_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchValues",
+			Handler:       _Registry_WatchValues_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Export",
+			Handler:       _Registry_Export_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Import",
+			Handler:       _Registry_Import_Handler,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "oim.proto",
 }
 
@@ -867,6 +1660,21 @@ func (m *SetValueRequest) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i += n1
 	}
+	if m.TtlSeconds != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.TtlSeconds))
+	}
+	if m.ExpectedRevision != nil {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.ExpectedRevision.Size()))
+		n2, err := m.ExpectedRevision.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n2
+	}
 	return i, nil
 }
 
@@ -897,6 +1705,21 @@ func (m *Value) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintOim(dAtA, i, uint64(len(m.Value)))
 		i += copy(dAtA[i:], m.Value)
 	}
+	if m.Revision != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Revision))
+	}
+	if m.Temporary {
+		dAtA[i] = 0x20
+		i++
+		if m.Temporary {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	return i, nil
 }
 
@@ -915,34 +1738,15 @@ func (m *SetValueReply) MarshalTo(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
-	return i, nil
-}
-
-func (m *GetValuesRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
-}
-
-func (m *GetValuesRequest) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if len(m.Path) > 0 {
-		dAtA[i] = 0xa
+	if m.Revision != 0 {
+		dAtA[i] = 0x8
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(len(m.Path)))
-		i += copy(dAtA[i:], m.Path)
+		i = encodeVarintOim(dAtA, i, uint64(m.Revision))
 	}
 	return i, nil
 }
 
-func (m *GetValuesReply) Marshal() (dAtA []byte, err error) {
+func (m *SetValuesRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -952,13 +1756,13 @@ func (m *GetValuesReply) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *GetValuesReply) MarshalTo(dAtA []byte) (int, error) {
+func (m *SetValuesRequest) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.Values) > 0 {
-		for _, msg := range m.Values {
+	if len(m.Changes) > 0 {
+		for _, msg := range m.Changes {
 			dAtA[i] = 0xa
 			i++
 			i = encodeVarintOim(dAtA, i, uint64(msg.Size()))
@@ -969,10 +1773,22 @@ func (m *GetValuesReply) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
+	if len(m.Guards) > 0 {
+		for _, msg := range m.Guards {
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintOim(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
 	return i, nil
 }
 
-func (m *MapVolumeRequest) Marshal() (dAtA []byte, err error) {
+func (m *RevisionGuard) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -982,56 +1798,26 @@ func (m *MapVolumeRequest) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *MapVolumeRequest) MarshalTo(dAtA []byte) (int, error) {
+func (m *RevisionGuard) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.VolumeId) > 0 {
+	if len(m.Path) > 0 {
 		dAtA[i] = 0xa
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(len(m.VolumeId)))
-		i += copy(dAtA[i:], m.VolumeId)
-	}
-	if m.Params != nil {
-		nn2, err := m.Params.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
-		}
-		i += nn2
-	}
-	return i, nil
-}
-
-func (m *MapVolumeRequest_Malloc) MarshalTo(dAtA []byte) (int, error) {
-	i := 0
-	if m.Malloc != nil {
-		dAtA[i] = 0x12
-		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.Malloc.Size()))
-		n3, err := m.Malloc.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
-		}
-		i += n3
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Path)))
+		i += copy(dAtA[i:], m.Path)
 	}
-	return i, nil
-}
-func (m *MapVolumeRequest_Ceph) MarshalTo(dAtA []byte) (int, error) {
-	i := 0
-	if m.Ceph != nil {
-		dAtA[i] = 0x1a
+	if m.Revision != 0 {
+		dAtA[i] = 0x10
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.Ceph.Size()))
-		n4, err := m.Ceph.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
-		}
-		i += n4
+		i = encodeVarintOim(dAtA, i, uint64(m.Revision))
 	}
 	return i, nil
 }
-func (m *MallocParams) Marshal() (dAtA []byte, err error) {
+
+func (m *SetValuesReply) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1041,15 +1827,20 @@ func (m *MallocParams) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *MallocParams) MarshalTo(dAtA []byte) (int, error) {
+func (m *SetValuesReply) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
+	if m.Revision != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Revision))
+	}
 	return i, nil
 }
 
-func (m *CephParams) Marshal() (dAtA []byte, err error) {
+func (m *GetValuesRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1059,45 +1850,21 @@ func (m *CephParams) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *CephParams) MarshalTo(dAtA []byte) (int, error) {
+func (m *GetValuesRequest) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.UserId) > 0 {
+	if len(m.Path) > 0 {
 		dAtA[i] = 0xa
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(len(m.UserId)))
-		i += copy(dAtA[i:], m.UserId)
-	}
-	if len(m.Secret) > 0 {
-		dAtA[i] = 0x12
-		i++
-		i = encodeVarintOim(dAtA, i, uint64(len(m.Secret)))
-		i += copy(dAtA[i:], m.Secret)
-	}
-	if len(m.Monitors) > 0 {
-		dAtA[i] = 0x1a
-		i++
-		i = encodeVarintOim(dAtA, i, uint64(len(m.Monitors)))
-		i += copy(dAtA[i:], m.Monitors)
-	}
-	if len(m.Pool) > 0 {
-		dAtA[i] = 0x22
-		i++
-		i = encodeVarintOim(dAtA, i, uint64(len(m.Pool)))
-		i += copy(dAtA[i:], m.Pool)
-	}
-	if len(m.Image) > 0 {
-		dAtA[i] = 0x2a
-		i++
-		i = encodeVarintOim(dAtA, i, uint64(len(m.Image)))
-		i += copy(dAtA[i:], m.Image)
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Path)))
+		i += copy(dAtA[i:], m.Path)
 	}
 	return i, nil
 }
 
-func (m *MapVolumeReply) Marshal() (dAtA []byte, err error) {
+func (m *GetValuesReply) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1107,35 +1874,51 @@ func (m *MapVolumeReply) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *MapVolumeReply) MarshalTo(dAtA []byte) (int, error) {
+func (m *GetValuesReply) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.PciAddress != nil {
-		dAtA[i] = 0xa
-		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.PciAddress.Size()))
-		n5, err := m.PciAddress.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
+	if len(m.Values) > 0 {
+		for _, msg := range m.Values {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintOim(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
 		}
-		i += n5
 	}
-	if m.ScsiDisk != nil {
-		dAtA[i] = 0x12
+	return i, nil
+}
+
+func (m *WatchValuesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *WatchValuesRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Path) > 0 {
+		dAtA[i] = 0xa
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.ScsiDisk.Size()))
-		n6, err := m.ScsiDisk.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
-		}
-		i += n6
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Path)))
+		i += copy(dAtA[i:], m.Path)
 	}
 	return i, nil
 }
 
-func (m *PCIAddress) Marshal() (dAtA []byte, err error) {
+func (m *WatchValuesReply) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1145,35 +1928,55 @@ func (m *PCIAddress) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *PCIAddress) MarshalTo(dAtA []byte) (int, error) {
+func (m *WatchValuesReply) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.Domain != 0 {
+	if m.Type != 0 {
 		dAtA[i] = 0x8
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.Domain))
+		i = encodeVarintOim(dAtA, i, uint64(m.Type))
 	}
-	if m.Bus != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.Bus))
+	if len(m.Values) > 0 {
+		for _, msg := range m.Values {
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintOim(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
-	if m.Device != 0 {
+	if m.Revision != 0 {
 		dAtA[i] = 0x18
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.Device))
+		i = encodeVarintOim(dAtA, i, uint64(m.Revision))
 	}
-	if m.Function != 0 {
-		dAtA[i] = 0x20
-		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.Function))
+	return i, nil
+}
+
+func (m *ExportRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ExportRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
 	return i, nil
 }
 
-func (m *SCSIDisk) Marshal() (dAtA []byte, err error) {
+func (m *ExportReply) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1183,25 +1986,32 @@ func (m *SCSIDisk) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *SCSIDisk) MarshalTo(dAtA []byte) (int, error) {
+func (m *ExportReply) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if m.Target != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.Target))
+	if len(m.Values) > 0 {
+		for _, msg := range m.Values {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintOim(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
-	if m.Lun != 0 {
+	if m.Revision != 0 {
 		dAtA[i] = 0x10
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.Lun))
+		i = encodeVarintOim(dAtA, i, uint64(m.Revision))
 	}
 	return i, nil
 }
 
-func (m *UnmapVolumeRequest) Marshal() (dAtA []byte, err error) {
+func (m *ImportRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1211,21 +2021,37 @@ func (m *UnmapVolumeRequest) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *UnmapVolumeRequest) MarshalTo(dAtA []byte) (int, error) {
+func (m *ImportRequest) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.VolumeId) > 0 {
-		dAtA[i] = 0xa
+	if m.Replace {
+		dAtA[i] = 0x8
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(len(m.VolumeId)))
-		i += copy(dAtA[i:], m.VolumeId)
+		if m.Replace {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.Values) > 0 {
+		for _, msg := range m.Values {
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintOim(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
 	return i, nil
 }
 
-func (m *UnmapVolumeReply) Marshal() (dAtA []byte, err error) {
+func (m *ImportReply) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1235,15 +2061,35 @@ func (m *UnmapVolumeReply) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *UnmapVolumeReply) MarshalTo(dAtA []byte) (int, error) {
+func (m *ImportReply) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
+	if m.Revision != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Revision))
+	}
+	if len(m.Skipped) > 0 {
+		for _, s := range m.Skipped {
+			dAtA[i] = 0x12
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
 	return i, nil
 }
 
-func (m *ProvisionMallocBDevRequest) Marshal() (dAtA []byte, err error) {
+func (m *MapVolumeRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1253,26 +2099,78 @@ func (m *ProvisionMallocBDevRequest) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *ProvisionMallocBDevRequest) MarshalTo(dAtA []byte) (int, error) {
+func (m *MapVolumeRequest) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.BdevName) > 0 {
+	if len(m.VolumeId) > 0 {
 		dAtA[i] = 0xa
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(len(m.BdevName)))
-		i += copy(dAtA[i:], m.BdevName)
+		i = encodeVarintOim(dAtA, i, uint64(len(m.VolumeId)))
+		i += copy(dAtA[i:], m.VolumeId)
 	}
-	if m.Size_ != 0 {
-		dAtA[i] = 0x10
+	if m.Params != nil {
+		nn3, err := m.Params.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += nn3
+	}
+	if m.IscsiChap != nil {
+		dAtA[i] = 0x22
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(m.Size_))
+		i = encodeVarintOim(dAtA, i, uint64(m.IscsiChap.Size()))
+		n4, err := m.IscsiChap.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n4
+	}
+	if len(m.HostNqn) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.HostNqn)))
+		i += copy(dAtA[i:], m.HostNqn)
+	}
+	if len(m.IscsiInitiator) > 0 {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.IscsiInitiator)))
+		i += copy(dAtA[i:], m.IscsiInitiator)
 	}
 	return i, nil
 }
 
-func (m *ProvisionMallocBDevReply) Marshal() (dAtA []byte, err error) {
+func (m *MapVolumeRequest_Malloc) MarshalTo(dAtA []byte) (int, error) {
+	i := 0
+	if m.Malloc != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Malloc.Size()))
+		n5, err := m.Malloc.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n5
+	}
+	return i, nil
+}
+func (m *MapVolumeRequest_Ceph) MarshalTo(dAtA []byte) (int, error) {
+	i := 0
+	if m.Ceph != nil {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Ceph.Size()))
+		n6, err := m.Ceph.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n6
+	}
+	return i, nil
+}
+func (m *MallocParams) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1282,7 +2180,7 @@ func (m *ProvisionMallocBDevReply) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *ProvisionMallocBDevReply) MarshalTo(dAtA []byte) (int, error) {
+func (m *MallocParams) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
@@ -1290,7 +2188,7 @@ func (m *ProvisionMallocBDevReply) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func (m *CheckMallocBDevRequest) Marshal() (dAtA []byte, err error) {
+func (m *CephParams) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1300,21 +2198,45 @@ func (m *CheckMallocBDevRequest) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *CheckMallocBDevRequest) MarshalTo(dAtA []byte) (int, error) {
+func (m *CephParams) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	if len(m.BdevName) > 0 {
+	if len(m.UserId) > 0 {
 		dAtA[i] = 0xa
 		i++
-		i = encodeVarintOim(dAtA, i, uint64(len(m.BdevName)))
-		i += copy(dAtA[i:], m.BdevName)
+		i = encodeVarintOim(dAtA, i, uint64(len(m.UserId)))
+		i += copy(dAtA[i:], m.UserId)
+	}
+	if len(m.Secret) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Secret)))
+		i += copy(dAtA[i:], m.Secret)
+	}
+	if len(m.Monitors) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Monitors)))
+		i += copy(dAtA[i:], m.Monitors)
+	}
+	if len(m.Pool) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Pool)))
+		i += copy(dAtA[i:], m.Pool)
+	}
+	if len(m.Image) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Image)))
+		i += copy(dAtA[i:], m.Image)
 	}
 	return i, nil
 }
 
-func (m *CheckMallocBDevReply) Marshal() (dAtA []byte, err error) {
+func (m *ISCSICHAP) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalTo(dAtA)
@@ -1324,247 +2246,1983 @@ func (m *CheckMallocBDevReply) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *CheckMallocBDevReply) MarshalTo(dAtA []byte) (int, error) {
+func (m *ISCSICHAP) MarshalTo(dAtA []byte) (int, error) {
 	var i int
 	_ = i
 	var l int
 	_ = l
-	return i, nil
-}
-
-func encodeVarintOim(dAtA []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+	if len(m.User) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.User)))
+		i += copy(dAtA[i:], m.User)
 	}
-	dAtA[offset] = uint8(v)
-	return offset + 1
-}
-func (m *SetValueRequest) Size() (n int) {
-	var l int
-	_ = l
-	if m.Value != nil {
-		l = m.Value.Size()
-		n += 1 + l + sovOim(uint64(l))
+	if len(m.Secret) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Secret)))
+		i += copy(dAtA[i:], m.Secret)
 	}
-	return n
+	return i, nil
 }
 
-func (m *Value) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Path)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
-	}
-	l = len(m.Value)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
+func (m *MapVolumeReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *SetValueReply) Size() (n int) {
+func (m *MapVolumeReply) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	return n
+	if m.PciAddress != nil {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.PciAddress.Size()))
+		n7, err := m.PciAddress.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n7
+	}
+	if m.ScsiDisk != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.ScsiDisk.Size()))
+		n8, err := m.ScsiDisk.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n8
+	}
+	if m.BlkDisk != nil {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.BlkDisk.Size()))
+		n9, err := m.BlkDisk.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n9
+	}
+	if m.NvmeofDisk != nil {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.NvmeofDisk.Size()))
+		n10, err := m.NvmeofDisk.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n10
+	}
+	if m.IscsiDisk != nil {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.IscsiDisk.Size()))
+		n11, err := m.IscsiDisk.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n11
+	}
+	return i, nil
 }
 
-func (m *GetValuesRequest) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Path)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
+func (m *PCIAddress) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *GetValuesReply) Size() (n int) {
+func (m *PCIAddress) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if len(m.Values) > 0 {
-		for _, e := range m.Values {
-			l = e.Size()
-			n += 1 + l + sovOim(uint64(l))
-		}
+	if m.Domain != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Domain))
 	}
-	return n
+	if m.Bus != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Bus))
+	}
+	if m.Device != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Device))
+	}
+	if m.Function != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Function))
+	}
+	return i, nil
 }
 
-func (m *MapVolumeRequest) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.VolumeId)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
-	}
-	if m.Params != nil {
-		n += m.Params.Size()
+func (m *SCSIDisk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *MapVolumeRequest_Malloc) Size() (n int) {
+func (m *SCSIDisk) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Malloc != nil {
-		l = m.Malloc.Size()
-		n += 1 + l + sovOim(uint64(l))
+	if m.Target != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Target))
 	}
-	return n
+	if m.Lun != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Lun))
+	}
+	return i, nil
 }
-func (m *MapVolumeRequest_Ceph) Size() (n int) {
-	var l int
-	_ = l
-	if m.Ceph != nil {
-		l = m.Ceph.Size()
-		n += 1 + l + sovOim(uint64(l))
+
+func (m *BlkDisk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
-func (m *MallocParams) Size() (n int) {
+
+func (m *BlkDisk) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	return n
+	return i, nil
 }
 
-func (m *CephParams) Size() (n int) {
+func (m *NVMeoFDisk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NVMeoFDisk) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.UserId)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
+	if len(m.Nqn) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Nqn)))
+		i += copy(dAtA[i:], m.Nqn)
 	}
-	l = len(m.Secret)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
+	if len(m.Transport) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Transport)))
+		i += copy(dAtA[i:], m.Transport)
 	}
-	l = len(m.Monitors)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
+	if len(m.Address) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Address)))
+		i += copy(dAtA[i:], m.Address)
 	}
-	l = len(m.Pool)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
+	if len(m.Port) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Port)))
+		i += copy(dAtA[i:], m.Port)
 	}
-	l = len(m.Image)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
+	if m.NamespaceId != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.NamespaceId))
 	}
-	return n
+	return i, nil
 }
 
-func (m *MapVolumeReply) Size() (n int) {
-	var l int
-	_ = l
-	if m.PciAddress != nil {
-		l = m.PciAddress.Size()
-		n += 1 + l + sovOim(uint64(l))
-	}
-	if m.ScsiDisk != nil {
-		l = m.ScsiDisk.Size()
-		n += 1 + l + sovOim(uint64(l))
+func (m *ISCSIDisk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *PCIAddress) Size() (n int) {
+func (m *ISCSIDisk) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Domain != 0 {
-		n += 1 + sovOim(uint64(m.Domain))
+	if len(m.Iqn) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Iqn)))
+		i += copy(dAtA[i:], m.Iqn)
 	}
-	if m.Bus != 0 {
-		n += 1 + sovOim(uint64(m.Bus))
+	if len(m.Portal) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.Portal)))
+		i += copy(dAtA[i:], m.Portal)
 	}
-	if m.Device != 0 {
-		n += 1 + sovOim(uint64(m.Device))
+	if m.Lun != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Lun))
 	}
-	if m.Function != 0 {
-		n += 1 + sovOim(uint64(m.Function))
+	return i, nil
+}
+
+func (m *UnmapVolumeRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *SCSIDisk) Size() (n int) {
+func (m *UnmapVolumeRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Target != 0 {
-		n += 1 + sovOim(uint64(m.Target))
-	}
-	if m.Lun != 0 {
-		n += 1 + sovOim(uint64(m.Lun))
+	if len(m.VolumeId) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.VolumeId)))
+		i += copy(dAtA[i:], m.VolumeId)
 	}
-	return n
+	return i, nil
 }
 
-func (m *UnmapVolumeRequest) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.VolumeId)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
+func (m *UnmapVolumeReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *UnmapVolumeReply) Size() (n int) {
+func (m *UnmapVolumeReply) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	return n
+	return i, nil
 }
 
-func (m *ProvisionMallocBDevRequest) Size() (n int) {
+func (m *ProvisionMallocBDevRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ProvisionMallocBDevRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.BdevName)
+	if len(m.BdevName) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.BdevName)))
+		i += copy(dAtA[i:], m.BdevName)
+	}
+	if m.Size_ != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(m.Size_))
+	}
+	return i, nil
+}
+
+func (m *ProvisionMallocBDevReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ProvisionMallocBDevReply) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	return i, nil
+}
+
+func (m *CheckMallocBDevRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CheckMallocBDevRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.BdevName) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintOim(dAtA, i, uint64(len(m.BdevName)))
+		i += copy(dAtA[i:], m.BdevName)
+	}
+	return i, nil
+}
+
+func (m *CheckMallocBDevReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CheckMallocBDevReply) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	return i, nil
+}
+
+func encodeVarintOim(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *SetValueRequest) Size() (n int) {
+	var l int
+	_ = l
+	if m.Value != nil {
+		l = m.Value.Size()
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.TtlSeconds != 0 {
+		n += 1 + sovOim(uint64(m.TtlSeconds))
+	}
+	if m.ExpectedRevision != nil {
+		l = m.ExpectedRevision.Size()
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+
+func (m *Value) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Path)
 	if l > 0 {
 		n += 1 + l + sovOim(uint64(l))
 	}
-	if m.Size_ != 0 {
-		n += 1 + sovOim(uint64(m.Size_))
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.Revision != 0 {
+		n += 1 + sovOim(uint64(m.Revision))
+	}
+	if m.Temporary {
+		n += 2
+	}
+	return n
+}
+
+func (m *SetValueReply) Size() (n int) {
+	var l int
+	_ = l
+	if m.Revision != 0 {
+		n += 1 + sovOim(uint64(m.Revision))
+	}
+	return n
+}
+
+func (m *SetValuesRequest) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Changes) > 0 {
+		for _, e := range m.Changes {
+			l = e.Size()
+			n += 1 + l + sovOim(uint64(l))
+		}
+	}
+	if len(m.Guards) > 0 {
+		for _, e := range m.Guards {
+			l = e.Size()
+			n += 1 + l + sovOim(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *RevisionGuard) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Path)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.Revision != 0 {
+		n += 1 + sovOim(uint64(m.Revision))
+	}
+	return n
+}
+
+func (m *SetValuesReply) Size() (n int) {
+	var l int
+	_ = l
+	if m.Revision != 0 {
+		n += 1 + sovOim(uint64(m.Revision))
+	}
+	return n
+}
+
+func (m *GetValuesRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Path)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+
+func (m *GetValuesReply) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for _, e := range m.Values {
+			l = e.Size()
+			n += 1 + l + sovOim(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *WatchValuesRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Path)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+
+func (m *WatchValuesReply) Size() (n int) {
+	var l int
+	_ = l
+	if m.Type != 0 {
+		n += 1 + sovOim(uint64(m.Type))
+	}
+	if len(m.Values) > 0 {
+		for _, e := range m.Values {
+			l = e.Size()
+			n += 1 + l + sovOim(uint64(l))
+		}
+	}
+	if m.Revision != 0 {
+		n += 1 + sovOim(uint64(m.Revision))
+	}
+	return n
+}
+
+func (m *ExportRequest) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *ExportReply) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for _, e := range m.Values {
+			l = e.Size()
+			n += 1 + l + sovOim(uint64(l))
+		}
+	}
+	if m.Revision != 0 {
+		n += 1 + sovOim(uint64(m.Revision))
+	}
+	return n
+}
+
+func (m *ImportRequest) Size() (n int) {
+	var l int
+	_ = l
+	if m.Replace {
+		n += 2
+	}
+	if len(m.Values) > 0 {
+		for _, e := range m.Values {
+			l = e.Size()
+			n += 1 + l + sovOim(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ImportReply) Size() (n int) {
+	var l int
+	_ = l
+	if m.Revision != 0 {
+		n += 1 + sovOim(uint64(m.Revision))
+	}
+	if len(m.Skipped) > 0 {
+		for _, s := range m.Skipped {
+			l = len(s)
+			n += 1 + l + sovOim(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *MapVolumeRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.VolumeId)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.Params != nil {
+		n += m.Params.Size()
+	}
+	if m.IscsiChap != nil {
+		l = m.IscsiChap.Size()
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.HostNqn)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.IscsiInitiator)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+
+func (m *MapVolumeRequest_Malloc) Size() (n int) {
+	var l int
+	_ = l
+	if m.Malloc != nil {
+		l = m.Malloc.Size()
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+func (m *MapVolumeRequest_Ceph) Size() (n int) {
+	var l int
+	_ = l
+	if m.Ceph != nil {
+		l = m.Ceph.Size()
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+func (m *MallocParams) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *CephParams) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.UserId)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.Secret)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.Monitors)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.Pool)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.Image)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+
+func (m *ISCSICHAP) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.User)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.Secret)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+
+func (m *MapVolumeReply) Size() (n int) {
+	var l int
+	_ = l
+	if m.PciAddress != nil {
+		l = m.PciAddress.Size()
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.ScsiDisk != nil {
+		l = m.ScsiDisk.Size()
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.BlkDisk != nil {
+		l = m.BlkDisk.Size()
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.NvmeofDisk != nil {
+		l = m.NvmeofDisk.Size()
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.IscsiDisk != nil {
+		l = m.IscsiDisk.Size()
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+
+func (m *PCIAddress) Size() (n int) {
+	var l int
+	_ = l
+	if m.Domain != 0 {
+		n += 1 + sovOim(uint64(m.Domain))
+	}
+	if m.Bus != 0 {
+		n += 1 + sovOim(uint64(m.Bus))
+	}
+	if m.Device != 0 {
+		n += 1 + sovOim(uint64(m.Device))
+	}
+	if m.Function != 0 {
+		n += 1 + sovOim(uint64(m.Function))
+	}
+	return n
+}
+
+func (m *SCSIDisk) Size() (n int) {
+	var l int
+	_ = l
+	if m.Target != 0 {
+		n += 1 + sovOim(uint64(m.Target))
+	}
+	if m.Lun != 0 {
+		n += 1 + sovOim(uint64(m.Lun))
+	}
+	return n
+}
+
+func (m *BlkDisk) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *NVMeoFDisk) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Nqn)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.Transport)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.Address)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.Port)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.NamespaceId != 0 {
+		n += 1 + sovOim(uint64(m.NamespaceId))
+	}
+	return n
+}
+
+func (m *ISCSIDisk) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Iqn)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	l = len(m.Portal)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.Lun != 0 {
+		n += 1 + sovOim(uint64(m.Lun))
+	}
+	return n
+}
+
+func (m *UnmapVolumeRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.VolumeId)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+
+func (m *UnmapVolumeReply) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *ProvisionMallocBDevRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.BdevName)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	if m.Size_ != 0 {
+		n += 1 + sovOim(uint64(m.Size_))
+	}
+	return n
+}
+
+func (m *ProvisionMallocBDevReply) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *CheckMallocBDevRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.BdevName)
+	if l > 0 {
+		n += 1 + l + sovOim(uint64(l))
+	}
+	return n
+}
+
+func (m *CheckMallocBDevReply) Size() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func sovOim(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozOim(x uint64) (n int) {
+	return sovOim(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *SetValueRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SetValueRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SetValueRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Value == nil {
+				m.Value = &Value{}
+			}
+			if err := m.Value.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TtlSeconds", wireType)
+			}
+			m.TtlSeconds = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TtlSeconds |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExpectedRevision", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ExpectedRevision == nil {
+				m.ExpectedRevision = &google_protobuf.Int64Value{}
+			}
+			if err := m.ExpectedRevision.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Value) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Value: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Value: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Path = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Revision", wireType)
+			}
+			m.Revision = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Revision |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Temporary", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Temporary = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SetValueReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SetValueReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SetValueReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Revision", wireType)
+			}
+			m.Revision = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Revision |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SetValuesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SetValuesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SetValuesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Changes", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Changes = append(m.Changes, &SetValueRequest{})
+			if err := m.Changes[len(m.Changes)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Guards", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Guards = append(m.Guards, &RevisionGuard{})
+			if err := m.Guards[len(m.Guards)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RevisionGuard) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RevisionGuard: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RevisionGuard: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Path = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Revision", wireType)
+			}
+			m.Revision = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Revision |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SetValuesReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SetValuesReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SetValuesReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Revision", wireType)
+			}
+			m.Revision = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Revision |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetValuesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetValuesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetValuesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Path = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetValuesReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetValuesReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetValuesReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Values = append(m.Values, &Value{})
+			if err := m.Values[len(m.Values)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *WatchValuesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WatchValuesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WatchValuesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Path = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *WatchValuesReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WatchValuesReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WatchValuesReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= (WatchValuesReply_Type(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Values = append(m.Values, &Value{})
+			if err := m.Values[len(m.Values)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Revision", wireType)
+			}
+			m.Revision = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Revision |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExportRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExportRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExportRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	return n
-}
-
-func (m *ProvisionMallocBDevReply) Size() (n int) {
-	var l int
-	_ = l
-	return n
-}
 
-func (m *CheckMallocBDevRequest) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.BdevName)
-	if l > 0 {
-		n += 1 + l + sovOim(uint64(l))
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-
-func (m *CheckMallocBDevReply) Size() (n int) {
-	var l int
-	_ = l
-	return n
+	return nil
 }
-
-func sovOim(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
+func (m *ExportReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExportReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExportReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Values = append(m.Values, &Value{})
+			if err := m.Values[len(m.Values)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Revision", wireType)
+			}
+			m.Revision = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Revision |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
 		}
 	}
-	return n
-}
-func sozOim(x uint64) (n int) {
-	return sovOim(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
-func (m *SetValueRequest) Unmarshal(dAtA []byte) error {
+func (m *ImportRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1587,15 +4245,35 @@ func (m *SetValueRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SetValueRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ImportRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SetValueRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ImportRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replace", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Replace = bool(v != 0)
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -1619,12 +4297,108 @@ func (m *SetValueRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Value == nil {
-				m.Value = &Value{}
-			}
-			if err := m.Value.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Values = append(m.Values, &Value{})
+			if err := m.Values[len(m.Values)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthOim
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ImportReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ImportReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ImportReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Revision", wireType)
+			}
+			m.Revision = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Revision |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Skipped", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Skipped = append(m.Skipped, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1647,7 +4421,7 @@ func (m *SetValueRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Value) Unmarshal(dAtA []byte) error {
+func (m *MapVolumeRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1670,15 +4444,15 @@ func (m *Value) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Value: wiretype end group for non-group")
+			return fmt.Errorf("proto: MapVolumeRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Value: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MapVolumeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field VolumeId", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1703,11 +4477,108 @@ func (m *Value) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Path = string(dAtA[iNdEx:postIndex])
+			m.VolumeId = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Malloc", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &MallocParams{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Params = &MapVolumeRequest_Malloc{v}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ceph", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &CephParams{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Params = &MapVolumeRequest_Ceph{v}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IscsiChap", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.IscsiChap == nil {
+				m.IscsiChap = &ISCSICHAP{}
+			}
+			if err := m.IscsiChap.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HostNqn", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1732,7 +4603,36 @@ func (m *Value) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Value = string(dAtA[iNdEx:postIndex])
+			m.HostNqn = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IscsiInitiator", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.IscsiInitiator = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1755,7 +4655,7 @@ func (m *Value) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SetValueReply) Unmarshal(dAtA []byte) error {
+func (m *MallocParams) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1778,10 +4678,10 @@ func (m *SetValueReply) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SetValueReply: wiretype end group for non-group")
+			return fmt.Errorf("proto: MallocParams: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SetValueReply: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MallocParams: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		default:
@@ -1805,7 +4705,7 @@ func (m *SetValueReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetValuesRequest) Unmarshal(dAtA []byte) error {
+func (m *CephParams) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1828,15 +4728,131 @@ func (m *GetValuesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetValuesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: CephParams: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetValuesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: CephParams: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Secret", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Secret = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Monitors", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Monitors = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Pool", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Pool = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Image", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1861,7 +4877,7 @@ func (m *GetValuesRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Path = string(dAtA[iNdEx:postIndex])
+			m.Image = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1884,7 +4900,7 @@ func (m *GetValuesRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetValuesReply) Unmarshal(dAtA []byte) error {
+func (m *ISCSICHAP) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1907,17 +4923,17 @@ func (m *GetValuesReply) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetValuesReply: wiretype end group for non-group")
+			return fmt.Errorf("proto: ISCSICHAP: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetValuesReply: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ISCSICHAP: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field User", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -1927,22 +4943,49 @@ func (m *GetValuesReply) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthOim
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Values = append(m.Values, &Value{})
-			if err := m.Values[len(m.Values)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.User = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Secret", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Secret = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1965,7 +5008,7 @@ func (m *GetValuesReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MapVolumeRequest) Unmarshal(dAtA []byte) error {
+func (m *MapVolumeReply) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1988,17 +5031,17 @@ func (m *MapVolumeRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MapVolumeRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: MapVolumeReply: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MapVolumeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MapVolumeReply: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field VolumeId", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field PciAddress", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2008,24 +5051,28 @@ func (m *MapVolumeRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthOim
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.VolumeId = string(dAtA[iNdEx:postIndex])
+			if m.PciAddress == nil {
+				m.PciAddress = &PCIAddress{}
+			}
+			if err := m.PciAddress.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Malloc", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ScsiDisk", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2049,15 +5096,16 @@ func (m *MapVolumeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &MallocParams{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.ScsiDisk == nil {
+				m.ScsiDisk = &SCSIDisk{}
+			}
+			if err := m.ScsiDisk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			m.Params = &MapVolumeRequest_Malloc{v}
 			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Ceph", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field BlkDisk", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2081,62 +5129,79 @@ func (m *MapVolumeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &CephParams{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.BlkDisk == nil {
+				m.BlkDisk = &BlkDisk{}
+			}
+			if err := m.BlkDisk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			m.Params = &MapVolumeRequest_Ceph{v}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipOim(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NvmeofDisk", wireType)
 			}
-			if skippy < 0 {
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
 				return ErrInvalidLengthOim
 			}
-			if (iNdEx + skippy) > l {
+			postIndex := iNdEx + msglen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *MallocParams) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowOim
+			if m.NvmeofDisk == nil {
+				m.NvmeofDisk = &NVMeoFDisk{}
 			}
-			if iNdEx >= l {
+			if err := m.NvmeofDisk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IscsiDisk", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= (uint64(b) & 0x7F) << shift
-			if b < 0x80 {
-				break
+			if m.IscsiDisk == nil {
+				m.IscsiDisk = &ISCSIDisk{}
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: MallocParams: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MallocParams: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
+			if err := m.IscsiDisk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipOim(dAtA[iNdEx:])
@@ -2158,7 +5223,7 @@ func (m *MallocParams) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *CephParams) Unmarshal(dAtA []byte) error {
+func (m *PCIAddress) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2181,17 +5246,17 @@ func (m *CephParams) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: CephParams: wiretype end group for non-group")
+			return fmt.Errorf("proto: PCIAddress: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: CephParams: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PCIAddress: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Domain", wireType)
 			}
-			var stringLen uint64
+			m.Domain = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2201,55 +5266,16 @@ func (m *CephParams) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				m.Domain |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthOim
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.UserId = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Secret", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowOim
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthOim
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Secret = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Monitors", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bus", wireType)
 			}
-			var stringLen uint64
+			m.Bus = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2259,26 +5285,16 @@ func (m *CephParams) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				m.Bus |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthOim
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+					break
+				}
 			}
-			m.Monitors = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Pool", wireType)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Device", wireType)
 			}
-			var stringLen uint64
+			m.Device = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2288,26 +5304,16 @@ func (m *CephParams) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				m.Device |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthOim
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Pool = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 5:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Image", wireType)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Function", wireType)
 			}
-			var stringLen uint64
+			m.Function = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2317,21 +5323,11 @@ func (m *CephParams) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				m.Function |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthOim
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Image = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipOim(dAtA[iNdEx:])
@@ -2353,7 +5349,7 @@ func (m *CephParams) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MapVolumeReply) Unmarshal(dAtA []byte) error {
+func (m *SCSIDisk) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2376,17 +5372,17 @@ func (m *MapVolumeReply) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MapVolumeReply: wiretype end group for non-group")
+			return fmt.Errorf("proto: SCSIDisk: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MapVolumeReply: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SCSIDisk: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PciAddress", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
 			}
-			var msglen int
+			m.Target = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2396,30 +5392,16 @@ func (m *MapVolumeReply) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				m.Target |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthOim
-			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.PciAddress == nil {
-				m.PciAddress = &PCIAddress{}
-			}
-			if err := m.PciAddress.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ScsiDisk", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Lun", wireType)
 			}
-			var msglen int
+			m.Lun = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2429,25 +5411,61 @@ func (m *MapVolumeReply) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				m.Lun |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipOim(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
 				return ErrInvalidLengthOim
 			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.ScsiDisk == nil {
-				m.ScsiDisk = &SCSIDisk{}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BlkDisk) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowOim
 			}
-			if err := m.ScsiDisk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-			iNdEx = postIndex
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BlkDisk: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BlkDisk: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
 		default:
 			iNdEx = preIndex
 			skippy, err := skipOim(dAtA[iNdEx:])
@@ -2469,7 +5487,7 @@ func (m *MapVolumeReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PCIAddress) Unmarshal(dAtA []byte) error {
+func (m *NVMeoFDisk) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2492,17 +5510,17 @@ func (m *PCIAddress) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PCIAddress: wiretype end group for non-group")
+			return fmt.Errorf("proto: NVMeoFDisk: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PCIAddress: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: NVMeoFDisk: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Domain", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nqn", wireType)
 			}
-			m.Domain = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2512,16 +5530,26 @@ func (m *PCIAddress) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Domain |= (uint32(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Nqn = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Bus", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Transport", wireType)
 			}
-			m.Bus = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2531,16 +5559,26 @@ func (m *PCIAddress) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Bus |= (uint32(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Transport = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Device", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Address", wireType)
 			}
-			m.Device = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2550,16 +5588,55 @@ func (m *PCIAddress) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Device |= (uint32(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Address = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Port", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Port = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Function", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field NamespaceId", wireType)
 			}
-			m.Function = 0
+			m.NamespaceId = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2569,7 +5646,7 @@ func (m *PCIAddress) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Function |= (uint32(b) & 0x7F) << shift
+				m.NamespaceId |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -2595,7 +5672,7 @@ func (m *PCIAddress) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SCSIDisk) Unmarshal(dAtA []byte) error {
+func (m *ISCSIDisk) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2618,17 +5695,17 @@ func (m *SCSIDisk) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SCSIDisk: wiretype end group for non-group")
+			return fmt.Errorf("proto: ISCSIDisk: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SCSIDisk: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ISCSIDisk: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Iqn", wireType)
 			}
-			m.Target = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowOim
@@ -2638,12 +5715,51 @@ func (m *SCSIDisk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Target |= (uint32(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Iqn = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Portal", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowOim
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthOim
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Portal = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Lun", wireType)
 			}
@@ -3197,50 +6313,83 @@ var (
 func init() { proto.RegisterFile("oim.proto", fileDescriptorOim) }
 
 var fileDescriptorOim = []byte{
-	// 718 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x55, 0x4b, 0x6f, 0xd3, 0x40,
-	0x10, 0x8e, 0x9b, 0xd6, 0x38, 0x13, 0xd2, 0x46, 0x4b, 0x9b, 0x5a, 0x06, 0x45, 0xd5, 0x22, 0x50,
-	0x2f, 0xa4, 0x34, 0xe5, 0x71, 0x41, 0x42, 0x34, 0x45, 0x90, 0x43, 0x51, 0x71, 0x45, 0x0f, 0x48,
-	0x28, 0x72, 0xec, 0x6d, 0xb2, 0x74, 0xed, 0x35, 0x5e, 0xdb, 0x28, 0x5c, 0x39, 0x71, 0x43, 0xe2,
-	0x3f, 0x21, 0x8e, 0xfc, 0x04, 0x54, 0xfe, 0x08, 0xda, 0xf5, 0x23, 0x69, 0x93, 0x22, 0xf5, 0x36,
-	0x8f, 0x6f, 0xbe, 0x99, 0x9d, 0x87, 0x0d, 0x35, 0x4e, 0xfd, 0x4e, 0x18, 0xf1, 0x98, 0x23, 0x5d,
-	0x8a, 0xe9, 0x43, 0xab, 0x3d, 0xe2, 0x7c, 0xc4, 0xc8, 0x8e, 0xb2, 0x0e, 0x93, 0xd3, 0x9d, 0xcf,
-	0x91, 0x13, 0x86, 0x24, 0x12, 0x19, 0x0e, 0x3f, 0x81, 0xb5, 0x63, 0x12, 0x9f, 0x38, 0x2c, 0x21,
-	0x36, 0xf9, 0x94, 0x10, 0x11, 0xa3, 0xbb, 0xb0, 0x92, 0x4a, 0xdd, 0xd4, 0xb6, 0xb4, 0xed, 0x7a,
-	0xb7, 0xd1, 0xc9, 0xa8, 0x3a, 0x19, 0x28, 0xf3, 0xe1, 0x5d, 0x58, 0x51, 0x3a, 0x42, 0xb0, 0x1c,
-	0x3a, 0xf1, 0x58, 0x81, 0x6b, 0xb6, 0x92, 0xd1, 0x7a, 0xc1, 0xb0, 0xa4, 0x8c, 0x79, 0xc8, 0x1a,
-	0x34, 0xa6, 0xa9, 0x42, 0x36, 0xc1, 0xf7, 0xa1, 0xf9, 0x2a, 0x37, 0x88, 0x22, 0xf9, 0x02, 0x3a,
-	0xfc, 0x14, 0x56, 0x67, 0x70, 0x21, 0x9b, 0xa0, 0x7b, 0xa0, 0x2b, 0x4e, 0x61, 0x6a, 0x5b, 0xd5,
-	0xf9, 0x1a, 0x73, 0x27, 0xfe, 0xa1, 0x41, 0xf3, 0xd0, 0x09, 0x4f, 0x38, 0x4b, 0xfc, 0xf2, 0x79,
-	0xb7, 0xa1, 0x96, 0x2a, 0xc3, 0x80, 0x7a, 0x79, 0x1a, 0x23, 0x33, 0xf4, 0x3d, 0xd4, 0x01, 0xdd,
-	0x77, 0x18, 0xe3, 0xae, 0x2a, 0xbd, 0xde, 0x5d, 0x2f, 0x88, 0x0f, 0x95, 0xf5, 0xc8, 0x89, 0x1c,
-	0x5f, 0xbc, 0xae, 0xd8, 0x39, 0x0a, 0x6d, 0xc3, 0xb2, 0x4b, 0xc2, 0xb1, 0x59, 0x55, 0x68, 0x54,
-	0xa0, 0x7b, 0x24, 0x1c, 0x97, 0x58, 0x85, 0xd8, 0x37, 0x40, 0x0f, 0x95, 0x05, 0xaf, 0xc2, 0xcd,
-	0x59, 0x36, 0xfc, 0x55, 0x03, 0x98, 0x06, 0xa0, 0x4d, 0xb8, 0x91, 0x08, 0x12, 0x4d, 0xab, 0xd3,
-	0xa5, 0xda, 0xf7, 0x50, 0x0b, 0x74, 0x41, 0xdc, 0x88, 0xc4, 0x79, 0x5b, 0x73, 0x0d, 0x59, 0x60,
-	0xf8, 0x3c, 0xa0, 0x31, 0x8f, 0x84, 0xaa, 0xa3, 0x66, 0x97, 0xba, 0x6a, 0x27, 0xe7, 0xcc, 0x5c,
-	0xce, 0xdb, 0xc9, 0x39, 0x93, 0xd3, 0xa1, 0xbe, 0x33, 0x22, 0xe6, 0x4a, 0x36, 0x1d, 0xa5, 0xe0,
-	0x18, 0x56, 0x67, 0x5a, 0x25, 0x9b, 0xbc, 0x07, 0xf5, 0xd0, 0xa5, 0x03, 0xc7, 0xf3, 0x22, 0x22,
-	0x44, 0xbe, 0x0d, 0xe5, 0x13, 0x8f, 0x7a, 0xfd, 0x17, 0x99, 0xc7, 0x86, 0xd0, 0xa5, 0xb9, 0x8c,
-	0x1e, 0x40, 0x4d, 0xb8, 0x82, 0x0e, 0x3c, 0x2a, 0xce, 0xf2, 0x1e, 0x36, 0x8b, 0x90, 0xe3, 0xde,
-	0x71, 0xff, 0x80, 0x8a, 0x33, 0xdb, 0x90, 0x10, 0x29, 0xe1, 0x8f, 0x00, 0x53, 0x22, 0xf9, 0x42,
-	0x8f, 0xfb, 0x0e, 0x0d, 0x54, 0xb2, 0x86, 0x9d, 0x6b, 0xa8, 0x09, 0xd5, 0x61, 0x22, 0x14, 0x5d,
-	0xc3, 0x96, 0xa2, 0x42, 0x92, 0x94, 0xba, 0x44, 0xbd, 0x58, 0x22, 0x95, 0x26, 0x7b, 0x71, 0x9a,
-	0x04, 0x6e, 0x4c, 0x79, 0xa0, 0xde, 0xdc, 0xb0, 0x4b, 0x1d, 0x3f, 0x02, 0xa3, 0xa8, 0x40, 0xc6,
-	0xc7, 0x4e, 0x34, 0x22, 0x71, 0x91, 0x29, 0xd3, 0x64, 0x26, 0x96, 0x04, 0x45, 0x26, 0x96, 0x04,
-	0x78, 0x17, 0xd0, 0xbb, 0xc0, 0xbf, 0xce, 0x12, 0x61, 0x04, 0xcd, 0x0b, 0x21, 0x72, 0xd7, 0x0f,
-	0xc1, 0x3a, 0x8a, 0x78, 0x4a, 0x05, 0xe5, 0x41, 0x36, 0xfd, 0xfd, 0x03, 0x92, 0xce, 0xd0, 0x0d,
-	0x3d, 0x92, 0x0e, 0x02, 0xc7, 0x27, 0x05, 0x9d, 0x34, 0xbc, 0x71, 0x7c, 0x75, 0x61, 0x82, 0x7e,
-	0xc9, 0x8e, 0xa9, 0x6a, 0x2b, 0x19, 0x5b, 0x60, 0x2e, 0xa4, 0x93, 0xa9, 0x1e, 0x43, 0xab, 0x37,
-	0x26, 0xee, 0xd9, 0xf5, 0xd2, 0xe0, 0x16, 0xac, 0xcf, 0x85, 0x85, 0x6c, 0xd2, 0xfd, 0xa6, 0x81,
-	0x61, 0x93, 0x11, 0x15, 0x71, 0x34, 0x41, 0xcf, 0xc0, 0x28, 0x6e, 0x18, 0x6d, 0x96, 0x73, 0xbd,
-	0xf8, 0x01, 0xb1, 0x36, 0xe6, 0x1d, 0xb2, 0xae, 0x0a, 0x7a, 0x0e, 0xb5, 0xf2, 0x90, 0x91, 0x59,
-	0xa0, 0x2e, 0x7f, 0x03, 0xac, 0xd6, 0x02, 0x8f, 0x22, 0xe8, 0xfe, 0x5c, 0x02, 0xe8, 0xf1, 0x20,
-	0x8e, 0x38, 0x63, 0x24, 0x92, 0x7c, 0xe5, 0xce, 0x4e, 0xf9, 0x2e, 0x5f, 0xfc, 0x94, 0xef, 0xe2,
-	0x82, 0xe3, 0x0a, 0x7a, 0x09, 0xf5, 0x99, 0x49, 0x21, 0xab, 0x00, 0xce, 0x4f, 0xdc, 0x32, 0x17,
-	0xfa, 0x32, 0x9a, 0x0f, 0x70, 0x6b, 0xc1, 0x34, 0x10, 0x2e, 0x6f, 0xe5, 0xca, 0xc9, 0x5b, 0x5b,
-	0xff, 0xc5, 0x64, 0xf4, 0x6f, 0x61, 0xed, 0xd2, 0x64, 0x50, 0xbb, 0xfc, 0xd2, 0x2c, 0x9c, 0xb4,
-	0x75, 0xe7, 0x4a, 0xbf, 0xa2, 0xdc, 0xdf, 0xf8, 0x75, 0xde, 0xd6, 0x7e, 0x9f, 0xb7, 0xb5, 0x3f,
-	0xe7, 0x6d, 0xed, 0xfb, 0xdf, 0x76, 0xe5, 0x7d, 0x95, 0x53, 0x7f, 0xa8, 0xab, 0x9f, 0xc2, 0xde,
-	0xbf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x09, 0x6d, 0x9f, 0x26, 0x49, 0x06, 0x00, 0x00,
+	// 1237 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xdd, 0x6e, 0x1b, 0xc5,
+	0x17, 0xf7, 0xda, 0x8e, 0x3f, 0x8e, 0xeb, 0xc4, 0x9d, 0xb6, 0xe9, 0x6a, 0xdb, 0xbf, 0xff, 0x61,
+	0x10, 0xc8, 0x05, 0xea, 0xb6, 0x6e, 0xa1, 0x5c, 0x20, 0x55, 0x89, 0x13, 0x9c, 0x95, 0x48, 0x30,
+	0xeb, 0x34, 0x48, 0x48, 0xc8, 0x5a, 0xaf, 0x27, 0xf6, 0x92, 0xfd, 0xca, 0xee, 0xd8, 0xd4, 0xdc,
+	0x72, 0xd1, 0x2b, 0x24, 0x5e, 0x81, 0x67, 0xe0, 0x19, 0x40, 0x5c, 0xf2, 0x08, 0x28, 0xbc, 0x08,
+	0x9a, 0x99, 0x9d, 0x5d, 0xdb, 0xb1, 0xd3, 0xf6, 0x6e, 0xce, 0x39, 0xbf, 0xf3, 0x7d, 0xf6, 0x9c,
+	0x85, 0xb2, 0x6f, 0xbb, 0xcd, 0x20, 0xf4, 0xa9, 0x8f, 0x0a, 0xec, 0x39, 0x7d, 0xac, 0xd5, 0x47,
+	0xbe, 0x3f, 0x72, 0xc8, 0x23, 0xce, 0x1d, 0x4c, 0xce, 0x1e, 0xfd, 0x18, 0x9a, 0x41, 0x40, 0xc2,
+	0x48, 0xe0, 0xf0, 0x6f, 0x0a, 0x6c, 0xf5, 0x08, 0x3d, 0x35, 0x9d, 0x09, 0x31, 0xc8, 0xc5, 0x84,
+	0x44, 0x14, 0xbd, 0x0f, 0x1b, 0x53, 0x46, 0xab, 0xca, 0x8e, 0xd2, 0xa8, 0xb4, 0xaa, 0x4d, 0x61,
+	0xab, 0x29, 0x40, 0x42, 0x86, 0xfe, 0x0f, 0x15, 0x4a, 0x9d, 0x7e, 0x44, 0x2c, 0xdf, 0x1b, 0x46,
+	0x6a, 0x76, 0x47, 0x69, 0x54, 0x0d, 0xa0, 0xd4, 0xe9, 0x09, 0x0e, 0x3a, 0x84, 0x9b, 0xe4, 0x55,
+	0x40, 0x2c, 0x4a, 0x86, 0xfd, 0x90, 0x4c, 0xed, 0xc8, 0xf6, 0x3d, 0x35, 0xc7, 0x2d, 0xde, 0x6b,
+	0x8a, 0xa8, 0x9a, 0x32, 0xaa, 0xa6, 0xee, 0xd1, 0xcf, 0x9e, 0x09, 0xfb, 0x35, 0xa9, 0x65, 0xc4,
+	0x4a, 0xf8, 0x08, 0x36, 0xb8, 0x08, 0x21, 0xc8, 0x07, 0x26, 0x1d, 0xf3, 0xb8, 0xca, 0x06, 0x7f,
+	0xa3, 0xdb, 0x32, 0xd8, 0x2c, 0x67, 0xc6, 0xd1, 0x69, 0x50, 0x5a, 0xf0, 0x99, 0x33, 0x12, 0x1a,
+	0x7f, 0x0c, 0xd5, 0x34, 0xe3, 0xc0, 0x99, 0x2d, 0x80, 0x95, 0x25, 0x30, 0x85, 0x9a, 0x04, 0x47,
+	0xb2, 0x3e, 0x4f, 0xa0, 0x68, 0x8d, 0x4d, 0x6f, 0x44, 0x22, 0x55, 0xd9, 0xc9, 0x35, 0x2a, 0xad,
+	0xbb, 0xb2, 0x42, 0x4b, 0x95, 0x34, 0x24, 0x0e, 0x3d, 0x84, 0xc2, 0x68, 0x62, 0x86, 0xbc, 0x50,
+	0x4c, 0xe3, 0x8e, 0xd4, 0x90, 0x49, 0x76, 0x98, 0xd4, 0x88, 0x41, 0xf8, 0x05, 0x54, 0x17, 0x04,
+	0x2b, 0x33, 0x9f, 0x0f, 0x3b, 0xbb, 0x14, 0xf6, 0x27, 0xb0, 0x39, 0x17, 0xf6, 0x9b, 0x92, 0xfc,
+	0x10, 0x6a, 0x9d, 0xe5, 0x24, 0x57, 0x78, 0xc4, 0xcf, 0x61, 0xb3, 0xb3, 0x68, 0xf5, 0x03, 0x28,
+	0xf0, 0x82, 0xcb, 0x4a, 0x2c, 0xcd, 0x4a, 0x2c, 0xc4, 0x0d, 0x40, 0xdf, 0x9a, 0xd4, 0x1a, 0xbf,
+	0xd9, 0xc5, 0xef, 0x0a, 0xd4, 0x16, 0xa0, 0xcc, 0xcb, 0x13, 0xc8, 0xd3, 0x59, 0x20, 0xe6, 0x71,
+	0xb3, 0xf5, 0x3f, 0xe9, 0x63, 0x19, 0xd7, 0x3c, 0x99, 0x05, 0xc4, 0xe0, 0xd0, 0xb9, 0xc0, 0xb2,
+	0xd7, 0x04, 0x76, 0xed, 0x9c, 0x3c, 0x80, 0x3c, 0x33, 0x88, 0x6e, 0x40, 0xa9, 0x77, 0xbc, 0xdb,
+	0xed, 0x1d, 0x7e, 0x7d, 0x52, 0xcb, 0xa0, 0x22, 0xe4, 0xba, 0x2f, 0x4f, 0x6a, 0x0a, 0x02, 0x28,
+	0xec, 0x1f, 0x7c, 0x75, 0x70, 0x72, 0x50, 0xcb, 0xe2, 0x2d, 0xa8, 0x1e, 0xbc, 0x0a, 0xfc, 0x90,
+	0xc6, 0xa9, 0xe1, 0x2e, 0x54, 0x24, 0xe3, 0xed, 0xcb, 0x74, 0x6d, 0x47, 0xbb, 0x50, 0xd5, 0xdd,
+	0x39, 0x17, 0x48, 0x85, 0x62, 0x48, 0x02, 0xc7, 0xb4, 0x44, 0x5d, 0x4a, 0x86, 0x24, 0xdf, 0x32,
+	0x77, 0xfc, 0x00, 0x2a, 0xba, 0x9b, 0xc6, 0x78, 0xdd, 0x80, 0xfc, 0xa1, 0x40, 0xed, 0xc8, 0x0c,
+	0x4e, 0x7d, 0x67, 0xe2, 0x26, 0x6b, 0xe2, 0x1e, 0x94, 0xa7, 0x9c, 0xd1, 0xb7, 0x87, 0x71, 0x0f,
+	0x4b, 0x82, 0xa1, 0x0f, 0x51, 0x13, 0x0a, 0xae, 0xe9, 0x38, 0xbe, 0xc5, 0x13, 0xa9, 0xb4, 0x6e,
+	0xcb, 0x18, 0x8e, 0x38, 0xb7, 0x6b, 0x86, 0xa6, 0x1b, 0x1d, 0x66, 0x8c, 0x18, 0x85, 0x1a, 0x90,
+	0xb7, 0x48, 0x30, 0x8e, 0x17, 0x04, 0x92, 0xe8, 0x36, 0x09, 0xc6, 0x09, 0x96, 0x23, 0xd0, 0x63,
+	0x00, 0x3b, 0xb2, 0x22, 0xbb, 0x6f, 0x8d, 0xcd, 0x40, 0xcd, 0x73, 0xfc, 0x4d, 0x89, 0xd7, 0x7b,
+	0xed, 0x9e, 0xde, 0x3e, 0xdc, 0xed, 0x1a, 0x65, 0x0e, 0x6a, 0x8f, 0xcd, 0x60, 0xaf, 0x04, 0x85,
+	0x80, 0xdb, 0xc0, 0x9b, 0x70, 0x63, 0xde, 0x3f, 0xfe, 0x59, 0x01, 0x48, 0x5d, 0xa0, 0xbb, 0x50,
+	0x9c, 0x44, 0x24, 0x4c, 0xf3, 0x29, 0x30, 0x52, 0x1f, 0xa2, 0x6d, 0x28, 0x44, 0xc4, 0x0a, 0x09,
+	0x8d, 0xb7, 0x4c, 0x4c, 0xb1, 0x9a, 0xb9, 0xbe, 0x67, 0x53, 0x3f, 0x8c, 0x78, 0xe4, 0x65, 0x23,
+	0xa1, 0xf9, 0x74, 0xfb, 0xbe, 0xa3, 0xe6, 0xe3, 0xe9, 0xf6, 0x7d, 0x87, 0x2d, 0x2b, 0xdb, 0x35,
+	0x47, 0x44, 0xdd, 0x10, 0xcb, 0x8a, 0x13, 0xf8, 0x39, 0x94, 0x93, 0xb8, 0x99, 0x1a, 0x73, 0x2a,
+	0x3f, 0x0a, 0xf6, 0x5e, 0xe7, 0x1e, 0xbf, 0xce, 0xc2, 0xe6, 0x5c, 0x5b, 0x58, 0x17, 0x9f, 0x42,
+	0x25, 0xb0, 0xec, 0xbe, 0x39, 0x1c, 0x86, 0x24, 0x8a, 0x54, 0x65, 0xb1, 0x9c, 0xdd, 0xb6, 0xbe,
+	0x2b, 0x24, 0x06, 0x04, 0x96, 0x1d, 0xbf, 0xd1, 0x43, 0x28, 0xf3, 0x8a, 0x0e, 0xed, 0xe8, 0x3c,
+	0xee, 0x57, 0x2d, 0x59, 0x69, 0xed, 0x9e, 0xbe, 0x6f, 0x47, 0xe7, 0x46, 0x89, 0x41, 0xd8, 0x0b,
+	0x7d, 0x04, 0xa5, 0x81, 0x73, 0x2e, 0xd0, 0xa2, 0x5f, 0x5b, 0x12, 0xbd, 0xe7, 0x9c, 0x73, 0x70,
+	0x71, 0x20, 0x1e, 0x2c, 0x1e, 0x6f, 0xea, 0x12, 0xff, 0x4c, 0xc0, 0xf3, 0x8b, 0xf1, 0x1c, 0x9f,
+	0x1e, 0x11, 0xff, 0x4b, 0xae, 0x01, 0x02, 0xc6, 0x95, 0x92, 0x16, 0x73, 0x9d, 0x8d, 0x15, 0x2d,
+	0xe6, 0x2a, 0xa2, 0xc5, 0xec, 0x89, 0x7f, 0x00, 0x48, 0x73, 0x63, 0xf5, 0x1a, 0xfa, 0xae, 0x69,
+	0x8b, 0x41, 0xae, 0x1a, 0x31, 0x85, 0x6a, 0x90, 0x1b, 0x4c, 0xe4, 0xad, 0x62, 0x4f, 0x8e, 0x24,
+	0x53, 0xdb, 0x22, 0x6a, 0x2e, 0x46, 0x72, 0x8a, 0x35, 0xf6, 0x6c, 0xe2, 0x59, 0x94, 0x7d, 0x0c,
+	0x79, 0x2e, 0x49, 0x68, 0xfc, 0x0c, 0x4a, 0x32, 0x04, 0xa6, 0x4f, 0xcd, 0x70, 0x44, 0xa8, 0xf4,
+	0x24, 0x28, 0xe6, 0xc9, 0x99, 0x78, 0xd2, 0x93, 0x33, 0xf1, 0x70, 0x19, 0x8a, 0x71, 0x71, 0xf0,
+	0x2f, 0x0a, 0x40, 0x9a, 0x39, 0xc3, 0x7a, 0x17, 0x5e, 0xdc, 0x70, 0xf6, 0x44, 0xf7, 0xa1, 0x4c,
+	0x43, 0xd3, 0x8b, 0xd8, 0xc7, 0x19, 0xb7, 0x3c, 0x65, 0xb0, 0x0f, 0x5f, 0xb6, 0x57, 0xcc, 0x9c,
+	0x24, 0xc5, 0xc8, 0x85, 0x34, 0x1d, 0xb9, 0x90, 0xa2, 0xf7, 0xe0, 0x86, 0x67, 0xba, 0x24, 0x0a,
+	0x4c, 0x8b, 0x7f, 0xa8, 0x1b, 0x3c, 0xa4, 0x4a, 0xc2, 0xd3, 0x87, 0xb8, 0x13, 0xcf, 0x9f, 0x8c,
+	0xc6, 0x4e, 0xa3, 0xb1, 0x2f, 0x3c, 0x96, 0x23, 0xb3, 0x64, 0x3a, 0x72, 0xfa, 0x04, 0x25, 0x73,
+	0xcc, 0xa5, 0x39, 0x3e, 0x01, 0xf4, 0xd2, 0x73, 0xdf, 0x65, 0x4f, 0x60, 0x04, 0xb5, 0x05, 0x95,
+	0xc0, 0x99, 0xe1, 0x23, 0xd0, 0xba, 0xa1, 0x2f, 0x56, 0x8f, 0xf8, 0x5c, 0xf7, 0xf6, 0xc9, 0x74,
+	0xce, 0xdc, 0x60, 0x48, 0xa6, 0x7d, 0x96, 0x81, 0x34, 0xc7, 0x18, 0xc7, 0xa6, 0xcb, 0xff, 0x10,
+	0x22, 0xfb, 0x27, 0x12, 0x6f, 0x4f, 0xfe, 0xc6, 0x1a, 0xa8, 0x2b, 0xcd, 0x31, 0x57, 0x9f, 0xc2,
+	0x76, 0x7b, 0x4c, 0xac, 0xf3, 0x77, 0x73, 0x83, 0xb7, 0xe1, 0xf6, 0x15, 0xb5, 0xc0, 0x99, 0xb5,
+	0x5e, 0xe7, 0xa0, 0x64, 0x90, 0x91, 0x1d, 0xd1, 0x70, 0x86, 0xbe, 0x80, 0x92, 0xbc, 0xc1, 0x68,
+	0xdd, 0x1f, 0x82, 0x76, 0xe7, 0xaa, 0x80, 0xc5, 0x95, 0x41, 0x2f, 0xa0, 0x2c, 0x59, 0x11, 0x52,
+	0x97, 0x51, 0xf2, 0x86, 0x6a, 0xdb, 0x2b, 0x24, 0x89, 0x81, 0xce, 0x55, 0x03, 0x9d, 0xb5, 0x06,
+	0x3a, 0xcb, 0x06, 0x3a, 0x50, 0x99, 0xbb, 0xb0, 0x48, 0x5b, 0x79, 0x76, 0x85, 0x11, 0x75, 0xdd,
+	0x49, 0xc6, 0x99, 0xc7, 0x0a, 0xfa, 0x1c, 0x0a, 0xe2, 0x18, 0xa2, 0x24, 0xdb, 0x85, 0x6b, 0xa9,
+	0xdd, 0x5a, 0x66, 0xcf, 0x69, 0xea, 0xee, 0xa2, 0xa6, 0xee, 0xae, 0xd4, 0x9c, 0xbb, 0x64, 0x38,
+	0xd3, 0x50, 0x5a, 0x7f, 0x66, 0x01, 0xda, 0xbe, 0x47, 0x43, 0xdf, 0x71, 0x48, 0xc8, 0x8a, 0x91,
+	0x2c, 0xca, 0xb4, 0x18, 0xcb, 0x27, 0x4d, 0xdb, 0x5e, 0x21, 0x11, 0xc5, 0x38, 0x80, 0xca, 0xdc,
+	0x9c, 0xa6, 0xc5, 0xb8, 0x3a, 0xef, 0x9a, 0xba, 0x52, 0x26, 0xcc, 0x7c, 0x0f, 0xb7, 0x56, 0xcc,
+	0x22, 0xc2, 0xc9, 0x82, 0x5e, 0x3b, 0xf7, 0xda, 0xce, 0xb5, 0x18, 0x61, 0xfe, 0x1b, 0xd8, 0x5a,
+	0x9a, 0x4b, 0x54, 0x4f, 0x4e, 0xe9, 0xca, 0x39, 0xd7, 0xee, 0xaf, 0x95, 0x73, 0x93, 0x7b, 0x77,
+	0xfe, 0xba, 0xac, 0x2b, 0x7f, 0x5f, 0xd6, 0x95, 0x7f, 0x2e, 0xeb, 0xca, 0xaf, 0xff, 0xd6, 0x33,
+	0xdf, 0xe5, 0x7c, 0xdb, 0x1d, 0x14, 0xf8, 0xaf, 0xfb, 0xd3, 0xff, 0x06, 0x00, 0x82, 0x78, 0xfb,
+	0x61, 0x73, 0x0c, 0x00, 0x00,
 }
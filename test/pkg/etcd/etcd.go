@@ -0,0 +1,225 @@
+/*
+Copyright 2018 Intel Corporation.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package etcd adds support for the TEST_ETCD_ENDPOINTS and
+// TEST_ETCD_BINARY env variables to test binaries and manages the
+// etcd instance for tests. Without those, etcd runs embedded in the
+// test binary.
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/embed"
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+
+	"github.com/intel/oim/pkg/log"
+	"github.com/intel/oim/pkg/oim-common"
+)
+
+var (
+	// Client is connected to the running etcd, nil if none.
+	Client *clientv3.Client
+	// Endpoints contains the client URLs of the running etcd.
+	Endpoints []string
+
+	etcdEndpoints = os.Getenv("TEST_ETCD_ENDPOINTS")
+	etcdApp       = os.Getenv("TEST_ETCD_BINARY")
+	etcdCmd       *exec.Cmd
+	etcdEmbedded  *embed.Etcd
+	tmpDir        string
+	etcdOut       io.WriteCloser
+)
+
+// Init connects to an existing etcd (TEST_ETCD_ENDPOINTS), starts a
+// new one (TEST_ETCD_BINARY) or, if neither is set, starts etcd inside
+// the test binary. Must be matched by a Finalize call, even after a
+// failure.
+func Init() error {
+	if Client != nil || etcdCmd != nil || etcdEmbedded != nil {
+		return errors.New("Finalize not called or failed")
+	}
+
+	switch {
+	case etcdEndpoints != "":
+		Endpoints = strings.Split(etcdEndpoints, ",")
+	case etcdApp == "":
+		if err := startEmbedded(); err != nil {
+			return err
+		}
+	default:
+		t, err := ioutil.TempDir("", "etcd")
+		if err != nil {
+			return errors.Wrap(err, "etcd temp directory")
+		}
+		tmpDir = t
+		clientURL, err := freeURL()
+		if err != nil {
+			return err
+		}
+		peerURL, err := freeURL()
+		if err != nil {
+			return err
+		}
+		etcdOut = oimcommon.LogWriter(log.L().With("at", "etcd"))
+		log.L().Infof("Starting %s", etcdApp)
+		cmd := exec.Command(etcdApp,
+			"--data-dir", filepath.Join(tmpDir, "data"),
+			"--listen-client-urls", clientURL,
+			"--advertise-client-urls", clientURL,
+			"--listen-peer-urls", peerURL,
+			"--initial-advertise-peer-urls", peerURL,
+			"--initial-cluster", "default="+peerURL,
+		)
+		cmd.Stdout = etcdOut
+		cmd.Stderr = etcdOut
+		if err := cmd.Start(); err != nil {
+			return errors.Wrapf(err, "start %s", etcdApp)
+		}
+		etcdCmd = cmd
+		Endpoints = []string{clientURL}
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   Endpoints,
+		DialTimeout: 30 * time.Second,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "connect to etcd at %v", Endpoints)
+	}
+	Client = client
+
+	// Wait for etcd to become ready.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for {
+		if _, err := Client.Get(ctx, "oim-test-ready"); err == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Timed out waiting for etcd at %v", Endpoints)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
+// Finalize frees any resources allocated by Init. Safe to call without
+// Init or after Init failure.
+func Finalize() error {
+	if Client != nil {
+		if err := Client.Close(); err != nil {
+			log.L().Errorw("close etcd client", "error", err)
+		}
+		Client = nil
+	}
+	if etcdEmbedded != nil {
+		log.L().Infof("Stopping embedded etcd")
+		etcdEmbedded.Close()
+		etcdEmbedded = nil
+		// etcdOut gets closed below.
+		capnslog.SetFormatter(capnslog.NewPrettyFormatter(ioutil.Discard, false))
+	}
+	if etcdCmd != nil {
+		log.L().Infof("Stopping etcd %d", etcdCmd.Process.Pid)
+		etcdCmd.Process.Kill() // nolint: gosec
+		etcdCmd.Wait()         // nolint: gosec
+		etcdCmd = nil
+	}
+	if etcdOut != nil {
+		if err := etcdOut.Close(); err != nil {
+			return err
+		}
+		etcdOut = nil
+	}
+	if tmpDir != "" {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			return err
+		}
+		tmpDir = ""
+	}
+	Endpoints = nil
+	return nil
+}
+
+// startEmbedded runs etcd inside the current process.
+func startEmbedded() error {
+	t, err := ioutil.TempDir("", "etcd")
+	if err != nil {
+		return errors.Wrap(err, "etcd temp directory")
+	}
+	tmpDir = t
+	clientURL, err := freeURL()
+	if err != nil {
+		return err
+	}
+	peerURL, err := freeURL()
+	if err != nil {
+		return err
+	}
+	cfg := embed.NewConfig()
+	cfg.Dir = filepath.Join(tmpDir, "data")
+	for _, u := range []struct {
+		urls *[]url.URL
+		url  string
+	}{
+		{&cfg.LCUrls, clientURL},
+		{&cfg.ACUrls, clientURL},
+		{&cfg.LPUrls, peerURL},
+		{&cfg.APUrls, peerURL},
+	} {
+		parsed, err := url.Parse(u.url)
+		if err != nil {
+			return errors.Wrapf(err, "parse %s", u.url)
+		}
+		*u.urls = []url.URL{*parsed}
+	}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	// etcd logs via capnslog, by default to stderr.
+	etcdOut = oimcommon.LogWriter(log.L().With("at", "etcd"))
+	capnslog.SetFormatter(capnslog.NewPrettyFormatter(etcdOut, false))
+
+	log.L().Infof("Starting embedded etcd")
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		return errors.Wrap(err, "start embedded etcd")
+	}
+	etcdEmbedded = e
+	select {
+	case <-e.Server.ReadyNotify():
+	case err := <-e.Err():
+		return errors.Wrap(err, "embedded etcd")
+	case <-time.After(30 * time.Second):
+		return errors.New("Timed out waiting for embedded etcd")
+	}
+	Endpoints = []string{clientURL}
+	return nil
+}
+
+// freeURL returns a http://localhost:<port> URL with a port that was
+// free when checking it.
+func freeURL() (string, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return "", errors.Wrap(err, "find free port")
+	}
+	defer listener.Close()
+	return "http://" + listener.Addr().String(), nil
+}